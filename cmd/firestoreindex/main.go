@@ -0,0 +1,111 @@
+// Command firestoreindex advises the composite indexes of the filter type by firestore.AdviseIndexes, and writes them to firestore.indexes.json by firestore.WriteIndexes, such as:
+//
+//	firestoreindex -pkg github.com/acme/app/internal/user -filter UserFilter -model User -collection users -sort "-createdDate;username,id"
+//
+// It must run in the module of the package, because it builds and runs a program which imports the package.
+// The indexes of the existing file are kept, so the file can be deployed by "firebase deploy --only firestore:indexes".
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const program = `package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	f "github.com/core-go/search/firestore"
+	"github.com/core-go/search/firestore/query"
+	p {{printf "%q" .Package}}
+)
+
+func main() {
+	indexes, err := f.AdviseIndexes({{printf "%q" .Collection}}, reflect.TypeOf(p.{{.Filter}}{}), reflect.TypeOf(p.{{.Model}}{}), query.BuildQueryByType, f.BuildSorts{{range .Sorts}}, {{printf "%q" .}}{{end}})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if err = f.WriteIndexes({{printf "%q" .Out}}, indexes); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	fmt.Printf("%d indexes of {{.Filter}} are written to %s\n", len(indexes), {{printf "%q" .Out}})
+}
+`
+
+type params struct {
+	Package    string
+	Filter     string
+	Model      string
+	Collection string
+	Sorts      []string
+	Out        string
+}
+
+func main() {
+	pkg := flag.String("pkg", "", "the import path of the package of the filter and the model types")
+	filter := flag.String("filter", "", "the filter type, such as UserFilter")
+	model := flag.String("model", "", "the model type, such as User")
+	collection := flag.String("collection", "", "the collection, such as users")
+	sorts := flag.String("sort", "", "the sorts of the search, separated by \";\", such as \"-createdDate;username,id\"")
+	out := flag.String("out", "firestore.indexes.json", "the file of the indexes")
+	flag.Parse()
+
+	if len(*pkg) == 0 || len(*filter) == 0 || len(*model) == 0 || len(*collection) == 0 {
+		exit(fmt.Errorf("-pkg, -filter, -model and -collection are required"))
+	}
+	if !isIdentifier(*filter) || !isIdentifier(*model) {
+		exit(fmt.Errorf("-filter and -model must be the exported type names"))
+	}
+	filename, err := filepath.Abs(*out)
+	if err != nil {
+		exit(err)
+	}
+	p := params{Package: *pkg, Filter: *filter, Model: *model, Collection: *collection, Sorts: make([]string, 0), Out: filename}
+	for _, s := range strings.Split(*sorts, ";") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			p.Sorts = append(p.Sorts, s)
+		}
+	}
+	var source bytes.Buffer
+	if err = template.Must(template.New("program").Parse(program)).Execute(&source, p); err != nil {
+		exit(err)
+	}
+	dir, err := os.MkdirTemp(".", "firestoreindex")
+	if err != nil {
+		exit(err)
+	}
+	defer os.RemoveAll(dir)
+	if err = os.WriteFile(filepath.Join(dir, "main.go"), source.Bytes(), 0644); err != nil {
+		exit(err)
+	}
+	cmd := exec.Command("go", "run", "./"+filepath.Base(dir))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		os.RemoveAll(dir)
+		exit(err)
+	}
+}
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return len(s) > 0 && s[0] >= 'A' && s[0] <= 'Z'
+}
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "firestoreindex:", err.Error())
+	os.Exit(2)
+}
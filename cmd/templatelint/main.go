@@ -0,0 +1,97 @@
+// Command templatelint checks the xml query templates against the filter types, such as:
+//
+//	templatelint -dir ./internal/user -type UserFilter -render configs/*.xml
+//	templatelint -dir ./internal -map "user=UserFilter,role=RoleFilter" -unused configs/query.xml
+//
+// It exits with the status 1 if there is any issue.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	t "github.com/core-go/search/template"
+	"github.com/core-go/search/template/lint"
+	"github.com/core-go/search/template/xml"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "the directory of the package of the filter types")
+	typeName := flag.String("type", "", "the filter type of all the templates")
+	mapping := flag.String("map", "", "the filter types by the template ids, such as \"user=UserFilter,role=RoleFilter\"")
+	render := flag.Bool("render", false, "render the templates by the sample filters to check the sql")
+	unused := flag.Bool("unused", false, "report the fields of the filter which are not used")
+	correct := flag.Bool("correct", false, "escape the operators, such as \" > \" and \" && \", before parsing")
+	flag.Parse()
+
+	types, err := parseMapping(*mapping)
+	if err != nil {
+		exit(err)
+	}
+	if len(*typeName) == 0 && len(types) == 0 {
+		exit(fmt.Errorf("-type or -map is required"))
+	}
+	var trim func(string) string
+	if *correct {
+		trim = xml.Correct
+	}
+	templates, err := t.LoadTemplates(trim, flag.Args()...)
+	if err != nil {
+		exit(err)
+	}
+	ids := make([]string, 0, len(templates))
+	for id := range templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	schemas := make(map[string]lint.Schema)
+	count := 0
+	for _, id := range ids {
+		name, ok := types[id]
+		if !ok {
+			name = *typeName
+		}
+		if len(name) == 0 {
+			continue
+		}
+		schema, ok := schemas[name]
+		if !ok {
+			if schema, err = lint.ParseSchema(*dir, name); err != nil {
+				exit(err)
+			}
+			schemas[name] = schema
+		}
+		linter := lint.NewLinter(schema, func(l *lint.Linter) {
+			l.Render = *render
+			l.Unused = *unused
+		})
+		for _, issue := range linter.Lint(id, *templates[id]) {
+			fmt.Println(issue.String())
+			count++
+		}
+	}
+	if count > 0 {
+		os.Exit(1)
+	}
+}
+func parseMapping(s string) (map[string]string, error) {
+	types := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); len(pair) == 0 {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 || len(strings.TrimSpace(kv[1])) == 0 {
+			return nil, fmt.Errorf("invalid mapping %q, which must be id=Type", pair)
+		}
+		types[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return types, nil
+}
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "templatelint:", err.Error())
+	os.Exit(2)
+}
@@ -1,174 +1,250 @@
 package convert
 
 import (
-	s "github.com/core-go/search"
+	"database/sql/driver"
 	"reflect"
 	"strings"
+	"time"
+
+	s "github.com/core-go/search"
 )
 
 const (
 	desc = "desc"
 	asc  = "asc"
 )
-func ToMap(in interface{}, modelType *reflect.Type, opts...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
-	return ToMapWithFields(in, "", modelType, opts...)
+
+// Mapper converts the filter into the map of the template parameters. The keys are the json names, and the nested structs are the nested maps,
+// so they are the dotted paths of template.ValueOf, such as "address.city".
+type Mapper struct {
+	BuildSort func(string, reflect.Type) string
+	// Location is the location of the times, such as time.UTC. The times are not converted if it is nil.
+	Location *time.Location
+	// Text converts the strings by the operator tag, such as "%abc%" of "like". The strings are not converted if it is nil.
+	Text func(value string, operator string) string
+	// Types and Fields are the converters of the values by the types and by the paths, such as "address.city". The converters of the paths are used first.
+	// If the converter returns nil, the value is not in the map.
+	Types  map[reflect.Type]func(interface{}) interface{}
+	Fields map[string]func(interface{}) interface{}
+}
+
+func NewMapper(options ...func(*Mapper)) *Mapper {
+	m := &Mapper{BuildSort: BuildSort, Text: TextByOperator, Types: make(map[reflect.Type]func(interface{}) interface{}), Fields: make(map[string]func(interface{}) interface{})}
+	for _, opt := range options {
+		opt(m)
+	}
+	return m
+}
+
+var mapper = NewMapper()
+
+func ToMap(in interface{}, modelType *reflect.Type, opts ...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
+	return mapper.ToMapWithFields(in, "", modelType, opts...)
+}
+func ToMapWithFields(in interface{}, sfields string, modelType *reflect.Type, opts ...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
+	return mapper.ToMapWithFields(in, sfields, modelType, opts...)
+}
+func (m *Mapper) ToMap(in interface{}, modelType *reflect.Type, opts ...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
+	return m.ToMapWithFields(in, "", modelType, opts...)
 }
-func ToMapWithFields(in interface{}, sfields string, modelType *reflect.Type, opts...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
-	var buildSort func(string, reflect.Type) string
+func (m *Mapper) ToMapWithFields(in interface{}, sfields string, modelType *reflect.Type, opts ...func(sortString string, modelType reflect.Type) string) map[string]interface{} {
+	buildSort := m.BuildSort
 	if len(opts) > 0 && opts[0] != nil {
 		buildSort = opts[0]
-	} else {
+	}
+	if buildSort == nil {
 		buildSort = BuildSort
 	}
 	out := make(map[string]interface{})
-	v := reflect.ValueOf(in)
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+	v := reflect.Indirect(reflect.ValueOf(in))
+	if v.Kind() != reflect.Struct {
+		return out
 	}
 	typ := v.Type()
 	for i := 0; i < v.NumField(); i++ {
 		f := v.Field(i)
-		fv := f.Interface()
-		k := f.Kind()
-		if k == reflect.Ptr {
-			if f.IsNil() {
-				continue
-			} else {
-				fv = reflect.Indirect(reflect.ValueOf(fv)).Interface()
-			}
-		} else if k == reflect.Slice {
-			if f.IsNil() {
-				continue
-			}
+		if !typ.Field(i).IsExported() || isNil(f) {
+			continue
 		}
-		sv, ok := fv.(string)
-		if ok {
-			if len(sv) > 0 {
-				n := getTag(typ.Field(i), "json")
-				m := getTag(typ.Field(i), "operator")
-				w := sv
-				if m == "like" {
-					w = Q(sv)
-				} else if m != "=" {
-					w = Prefix(sv)
-				}
-				out[n] = w
-			}
+		if filter, ok := reflect.Indirect(f).Interface().(s.Filter); ok {
+			mapFilter(out, filter, typ, sfields, modelType, buildSort)
 			continue
 		}
-		if v, ok := fv.(s.Filter); ok {
-			if modelType != nil {
-				if len(v.Fields) > 0 {
-					fields := make([]string, 0)
-					for _, key := range v.Fields {
-						i, _, columnName := getFieldByJson(*modelType, key)
-						if len(columnName) < 0 {
-							fields = fields[len(fields):]
-							break
-						} else if i > -1 {
-							fields = append(fields, columnName)
-						}
-					}
-					if len(fields) > 0 {
-						out["fields"] = strings.Join(fields, ",")
-					} else {
-						if len(sfields) > 0 {
-							out["fields"] = sfields
-						}
-					}
-				} else if len(sfields) > 0 {
-					out["fields"] = sfields
-				}
-			}
-			if len(v.Sort) > 0 {
-				t := typ
-				if *modelType != nil {
-					t = *modelType
-				}
-				sortString := buildSort(v.Sort, t)
-				if len(sortString) > 0 {
-					out["sort"] = sortString
-				}
-			}
-			if v.Excluding != nil && len(v.Excluding) > 0 {
-				out["excluding"] = v.Excluding
+		m.mapField(out, typ.Field(i), f, "")
+	}
+	return out
+}
+
+// mapField puts the value of the field into the map by its json name. The embedded structs are flattened, and the other structs are the nested maps,
+// except the values of the database, such as sql.NullString.
+func (m *Mapper) mapField(out map[string]interface{}, field reflect.StructField, f reflect.Value, prefix string) {
+	n := getTag(field, "json")
+	if n == "-" {
+		return
+	}
+	f = reflect.Indirect(f)
+	fv := f.Interface()
+	path := prefix + n
+	if convert, ok := m.Fields[path]; ok {
+		if cv := convert(fv); cv != nil {
+			out[n] = cv
+		}
+		return
+	}
+	if convert, ok := m.Types[f.Type()]; ok {
+		if cv := convert(fv); cv != nil {
+			out[n] = cv
+		}
+		return
+	}
+	switch x := fv.(type) {
+	case string:
+		if len(x) > 0 {
+			if m.Text != nil {
+				x = m.Text(x, getTag(field, "operator"))
 			}
-			if len(v.Q) > 0 {
-				out["q"] = strings.TrimSpace(v.Q)
+			out[n] = x
+		}
+		return
+	case time.Time:
+		out[n] = m.time(x)
+		return
+	case s.DateRange:
+		if sub := m.mapRange(f); len(sub) > 0 {
+			m.roundDates(sub, x)
+			out[n] = sub
+		}
+		return
+	}
+	if isRange(f.Type()) {
+		if sub := m.mapRange(f); len(sub) > 0 {
+			out[n] = sub
+		}
+		return
+	}
+	if _, ok := fv.(driver.Valuer); ok || f.Kind() != reflect.Struct || f.Type().PkgPath() == searchPkg {
+		out[n] = fv
+		return
+	}
+	sub := out
+	if !field.Anonymous {
+		sub = make(map[string]interface{})
+		prefix = path + "."
+	}
+	t := f.Type()
+	for i := 0; i < f.NumField(); i++ {
+		if t.Field(i).IsExported() && !isNil(f.Field(i)) {
+			m.mapField(sub, t.Field(i), f.Field(i), prefix)
+		}
+	}
+	if !field.Anonymous && len(sub) > 0 {
+		out[n] = sub
+	}
+}
+
+// mapRange puts all the bounds of the range which are not nil, such as min, max, bottom and top, into the sub map.
+func (m *Mapper) mapRange(f reflect.Value) map[string]interface{} {
+	sub := make(map[string]interface{})
+	t := f.Type()
+	for i := 0; i < f.NumField(); i++ {
+		if b := f.Field(i); !isNil(b) {
+			v := reflect.Indirect(b).Interface()
+			if tv, ok := v.(time.Time); ok {
+				v = m.time(tv)
 			}
-		} else {
-			n := getTag(typ.Field(i), "json")
-			if dateTime, ok := fv.(s.TimeRange); ok {
-				if dateTime.Min != nil || dateTime.Max != nil || dateTime.Top != nil {
-					sub := make(map[string]interface{})
-					if dateTime.Min != nil {
-						sub["min"] = *dateTime.Min
-					}
-					if dateTime.Max != nil {
-						sub["max"] = *dateTime.Max
-					} else if dateTime.Top != nil {
-						sub["top"] = *dateTime.Top
-					}
-					out[n] = sub
-				}
-			} else if numberRange, ok := fv.(s.NumberRange); ok {
-				if numberRange.Min != nil || numberRange.Max != nil || numberRange.Top != nil {
-					sub := make(map[string]interface{})
-					if numberRange.Min != nil {
-						sub["min"] = *numberRange.Min
-					}
-					if numberRange.Max != nil {
-						sub["max"] = *numberRange.Max
-					} else if numberRange.Top != nil {
-						sub["top"] = *numberRange.Top
-					}
-					out[n] = sub
-				}
-			} else if numberRange, ok := fv.(s.Int64Range); ok {
-				if numberRange.Min != nil || numberRange.Max != nil || numberRange.Top != nil {
-					sub := make(map[string]interface{})
-					if numberRange.Min != nil {
-						sub["min"] = *numberRange.Min
-					}
-					if numberRange.Max != nil {
-						sub["max"] = *numberRange.Max
-					} else if numberRange.Top != nil {
-						sub["top"] = *numberRange.Top
-					}
-					out[n] = sub
-				}
-			} else if numberRange, ok := fv.(s.IntRange); ok {
-				if numberRange.Min != nil || numberRange.Max != nil || numberRange.Top != nil {
-					sub := make(map[string]interface{})
-					if numberRange.Min != nil {
-						sub["min"] = *numberRange.Min
-					}
-					if numberRange.Max != nil {
-						sub["max"] = *numberRange.Max
-					} else if numberRange.Top != nil {
-						sub["top"] = *numberRange.Top
-					}
-					out[n] = sub
-				}
-			} else if numberRange, ok := fv.(s.Int32Range); ok {
-				if numberRange.Min != nil || numberRange.Max != nil || numberRange.Top != nil {
-					sub := make(map[string]interface{})
-					if numberRange.Min != nil {
-						sub["min"] = *numberRange.Min
-					}
-					if numberRange.Max != nil {
-						sub["max"] = *numberRange.Max
-					} else if numberRange.Top != nil {
-						sub["top"] = *numberRange.Top
-					}
-					out[n] = sub
+			sub[getTag(t.Field(i), "json")] = v
+		}
+	}
+	return sub
+}
+
+// roundDates rounds the dates of the date range by the days: min is the start of its day, and top is the start of the day after max, if top is nil,
+// so the templates can use "date < #{range.top}" for the whole day of max.
+func (m *Mapper) roundDates(sub map[string]interface{}, r s.DateRange) {
+	if r.Min != nil {
+		sub["min"] = m.startOfDay(*r.Min, 0)
+	}
+	if r.Max != nil && r.Top == nil {
+		sub["top"] = m.startOfDay(*r.Max, 1)
+	}
+}
+func (m *Mapper) startOfDay(t time.Time, days int) time.Time {
+	t = m.time(t)
+	y, mo, d := t.Date()
+	return time.Date(y, mo, d+days, 0, 0, 0, 0, t.Location())
+}
+func (m *Mapper) time(t time.Time) time.Time {
+	if m.Location != nil {
+		return t.In(m.Location)
+	}
+	return t
+}
+
+var searchPkg = reflect.TypeOf(s.Filter{}).PkgPath()
+
+func isRange(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == searchPkg && strings.HasSuffix(t.Name(), "Range")
+}
+func isNil(f reflect.Value) bool {
+	switch f.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return f.IsNil()
+	}
+	return false
+}
+func mapFilter(out map[string]interface{}, v s.Filter, typ reflect.Type, sfields string, modelType *reflect.Type, buildSort func(string, reflect.Type) string) {
+	if modelType != nil {
+		if len(v.Fields) > 0 {
+			fields := make([]string, 0)
+			for _, key := range v.Fields {
+				i, _, columnName := getFieldByJson(*modelType, key)
+				if len(columnName) < 0 {
+					fields = fields[len(fields):]
+					break
+				} else if i > -1 {
+					fields = append(fields, columnName)
 				}
+			}
+			if len(fields) > 0 {
+				out["fields"] = strings.Join(fields, ",")
 			} else {
-				out[n] = fv
+				if len(sfields) > 0 {
+					out["fields"] = sfields
+				}
 			}
+		} else if len(sfields) > 0 {
+			out["fields"] = sfields
 		}
 	}
-	return out
+	if len(v.Sort) > 0 {
+		t := typ
+		if modelType != nil && *modelType != nil {
+			t = *modelType
+		}
+		sortString := buildSort(v.Sort, t)
+		if len(sortString) > 0 {
+			out["sort"] = sortString
+		}
+	}
+	if v.Excluding != nil && len(v.Excluding) > 0 {
+		out["excluding"] = v.Excluding
+	}
+	if len(v.Q) > 0 {
+		out["q"] = strings.TrimSpace(v.Q)
+	}
+	if len(v.Terms) > 0 {
+		out["terms"] = v.Terms
+	}
+}
+
+// TextByOperator converts the string by the operator tag: "like" is "%value%", "=" is the value, and the others are "value%".
+func TextByOperator(value string, operator string) string {
+	if operator == "like" {
+		return Q(value)
+	} else if operator != "=" {
+		return Prefix(value)
+	}
+	return value
 }
 func getTag(fi reflect.StructField, tag string) string {
 	if tagv := fi.Tag.Get(tag); tagv != "" {
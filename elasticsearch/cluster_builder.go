@@ -0,0 +1,137 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+
+	"github.com/core-go/search/geo"
+)
+
+// ClusterBuilder counts the documents of the filter by geohash_grid or geotile_grid, with the geo_centroid of each cell.
+type ClusterBuilder[T any, F any] struct {
+	Client     *elasticsearch.Client
+	Index      []string
+	BuildQuery func(F) map[string]interface{}
+	// Field is the geo_point field
+	Field string
+}
+
+// NewClusterBuilder creates the builder. If the field is not declared, it is the json name of the geo.JSON, geo.Geometry or geo.LatLon field of T.
+func NewClusterBuilder[T any, F any](client *elasticsearch.Client, index []string, buildQuery func(F) map[string]interface{}, opts ...string) *ClusterBuilder[T, F] {
+	var field string
+	if len(opts) > 0 && len(opts[0]) > 0 {
+		field = opts[0]
+	} else {
+		var t T
+		field = findGeoField(reflect.TypeOf(t))
+	}
+	return &ClusterBuilder[T, F]{Client: client, Index: index, BuildQuery: buildQuery, Field: field}
+}
+
+func (b *ClusterBuilder[T, F]) Cluster(ctx context.Context, filter F, grid geo.Grid) ([]geo.Cell, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	if len(b.Field) == 0 {
+		return nil, errors.New("geo field is required")
+	}
+	query := b.BuildQuery(filter)
+	body := UpdateQuery(query)
+	body["size"] = 0
+	body["aggs"] = BuildClusterAggregation(b.Field, grid)
+	req := esapi.SearchRequest{
+		Index: b.Index,
+		Body:  esutil.NewJSONReader(body),
+	}
+	res, err := req.Do(ctx, b.Client)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, errors.New("response error")
+	}
+	var r struct {
+		Aggregations struct {
+			Cells struct {
+				Buckets []struct {
+					Key      string `json:"key"`
+					DocCount int64  `json:"doc_count"`
+					Centroid struct {
+						Location struct {
+							Lat float64 `json:"lat"`
+							Lon float64 `json:"lon"`
+						} `json:"location"`
+					} `json:"centroid"`
+				} `json:"buckets"`
+			} `json:"cells"`
+		} `json:"aggregations"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return nil, err
+	}
+	cells := make([]geo.Cell, 0)
+	for _, bucket := range r.Aggregations.Cells.Buckets {
+		cells = append(cells, geo.Cell{Key: bucket.Key, Count: bucket.DocCount, Latitude: bucket.Centroid.Location.Lat, Longitude: bucket.Centroid.Location.Lon})
+	}
+	return cells, nil
+}
+
+// BuildClusterAggregation builds the "cells" aggregation: geohash_grid or geotile_grid, with the geo_centroid sub aggregation.
+func BuildClusterAggregation(field string, grid geo.Grid) map[string]interface{} {
+	aggregation := "geohash_grid"
+	if grid.Type == geo.GridGeotile {
+		aggregation = "geotile_grid"
+	}
+	return map[string]interface{}{
+		"cells": map[string]interface{}{
+			aggregation: map[string]interface{}{
+				"field":     field,
+				"precision": grid.Precision,
+			},
+			"aggs": map[string]interface{}{
+				"centroid": map[string]interface{}{
+					"geo_centroid": map[string]interface{}{"field": field},
+				},
+			},
+		},
+	}
+}
+
+func findGeoField(modelType reflect.Type) string {
+	if modelType == nil {
+		return ""
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return ""
+	}
+	i := geo.FindGeoIndex(modelType)
+	if i < 0 {
+		latLon := reflect.TypeOf(geo.LatLon{})
+		for j := 0; j < modelType.NumField(); j++ {
+			t := modelType.Field(j).Type
+			if t == latLon || t == reflect.PtrTo(latLon) {
+				i = j
+				break
+			}
+		}
+	}
+	if i < 0 {
+		return ""
+	}
+	field := modelType.Field(i)
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		return strings.Split(tag, ",")[0]
+	}
+	return field.Name
+}
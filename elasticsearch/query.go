@@ -7,15 +7,27 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/core-go/search"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/elastic/go-elasticsearch/v8/esutil"
 )
 
+// knn is the key of the knn search in the query, which is built by query.BuildKnn.
+const knn = "$knn"
+
 func BuildSearchResult(ctx context.Context, db *elasticsearch.Client, index []string, results interface{}, jsonName string, query map[string]interface{}, sort []map[string]interface{}, limit int64, offset int64, version string) (int64, error) {
+	return BuildSearchResultWithScore(ctx, db, index, results, jsonName, query, sort, limit, offset, version, "")
+}
+
+// BuildSearchResultWithScore builds the search result, and sets the _score of each hit to the score field, such as the similarity of the knn search.
+func BuildSearchResultWithScore(ctx context.Context, db *elasticsearch.Client, index []string, results interface{}, jsonName string, query map[string]interface{}, sort []map[string]interface{}, limit int64, offset int64, version string, score string) (int64, error) {
 	from := int(offset)
 	size := int(limit)
 	fullQuery := UpdateQuery(query)
+	if k, ok := query[knn].(map[string]interface{}); ok {
+		fullQuery = BuildKnnQuery(fullQuery, k)
+	}
 	fullQuery["sort"] = sort
 	req := esapi.SearchRequest{
 		// Index: []string{indexName},
@@ -52,6 +64,9 @@ func BuildSearchResult(ctx context.Context, db *elasticsearch.Client, index []st
 				if len(version) > 0 {
 					rs[version] = hitObj["_version"]
 				}
+				if len(score) > 0 {
+					rs[score] = hitObj["_score"]
+				}
 				listResults = append(listResults, r)
 			}
 
@@ -72,8 +87,13 @@ func UpdateQuery(m map[string]interface{}) map[string]interface{} {
 	}
 	queryFields := make([]map[string]interface{}, 0)
 	for key, value := range m {
+		if key == knn {
+			continue
+		}
 		q := make(map[string]interface{})
-		if reflect.ValueOf(value).Kind() == reflect.Map {
+		if clause, ok := value.(map[string]interface{}); ok && isClause(clause) {
+			q = clause
+		} else if reflect.ValueOf(value).Kind() == reflect.Map {
 			q["range"] = make(map[string]interface{})
 			q["range"].(map[string]interface{})[key] = make(map[string]interface{})
 			for operator, val := range value.(map[string]interface{}) {
@@ -88,6 +108,68 @@ func UpdateQuery(m map[string]interface{}) map[string]interface{} {
 	result["query"].(map[string]interface{})["bool"].(map[string]interface{})["must"] = queryFields
 	return result
 }
+
+// BuildKnnQuery moves the bool query into the filter of the knn search, so the conditions are the pre-filter of the nearest neighbors.
+func BuildKnnQuery(fullQuery map[string]interface{}, k map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	knnSearch := make(map[string]interface{})
+	for key, value := range k {
+		knnSearch[key] = value
+	}
+	if q, ok := fullQuery["query"].(map[string]interface{}); ok {
+		if b, ok := q["bool"].(map[string]interface{}); ok {
+			if must, ok := b["must"].([]map[string]interface{}); ok && len(must) > 0 {
+				knnSearch["filter"] = q
+			}
+		}
+	}
+	result["knn"] = knnSearch
+	return result
+}
+
+var clauses = []string{"geo_distance", "geo_bounding_box", "geo_shape", "match", "match_phrase_prefix", "match_none", "bool"}
+
+// isClause checks if the value of the query is a full clause, such as geo_distance or match, instead of the operators of range.
+func isClause(clause map[string]interface{}) bool {
+	for _, key := range clauses {
+		if _, ok := clause[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildGeoDistanceSort replaces the "distance" sort with the _geo_distance sort from the point of the geo_distance query.
+func BuildGeoDistanceSort(sort []map[string]interface{}, query map[string]interface{}) []map[string]interface{} {
+	var field string
+	var point interface{}
+	for _, value := range query {
+		if clause, ok := value.(map[string]interface{}); ok {
+			if d, ok := clause["geo_distance"].(map[string]interface{}); ok {
+				for k, v := range d {
+					if k != "distance" {
+						field, point = k, v
+					}
+				}
+			}
+		}
+	}
+	if len(field) == 0 {
+		return sort
+	}
+	for i, m := range sort {
+		if order, ok := m[search.Distance].(map[string]string); ok {
+			sort[i] = map[string]interface{}{
+				"_geo_distance": map[string]interface{}{
+					field:   point,
+					"order": order["order"],
+					"unit":  "m",
+				},
+			}
+		}
+	}
+	return sort
+}
 func BuildSort(s string, modelType reflect.Type) []map[string]interface{} {
 	sort := []map[string]interface{}{}
 	if len(s) == 0 {
@@ -0,0 +1,56 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/core-go/search"
+	"github.com/core-go/search/geo"
+)
+
+// BuildGeoDistance builds the geo_distance clause. The distance is in meters.
+func BuildGeoDistance(field string, d search.GeoDistance) map[string]interface{} {
+	return map[string]interface{}{
+		"geo_distance": map[string]interface{}{
+			"distance": fmt.Sprintf("%sm", strconv.FormatFloat(d.Radius, 'f', -1, 64)),
+			field:      map[string]interface{}{"lat": d.Latitude, "lon": d.Longitude},
+		},
+	}
+}
+
+// BuildGeoBoundingBox builds the geo_bounding_box clause.
+func BuildGeoBoundingBox(field string, b search.GeoBoundingBox) map[string]interface{} {
+	return map[string]interface{}{
+		"geo_bounding_box": map[string]interface{}{
+			field: map[string]interface{}{
+				"top_left":     map[string]interface{}{"lat": b.Top, "lon": b.Left},
+				"bottom_right": map[string]interface{}{"lat": b.Bottom, "lon": b.Right},
+			},
+		},
+	}
+}
+
+// BuildGeoShape builds the geo_shape clause, which works on both geo_point and geo_shape fields. The relation is "within" or "intersects".
+func BuildGeoShape(field string, relation string, g geo.Geometry) map[string]interface{} {
+	return map[string]interface{}{
+		"geo_shape": map[string]interface{}{
+			field: map[string]interface{}{
+				"shape":    g,
+				"relation": relation,
+			},
+		},
+	}
+}
+
+// getGeoName gets the json name of the model field which has the same name as the filter field. If there is no such field, the geo.JSON field of the model is used.
+func getGeoName(modelType reflect.Type, fieldName string) string {
+	if i, columnName := findFieldByName(modelType, fieldName); i >= 0 {
+		return columnName
+	}
+	if i := geo.FindGeoIndex(modelType); i >= 0 {
+		_, columnName := findFieldByName(modelType, modelType.Field(i).Name)
+		return columnName
+	}
+	return fieldName
+}
@@ -0,0 +1,57 @@
+package query
+
+import "github.com/core-go/search"
+
+// MoreLikeThis is the key of the more like this query in the query.
+const MoreLikeThis = "$mlt"
+
+// BuildMoreLikeThis builds the bool query of the more_like_this query of each field, which is boosted by the weight of the field,
+// so the documents are ranked by the weighted matched fields, and at least MinMatch fields must match. The document is liked by Id if it exists, else by Like.
+// The liked document is not in the result, because "include" is false by default.
+func BuildMoreLikeThis(m search.MoreLikeThis) map[string]interface{} {
+	var like []map[string]interface{}
+	if len(m.Id) > 0 {
+		like = []map[string]interface{}{{"_id": m.Id}}
+	} else {
+		like = []map[string]interface{}{{"doc": m.Like}}
+	}
+	should := make([]map[string]interface{}, 0, len(m.Fields))
+	for _, field := range m.Fields {
+		name, weight := search.ParseField(field)
+		if len(m.Id) == 0 {
+			if _, ok := m.Like[name]; !ok {
+				continue
+			}
+		}
+		should = append(should, map[string]interface{}{
+			"more_like_this": map[string]interface{}{
+				"fields":        []string{name},
+				"like":          like,
+				"min_term_freq": 1,
+				"min_doc_freq":  1,
+				"boost":         weight,
+			},
+		})
+	}
+	minMatch := m.MinMatch
+	if minMatch < 1 {
+		minMatch = 1
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": minMatch,
+		},
+	}
+}
+
+// getMoreLikeThis gets the MoreLikeThis of the field, which is search.MoreLikeThis or *search.MoreLikeThis, as the sql and the mongo builders.
+func getMoreLikeThis(v interface{}) (*search.MoreLikeThis, bool) {
+	switch m := v.(type) {
+	case search.MoreLikeThis:
+		return &m, true
+	case *search.MoreLikeThis:
+		return m, m != nil
+	}
+	return nil, false
+}
@@ -0,0 +1,90 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/core-go/search"
+)
+
+// FoldingAnalyzer is the name of the analyzer of BuildFoldingAnalysis.
+const FoldingAnalyzer = "folding"
+
+// BuildFoldingAnalysis builds the analysis settings of the index, with the analyzer which folds the case and the diacritics.
+// The text fields which are searched by the unaccent option should use this analyzer, such as "analyzer": "folding".
+func BuildFoldingAnalysis() map[string]interface{} {
+	return map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"analyzer": map[string]interface{}{
+				FoldingAnalyzer: map[string]interface{}{
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "asciifolding"},
+				},
+			},
+		},
+	}
+}
+
+// BuildMatchQuery builds the match clause of the keyword by the q tag: match_phrase_prefix for prefix, and match for the others.
+// The unaccent option normalizes the keyword, and the fuzzy option adds the fuzziness, which is AUTO or the number, such as `q:"like,fuzzy=1"`.
+// The synonym option analyzes the keyword by the analyzer of BuildSynonymAnalysis.
+func BuildMatchQuery(field string, tag string, keyword string) map[string]interface{} {
+	match := strings.TrimSpace(strings.Split(tag, ",")[0])
+	if _, ok := search.GetOption(tag, search.Unaccent); ok {
+		keyword = search.Normalize(keyword)
+	}
+	fuzziness, fuzzy := search.GetOption(tag, search.Fuzzy)
+	if match != "=" && match != "like" && !fuzzy {
+		m := map[string]interface{}{"query": keyword}
+		if isSynonym(tag) {
+			m["analyzer"] = SynonymAnalyzer
+		}
+		return map[string]interface{}{
+			"match_phrase_prefix": map[string]interface{}{field: m},
+		}
+	}
+	m := map[string]interface{}{"query": keyword, "operator": "and"}
+	if isSynonym(tag) {
+		m["analyzer"] = SynonymAnalyzer
+	}
+	if fuzzy {
+		if len(fuzziness) > 0 {
+			m["fuzziness"] = fuzziness
+		} else {
+			m["fuzziness"] = "AUTO"
+		}
+	}
+	return map[string]interface{}{"match": map[string]interface{}{field: m}}
+}
+
+// BuildKeywordQuery builds the bool query which matches the keyword on any of the fields, which are the json names and the q tags.
+func BuildKeywordQuery(fields []string, tags []string, keyword string) map[string]interface{} {
+	should := make([]map[string]interface{}, 0)
+	for i, field := range fields {
+		should = append(should, BuildMatchQuery(field, tags[i], keyword))
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+func isNormalized(tag string) bool {
+	_, unaccent := search.GetOption(tag, search.Unaccent)
+	_, fuzzy := search.GetOption(tag, search.Fuzzy)
+	return unaccent || fuzzy
+}
+func getString(v reflect.Value) (string, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", v.Type().Elem().Kind() == reflect.String
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return v.String(), true
+	}
+	return "", false
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/core-go/search"
+	"github.com/core-go/search/geo"
 )
 
 func UseQuery[T any, F any]() func(F) map[string]interface{} {
@@ -28,16 +29,62 @@ func (b *Builder[T, F]) BuildQuery(filter F) map[string]interface{} {
 	return Build(filter, b.ModelType)
 }
 
+// UseQueryWithError is UseQuery, which returns the error of the invalid filter, such as the invalid shape of GeoWithin.
+func UseQueryWithError[T any, F any]() func(F) (map[string]interface{}, error) {
+	b := NewBuilder[T, F]()
+	return b.BuildQueryWithError
+}
+func (b *Builder[T, F]) BuildQueryWithError(filter F) (map[string]interface{}, error) {
+	return BuildWithError(filter, b.ModelType)
+}
+
+// Build builds the query as BuildWithError. If the filter is invalid, it builds the query which matches no documents; use BuildWithError to get the error.
 func Build(filter interface{}, resultModelType reflect.Type) map[string]interface{} {
+	query, err := BuildWithError(filter, resultModelType)
+	if err != nil {
+		return map[string]interface{}{"$none": map[string]interface{}{"match_none": map[string]interface{}{}}}
+	}
+	return query
+}
+
+// BuildWithError builds the query of the filter, and returns the error of the invalid filter, such as the invalid shape of GeoWithin or GeoIntersects.
+func BuildWithError(filter interface{}, resultModelType reflect.Type) (map[string]interface{}, error) {
 	query := map[string]interface{}{}
 	if _, ok := filter.(*search.Filter); ok {
-		return query
+		return query, nil
 	}
 	value := reflect.Indirect(reflect.ValueOf(filter))
 	numField := value.NumField()
+	var keyword string
+	var terms [][]string
+	qFields := make([]string, 0)
+	qTags := make([]string, 0)
 	for i := 0; i < numField; i++ {
 		fieldValue := value.Field(i).Interface()
+		tf := value.Type().Field(i)
+		if str, ok := getString(value.Field(i)); ok {
+			tag, ok1 := tf.Tag.Lookup("operator")
+			if !ok1 {
+				tag, ok1 = tf.Tag.Lookup("q")
+			}
+			if len(str) == 0 {
+				if qTag, isQ := tf.Tag.Lookup("q"); isQ {
+					_, columnName := findFieldByName(resultModelType, tf.Name)
+					qFields = append(qFields, columnName)
+					qTags = append(qTags, qTag)
+				}
+				continue
+			} else if ok1 && (isNormalized(tag) || isSynonym(tag)) {
+				_, columnName := findFieldByName(resultModelType, tf.Name)
+				query[columnName] = BuildMatchQuery(columnName, tag, str)
+				continue
+			}
+		}
 		if v, ok := fieldValue.(*search.Filter); ok {
+			if len(v.Q) > 0 {
+				keyword = strings.TrimSpace(v.Q)
+				terms = v.Terms
+			}
 			if v.Excluding != nil && len(v.Excluding) > 0 {
 				_, _, columnName := getFieldByBson(value.Type(), "_id")
 				if len(columnName) > 0 {
@@ -67,8 +114,8 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 
 			if numberRange.Min != nil {
 				amountQuery["$gte"] = *numberRange.Min
-			} else if numberRange.Lower != nil {
-				amountQuery["$gt"] = *numberRange.Lower
+			} else if numberRange.Bottom != nil {
+				amountQuery["$gt"] = *numberRange.Bottom
 			}
 			if numberRange.Max != nil {
 				amountQuery["$lte"] = *numberRange.Max
@@ -85,8 +132,8 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 
 			if numberRange.Min != nil {
 				amountQuery["$gte"] = *numberRange.Min
-			} else if numberRange.Lower != nil {
-				amountQuery["$gt"] = *numberRange.Lower
+			} else if numberRange.Bottom != nil {
+				amountQuery["$gt"] = *numberRange.Bottom
 			}
 			if numberRange.Max != nil {
 				amountQuery["$lte"] = *numberRange.Max
@@ -102,8 +149,8 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 
 			if numberRange.Min != nil {
 				amountQuery["$gte"] = *numberRange.Min
-			} else if numberRange.Lower != nil {
-				amountQuery["$gt"] = *numberRange.Lower
+			} else if numberRange.Bottom != nil {
+				amountQuery["$gt"] = *numberRange.Bottom
 			}
 			if numberRange.Max != nil {
 				amountQuery["$lte"] = *numberRange.Max
@@ -119,8 +166,8 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 
 			if numberRange.Min != nil {
 				amountQuery["$gte"] = *numberRange.Min
-			} else if numberRange.Lower != nil {
-				amountQuery["$gt"] = *numberRange.Lower
+			} else if numberRange.Bottom != nil {
+				amountQuery["$gt"] = *numberRange.Bottom
 			}
 			if numberRange.Max != nil {
 				amountQuery["$lte"] = *numberRange.Max
@@ -130,6 +177,41 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 			if len(amountQuery) > 0 {
 				query[columnName] = amountQuery
 			}
+		} else if geoDistance, ok := fieldValue.(*search.GeoDistance); ok && geoDistance != nil {
+			if geoDistance.Radius > 0 {
+				columnName := getGeoName(resultModelType, value.Type().Field(i).Name)
+				query[columnName] = BuildGeoDistance(columnName, *geoDistance)
+			}
+		} else if box, ok := fieldValue.(*search.GeoBoundingBox); ok && box != nil {
+			if !box.IsEmpty() {
+				columnName := getGeoName(resultModelType, value.Type().Field(i).Name)
+				query[columnName] = BuildGeoBoundingBox(columnName, *box)
+			}
+		} else if within, ok := fieldValue.(*search.GeoWithin); ok && within != nil {
+			if len(within.Type) > 0 {
+				if err := geo.Geometry(*within).Validate(); err != nil {
+					return nil, err
+				}
+				columnName := getGeoName(resultModelType, value.Type().Field(i).Name)
+				query[columnName] = BuildGeoShape(columnName, "within", geo.Geometry(*within))
+			}
+		} else if intersects, ok := fieldValue.(*search.GeoIntersects); ok && intersects != nil {
+			if len(intersects.Type) > 0 {
+				if err := geo.Geometry(*intersects).Validate(); err != nil {
+					return nil, err
+				}
+				columnName := getGeoName(resultModelType, value.Type().Field(i).Name)
+				query[columnName] = BuildGeoShape(columnName, "intersects", geo.Geometry(*intersects))
+			}
+		} else if mlt, ok := getMoreLikeThis(fieldValue); ok {
+			if !mlt.IsEmpty() {
+				query[MoreLikeThis] = BuildMoreLikeThis(*mlt)
+			}
+		} else if vq, ok := getVectorQuery(fieldValue); ok {
+			if !vq.IsEmpty() {
+				_, columnName := findFieldByName(resultModelType, value.Type().Field(i).Name)
+				query[Knn] = BuildKnn(columnName, *vq)
+			}
 		} else if value.Field(i).Kind().String() == "slice" {
 			actionDateQuery := map[string]interface{}{}
 			_, columnName := findFieldByName(resultModelType, value.Type().Field(i).Name)
@@ -146,7 +228,14 @@ func Build(filter interface{}, resultModelType reflect.Type) map[string]interfac
 			}
 		}
 	}
-	return query
+	if len(keyword) > 0 && len(qFields) > 0 {
+		if len(terms) > 0 {
+			query["$q"] = BuildTermsQuery(qFields, qTags, keyword, terms)
+		} else {
+			query["$q"] = BuildKeywordQuery(qFields, qTags, keyword)
+		}
+	}
+	return query, nil
 }
 
 func findFieldByName(modelType reflect.Type, fieldName string) (index int, jsonTagName string) {
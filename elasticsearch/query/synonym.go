@@ -0,0 +1,86 @@
+package query
+
+import "github.com/core-go/search"
+
+// SynonymAnalyzer is the name of the search analyzer of BuildSynonymAnalysis.
+const SynonymAnalyzer = "synonym"
+
+// BuildSynonymAnalysis builds the analysis settings of the index, with the search analyzer which expands the synonyms of the file in the Solr format,
+// such as "analysis/synonyms.txt" in the config directory, and removes the stop words. The filter is updateable,
+// so the file is changed without closing the index, by calling ReloadSearchAnalyzers. The fields which are searched by the synonym option should use
+// this analyzer as "search_analyzer".
+func BuildSynonymAnalysis(synonymsPath string, stopWords ...string) map[string]interface{} {
+	filters := map[string]interface{}{
+		"synonym_graph_filter": map[string]interface{}{
+			"type":          "synonym_graph",
+			"synonyms_path": synonymsPath,
+			"updateable":    true,
+		},
+	}
+	chain := []string{"lowercase"}
+	if len(stopWords) > 0 {
+		filters["stop_filter"] = map[string]interface{}{
+			"type":      "stop",
+			"stopwords": stopWords,
+		}
+		chain = append(chain, "stop_filter")
+	}
+	chain = append(chain, "synonym_graph_filter")
+	return map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"filter": filters,
+			"analyzer": map[string]interface{}{
+				SynonymAnalyzer: map[string]interface{}{
+					"tokenizer": "standard",
+					"filter":    chain,
+				},
+			},
+		},
+	}
+}
+
+// BuildTermsQuery builds the bool query of the terms of the expanded keyword: each group must match any of the fields by any of its terms.
+// The fields with the synonym option are matched by the keyword instead, because the synonyms are expanded by the analyzer.
+func BuildTermsQuery(fields []string, tags []string, keyword string, terms [][]string) map[string]interface{} {
+	should := make([]map[string]interface{}, 0)
+	must := make([]map[string]interface{}, 0, len(terms))
+	for _, group := range terms {
+		or := make([]map[string]interface{}, 0)
+		for _, term := range group {
+			for i, field := range fields {
+				if !isSynonym(tags[i]) {
+					or = append(or, BuildMatchQuery(field, tags[i], term))
+				}
+			}
+		}
+		if len(or) > 0 {
+			must = append(must, map[string]interface{}{
+				"bool": map[string]interface{}{
+					"should":               or,
+					"minimum_should_match": 1,
+				},
+			})
+		}
+	}
+	if len(must) > 0 {
+		should = append(should, map[string]interface{}{
+			"bool": map[string]interface{}{"must": must},
+		})
+	}
+	for i, field := range fields {
+		if isSynonym(tags[i]) {
+			should = append(should, BuildMatchQuery(field, tags[i], keyword))
+		}
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+func isSynonym(tag string) bool {
+	_, ok := search.GetOption(tag, search.Synonym)
+	return ok
+}
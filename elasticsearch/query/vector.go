@@ -0,0 +1,55 @@
+package query
+
+import (
+	"math"
+
+	"github.com/core-go/search"
+)
+
+// Knn is the key of the knn clause in the query, which is not in the bool query, because the knn search is at the top level of the request.
+const Knn = "$knn"
+
+// BuildKnn builds the knn search of the dense_vector field. The metric is the similarity of the mapping of the field, so it is only used to convert
+// the minimum score to the similarity threshold. The number of candidates is 10 times K, at most 10000.
+func BuildKnn(field string, q search.VectorQuery) map[string]interface{} {
+	k := q.K
+	if k <= 0 {
+		k = 10
+	}
+	candidates := k * 10
+	if candidates > 10000 {
+		candidates = 10000
+	}
+	if candidates < k {
+		candidates = k
+	}
+	knn := map[string]interface{}{
+		"field":          field,
+		"query_vector":   q.Vector,
+		"k":              k,
+		"num_candidates": candidates,
+	}
+	if q.MinScore > 0 {
+		knn["similarity"] = getSimilarity(q.GetMetric(), q.MinScore)
+	}
+	return knn
+}
+
+// getSimilarity converts the minimum score to the similarity of the knn search: the cosine or the dot product, or the maximum l2_norm distance.
+func getSimilarity(metric string, minScore float64) float64 {
+	if metric == search.L2Norm {
+		return math.Sqrt(1/minScore - 1)
+	}
+	return 2*minScore - 1
+}
+
+// getVectorQuery gets the VectorQuery of the field, which is search.VectorQuery or *search.VectorQuery, as the sql builder.
+func getVectorQuery(v interface{}) (*search.VectorQuery, bool) {
+	switch q := v.(type) {
+	case search.VectorQuery:
+		return &q, true
+	case *search.VectorQuery:
+		return q, q != nil
+	}
+	return nil, false
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
+	"github.com/core-go/search"
 	"github.com/elastic/go-elasticsearch/v8"
 )
 
@@ -16,7 +18,10 @@ type SearchBuilder[T any, F any] struct {
 	ModelType   reflect.Type
 	idJson      string
 	versionJson string
+	scoreJson   string
 	Map         func(*T)
+	// BuildQueryWithError builds the query, and returns the error of the invalid filter, which is returned by Search. If it is set, BuildQuery is not used.
+	BuildQueryWithError func(F) (map[string]interface{}, error)
 }
 
 func NewSearchBuilder[T any, F any](client *elasticsearch.Client, index []string, buildQuery func(F) map[string]interface{}, getSort func(m interface{}) string, opts ...func(*T)) *SearchBuilder[T, F] {
@@ -36,14 +41,34 @@ func NewSearchBuilderWithVersion[T any, F any](client *elasticsearch.Client, ind
 	if len(opts) > 0 && opts[0] != nil {
 		mp = opts[0]
 	}
-	return &SearchBuilder[T, F]{Client: client, Index: index, BuildQuery: buildQuery, GetSort: getSort, ModelType: modelType, idJson: idJson, versionJson: versionJson, Map: mp}
+	scoreJson := ""
+	if i := search.FindVectorScoreField(modelType); i >= 0 {
+		scoreJson = strings.Split(modelType.Field(i).Tag.Get("json"), ",")[0]
+	}
+	return &SearchBuilder[T, F]{Client: client, Index: index, BuildQuery: buildQuery, GetSort: getSort, ModelType: modelType, idJson: idJson, versionJson: versionJson, scoreJson: scoreJson, Map: mp}
+}
+
+// NewSearchBuilderWithError creates the search builder by buildQuery, which returns the error of the invalid filter, such as query.UseQueryWithError.
+func NewSearchBuilderWithError[T any, F any](client *elasticsearch.Client, index []string, buildQuery func(F) (map[string]interface{}, error), getSort func(m interface{}) string, opts ...func(*T)) *SearchBuilder[T, F] {
+	builder := NewSearchBuilderWithVersion[T, F](client, index, nil, getSort, "", opts...)
+	builder.BuildQueryWithError = buildQuery
+	return builder
 }
 func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
-	query := b.BuildQuery(filter)
+	var query map[string]interface{}
+	if b.BuildQueryWithError != nil {
+		var er0 error
+		query, er0 = b.BuildQueryWithError(filter)
+		if er0 != nil {
+			return nil, -1, er0
+		}
+	} else {
+		query = b.BuildQuery(filter)
+	}
 	s := b.GetSort(filter)
-	sort := BuildSort(s, b.ModelType)
+	sort := BuildGeoDistanceSort(BuildSort(s, b.ModelType), query)
 	var objs []T
-	total, err := BuildSearchResult(ctx, b.Client, b.Index, objs, b.idJson, query, sort, limit, offset, b.versionJson)
+	total, err := BuildSearchResultWithScore(ctx, b.Client, b.Index, &objs, b.idJson, query, sort, limit, offset, b.versionJson, b.scoreJson)
 	if b.Map != nil {
 		l := len(objs)
 		for i := 0; i < l; i++ {
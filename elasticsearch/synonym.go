@@ -0,0 +1,23 @@
+package elasticsearch
+
+import (
+	"context"
+	"errors"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// ReloadSearchAnalyzers reloads the updateable search analyzers of the indexes, such as the synonym analyzer, after the synonym file is changed.
+func ReloadSearchAnalyzers(ctx context.Context, db *elasticsearch.Client, index ...string) error {
+	req := esapi.IndicesReloadSearchAnalyzersRequest{Index: index}
+	res, err := req.Do(ctx, db)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return errors.New("response error")
+	}
+	return nil
+}
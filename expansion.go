@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Synonym is the option of the q tag to expand the keyword by the synonym analyzer of the store, such as `q:"like,synonym"` for Elasticsearch.
+const Synonym = "synonym"
+
+// Expander expands the keyword into the groups of the terms. The result matches if each group matches by any of its terms.
+// The stop words are removed, and the words without synonyms are the groups of one term.
+type Expander interface {
+	Expand(keyword string) [][]string
+}
+
+// ExpanderFunc is the function which implements Expander.
+type ExpanderFunc func(keyword string) [][]string
+
+func (f ExpanderFunc) Expand(keyword string) [][]string {
+	return f(keyword)
+}
+
+// ErrNoFilter is returned by ExpandFilter if the filter has no Filter which can be changed, such as a struct which is not a pointer.
+var ErrNoFilter = errors.New("the filter must be a pointer to a struct which has the field of *Filter or Filter")
+
+// ExpandFilter expands Q of the filter into Terms, so the query builders match the OR groups of the terms instead of Q.
+// The filter is *Filter, or a pointer to a struct which has the field of *Filter or Filter; else ErrNoFilter is returned.
+func ExpandFilter(filter interface{}, expander Expander) error {
+	if filter == nil || expander == nil {
+		return nil
+	}
+	f := GetFilter(filter)
+	if f == nil {
+		return ErrNoFilter
+	}
+	keyword := strings.TrimSpace(f.Q)
+	if len(keyword) == 0 {
+		f.Terms = nil
+		return nil
+	}
+	f.Terms = expander.Expand(keyword)
+	return nil
+}
+
+// UseExpander wraps the search function, so the filter is expanded before the query is built. The filter can be a struct or a pointer to a struct.
+func UseExpander[T any, F any](search func(context.Context, F, int64, int64) ([]T, int64, error), expander Expander) func(context.Context, F, int64, int64) ([]T, int64, error) {
+	return func(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
+		if err := ExpandFilter(addressOf(&filter), expander); err != nil {
+			return nil, 0, err
+		}
+		return search(ctx, filter, limit, offset)
+	}
+}
+
+// UseNextExpander wraps the search function which pages by the next page token, so the filter is expanded before the query is built.
+func UseNextExpander[T any, F any](search func(context.Context, F, int64, string) ([]T, string, error), expander Expander) func(context.Context, F, int64, string) ([]T, string, error) {
+	return func(ctx context.Context, filter F, limit int64, next string) ([]T, string, error) {
+		if err := ExpandFilter(addressOf(&filter), expander); err != nil {
+			return nil, "", err
+		}
+		return search(ctx, filter, limit, next)
+	}
+}
+
+// addressOf returns the filter if it is a pointer, or the pointer to the filter, so the fields of the filter can be changed.
+func addressOf[F any](filter *F) interface{} {
+	if v := reflect.ValueOf(*filter); v.Kind() == reflect.Ptr {
+		return *filter
+	}
+	return filter
+}
+
+// MatchTerms checks if each group of the terms is contained in any of the texts, without the diacritics and the case. It is for the search in memory.
+func MatchTerms(terms [][]string, texts ...string) bool {
+	for _, group := range terms {
+		found := false
+		for _, term := range group {
+			for _, text := range texts {
+				if FoldContains(text, term) {
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
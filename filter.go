@@ -2,6 +2,12 @@ package search
 
 import "reflect"
 
+// Relevance is the sort field to order by the relevance of the full text search of Q, such as "-relevance".
+const Relevance = "relevance"
+
+// Weight is the option of the q tag to weight the relevance of the column, such as `q:"like,weight=3"`. The default weight is 1.
+const Weight = "weight"
+
 type Filter struct {
 	PageIndex     int64 `yaml:"page_index" mapstructure:"page_index" json:"pageIndex,omitempty" gorm:"column:pageindex" bson:"pageIndex,omitempty" dynamodbav:"pageIndex,omitempty" firestore:"pageIndex,omitempty"`
 	PageSize      int64 `yaml:"page_size" mapstructure:"page_size" json:"pageSize,omitempty" gorm:"column:pagesize" bson:"pageSize,omitempty" dynamodbav:"pageSize,omitempty" firestore:"pageSize,omitempty"`
@@ -18,6 +24,8 @@ type Filter struct {
 	Next          string   `yaml:"next" mapstructure:"next" json:"next,omitempty" gorm:"column:next" bson:"next,omitempty" dynamodbav:"next,omitempty" firestore:"next,omitempty"`
 	RefId         string   `yaml:"ref_id" mapstructure:"ref_id" json:"refId,omitempty" gorm:"column:refid" bson:"refId,omitempty" dynamodbav:"refId,omitempty" firestore:"refId,omitempty"`
 	NextPageToken string   `yaml:"next_page_token" mapstructure:"next_page_token" json:"nextPageToken,omitempty" gorm:"column:nextpagetoken" bson:"nextPageToken,omitempty" dynamodbav:"nextPageToken,omitempty" firestore:"nextPageToken,omitempty"`
+	// Terms is Q expanded by an Expander: each group is the alternatives of a word or a phrase, such as [["tv", "television"], ["show"]].
+	Terms [][]string `yaml:"-" mapstructure:"-" json:"-" gorm:"-" bson:"-" dynamodbav:"-" firestore:"-"`
 }
 type Result struct {
 	List          interface{} `yaml:"list" mapstructure:"list" json:"list,omitempty" gorm:"column:list" bson:"list,omitempty" dynamodbav:"list,omitempty" firestore:"list,omitempty"`
@@ -25,16 +33,30 @@ type Result struct {
 	Next          string      `yaml:"next" mapstructure:"next" json:"next,omitempty" gorm:"column:next" bson:"next,omitempty" dynamodbav:"next,omitempty" firestore:"next,omitempty"`
 }
 
+// GetFilter gets the Filter of the filter, which is a field of the type *Filter or Filter, such as the embedded Filter.
+// The field of the type Filter is got only if the filter is a pointer, so the Filter can be changed. It returns nil if there is no such field.
 func GetFilter(m interface{}) *Filter {
 	if sModel, ok := m.(*Filter); ok {
 		return sModel
 	} else {
 		value := reflect.Indirect(reflect.ValueOf(m))
+		if value.Kind() != reflect.Struct {
+			return nil
+		}
 		numField := value.NumField()
 		for i := 0; i < numField; i++ {
-			if sModel1, ok := value.Field(i).Interface().(*Filter); ok {
+			field := value.Field(i)
+			if !field.CanInterface() {
+				continue
+			}
+			if sModel1, ok := field.Interface().(*Filter); ok {
 				return sModel1
 			}
+			if field.CanAddr() {
+				if sModel2, ok := field.Addr().Interface().(*Filter); ok {
+					return sModel2
+				}
+			}
 		}
 	}
 	return nil
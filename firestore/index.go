@@ -0,0 +1,285 @@
+package firestore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxIndexFields = 16
+
+type IndexField struct {
+	FieldPath   string `json:"fieldPath"`
+	Order       string `json:"order,omitempty"`
+	ArrayConfig string `json:"arrayConfig,omitempty"`
+}
+type Index struct {
+	CollectionGroup string       `json:"collectionGroup"`
+	QueryScope      string       `json:"queryScope"`
+	Fields          []IndexField `json:"fields"`
+}
+
+// Indexes is the format of firestore.indexes.json, which is deployed by "firebase deploy --only firestore:indexes".
+type Indexes struct {
+	Indexes        []Index       `json:"indexes"`
+	FieldOverrides []interface{} `json:"fieldOverrides"`
+}
+
+// IndexError is returned when a query fails because the composite index does not exist. Indexes are the indexes that fix it:
+// a query with the OR filters needs an index for each disjunction.
+type IndexError struct {
+	Err     error
+	Indexes []Index
+}
+
+func (e *IndexError) Error() string {
+	data, _ := json.Marshal(e.Indexes)
+	return fmt.Sprintf("%s; required indexes: %s", e.Err.Error(), string(data))
+}
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
+
+// WrapIndexError wraps the error of a query which requires an index with the definition of the index.
+func WrapIndexError(err error, collection string, query []Query, sort []Sort) error {
+	if err == nil || status.Code(err) != codes.FailedPrecondition || !strings.Contains(err.Error(), "index") {
+		return err
+	}
+	indexes := BuildIndexes(collection, query, sort)
+	if len(indexes) == 0 {
+		return err
+	}
+	return &IndexError{Err: err, Indexes: indexes}
+}
+
+// BuildIndexes builds the composite indexes of a query. The OR filters are expanded, because each disjunction needs its own index.
+func BuildIndexes(collection string, query []Query, sort []Sort) []Index {
+	indexes := make([]Index, 0)
+	for _, conjunction := range expand(query) {
+		index := BuildIndex(collection, conjunction, sort)
+		if index != nil {
+			indexes = appendIndex(indexes, *index)
+		}
+	}
+	return indexes
+}
+
+// BuildIndex builds the composite index of the conditions which are all AND: the equality fields, then the inequality fields which are not in the sort, then the sort fields.
+// It returns nil if the single field indexes are enough.
+func BuildIndex(collection string, query []Query, sort []Sort) *Index {
+	fields := make([]IndexField, 0)
+	equalities := make([]IndexField, 0)
+	ranges := make([]IndexField, 0)
+	for _, q := range query {
+		switch q.Operator {
+		case "==", "in":
+			equalities = appendIndexField(equalities, IndexField{FieldPath: q.Path, Order: "ASCENDING"})
+		case "array-contains", "array-contains-any":
+			equalities = appendIndexField(equalities, IndexField{FieldPath: q.Path, ArrayConfig: "CONTAINS"})
+		default:
+			ranges = appendIndexField(ranges, IndexField{FieldPath: q.Path, Order: "ASCENDING"})
+		}
+	}
+	fields = append(fields, equalities...)
+	for _, r := range ranges {
+		if !hasSort(sort, r.FieldPath) {
+			fields = appendIndexField(fields, r)
+		}
+	}
+	for _, s := range sort {
+		order := "ASCENDING"
+		if s.Direction == firestore.Desc {
+			order = "DESCENDING"
+		}
+		fields = appendIndexField(fields, IndexField{FieldPath: s.Path, Order: order})
+	}
+	if len(fields) < 2 || (len(ranges) == 0 && len(sort) == 0) {
+		return nil
+	}
+	return &Index{CollectionGroup: collection, QueryScope: "COLLECTION", Fields: fields}
+}
+
+// AdviseIndexes enumerates the combinations of the fields of the filter type, builds the query of each combination by buildQuery (such as query.BuildQueryByType),
+// and returns the composite indexes for each of the sorts. The empty sort is always checked.
+func AdviseIndexes(collection string, filterType reflect.Type, modelType reflect.Type, buildQuery func(interface{}, reflect.Type) ([]Query, []string), buildSort func(string, reflect.Type) []Sort, sorts ...string) ([]Index, error) {
+	if filterType.Kind() == reflect.Ptr {
+		filterType = filterType.Elem()
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if filterType.Kind() != reflect.Struct {
+		return nil, errors.New("filter type must be a struct")
+	}
+	candidates := make([]int, 0)
+	numField := filterType.NumField()
+	for i := 0; i < numField; i++ {
+		field := filterType.Field(i)
+		if !field.IsExported() || isFilter(field.Type) {
+			continue
+		}
+		if tag, ok := field.Tag.Lookup("firestore"); ok && strings.Split(tag, ",")[0] == "-" {
+			continue
+		}
+		candidates = append(candidates, i)
+	}
+	if len(candidates) > maxIndexFields {
+		return nil, fmt.Errorf("%s has %d filter fields, the maximum is %d", filterType.Name(), len(candidates), maxIndexFields)
+	}
+	ss := make([][]Sort, 0)
+	ss = append(ss, []Sort{})
+	for _, s := range sorts {
+		if len(strings.TrimSpace(s)) > 0 {
+			ss = append(ss, buildSort(s, modelType))
+		}
+	}
+	indexes := make([]Index, 0)
+	n := len(candidates)
+	for mask := 0; mask < 1<<n; mask++ {
+		filter := reflect.New(filterType)
+		for j := 0; j < n; j++ {
+			if mask&(1<<j) != 0 {
+				f := filter.Elem().Field(candidates[j])
+				f.Set(sampleValue(f.Type()))
+			}
+		}
+		query, _ := buildQuery(filter.Interface(), modelType)
+		for _, s := range ss {
+			for _, index := range BuildIndexes(collection, query, s) {
+				indexes = appendIndex(indexes, index)
+			}
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexKey(indexes[i]) < indexKey(indexes[j])
+	})
+	return indexes, nil
+}
+
+// WriteIndexes writes the indexes to the file, such as firestore.indexes.json. The indexes of the existing file are kept.
+func WriteIndexes(filename string, indexes ...[]Index) error {
+	all := Indexes{Indexes: make([]Index, 0), FieldOverrides: make([]interface{}, 0)}
+	if data, err := os.ReadFile(filename); err == nil {
+		if er1 := json.Unmarshal(data, &all); er1 != nil {
+			return fmt.Errorf("cannot parse %s: %w", filename, er1)
+		}
+		if all.FieldOverrides == nil {
+			all.FieldOverrides = make([]interface{}, 0)
+		}
+	}
+	for _, sub := range indexes {
+		for _, index := range sub {
+			all.Indexes = appendIndex(all.Indexes, index)
+		}
+	}
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// expand converts the query into the disjunctive normal form: each item of the result is a list of conditions which are all AND.
+func expand(query []Query) [][]Query {
+	result := [][]Query{{}}
+	for _, q := range query {
+		var options [][]Query
+		if len(q.Or) > 0 {
+			options = make([][]Query, 0)
+			for _, sub := range q.Or {
+				options = append(options, expand([]Query{sub})...)
+			}
+		} else if len(q.And) > 0 {
+			options = expand(q.And)
+		} else {
+			options = [][]Query{{q}}
+		}
+		next := make([][]Query, 0)
+		for _, r := range result {
+			for _, o := range options {
+				c := append(append([]Query{}, r...), o...)
+				next = append(next, c)
+			}
+		}
+		result = next
+	}
+	return result
+}
+func hasSort(sort []Sort, path string) bool {
+	for _, s := range sort {
+		if s.Path == path {
+			return true
+		}
+	}
+	return false
+}
+func appendIndexField(fields []IndexField, field IndexField) []IndexField {
+	for _, f := range fields {
+		if f.FieldPath == field.FieldPath {
+			return fields
+		}
+	}
+	return append(fields, field)
+}
+func appendIndex(indexes []Index, index Index) []Index {
+	key := indexKey(index)
+	for _, i := range indexes {
+		if indexKey(i) == key {
+			return indexes
+		}
+	}
+	return append(indexes, index)
+}
+func indexKey(index Index) string {
+	keys := make([]string, 0)
+	for _, f := range index.Fields {
+		keys = append(keys, f.FieldPath+" "+f.Order+f.ArrayConfig)
+	}
+	return index.CollectionGroup + ":" + index.QueryScope + ":" + strings.Join(keys, ",")
+}
+func isFilter(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name() == "Filter" && strings.HasSuffix(t.PkgPath(), "core-go/search")
+}
+
+// sampleValue creates a value which is not empty, so that the query builder creates the condition of the field.
+func sampleValue(t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(t.Elem()))
+		v.Elem().Set(sampleValue(t.Elem()))
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Slice:
+		s := reflect.MakeSlice(t, 1, 1)
+		s.Index(0).Set(sampleValue(t.Elem()))
+		v.Set(s)
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Now()))
+		} else if min := v.FieldByName("Min"); min.IsValid() && min.CanSet() {
+			min.Set(sampleValue(min.Type()))
+		}
+	}
+	return v
+}
@@ -1,7 +1,45 @@
 package firestore
 
+import "cloud.google.com/go/firestore"
+
+// Query is a condition of a field. If Or is not empty, it is the union of the sub queries; if And is not empty, it is the intersection of the sub queries.
 type Query struct {
 	Path     string
 	Operator string
 	Value    interface{}
+	Or       []Query
+	And      []Query
+}
+
+type Sort struct {
+	Path      string
+	Direction firestore.Direction
+}
+
+func ToFilter(query Query) firestore.EntityFilter {
+	if len(query.Or) > 0 {
+		filters := make([]firestore.EntityFilter, 0)
+		for _, sub := range query.Or {
+			filters = append(filters, ToFilter(sub))
+		}
+		return firestore.OrFilter{Filters: filters}
+	}
+	if len(query.And) > 0 {
+		filters := make([]firestore.EntityFilter, 0)
+		for _, sub := range query.And {
+			filters = append(filters, ToFilter(sub))
+		}
+		return firestore.AndFilter{Filters: filters}
+	}
+	return firestore.PropertyFilter{Path: query.Path, Operator: query.Operator, Value: query.Value}
+}
+func BuildWhere(q firestore.Query, queries []Query) firestore.Query {
+	for _, p := range queries {
+		if len(p.Or) > 0 || len(p.And) > 0 {
+			q = q.WhereEntity(ToFilter(p))
+		} else {
+			q = q.Where(p.Path, p.Operator, p.Value)
+		}
+	}
+	return q
 }
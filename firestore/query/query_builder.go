@@ -50,7 +50,10 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 	value := reflect.Indirect(reflect.ValueOf(filter))
 	filterType := value.Type()
 	numField := value.NumField()
+	groups := make(map[string][]f.Query)
+	groupNames := make([]string, 0)
 	for i := 0; i < numField; i++ {
+		start := len(query)
 		fsName := getFirestore(filterType, i)
 		if fsName == "-" {
 			continue
@@ -107,6 +110,9 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 			continue
 		}
 		if len(psv) > 0 {
+			if operator == "==" && isArray(resultModelType, fsName) {
+				operator = "array-contains"
+			}
 			query = append(query, f.Query{Path: fsName, Operator: operator, Value: psv})
 		} else if rangeTime, ok := x.(search.TimeRange); ok {
 			timeQuery := make([]f.Query, 0)
@@ -152,13 +158,13 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 
 			if numberRange.Min != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">=", Value: *numberRange.Min})
-			} else if numberRange.Lower != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Lower})
+			} else if numberRange.Bottom != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Bottom})
 			}
 			if numberRange.Max != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<=", Value: *numberRange.Max})
-			} else if numberRange.Upper != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Upper})
+			} else if numberRange.Top != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Top})
 			}
 
 			if len(numQuery) > 0 {
@@ -169,13 +175,13 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 
 			if numberRange.Min != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">=", Value: *numberRange.Min})
-			} else if numberRange.Lower != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Lower})
+			} else if numberRange.Bottom != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Bottom})
 			}
 			if numberRange.Max != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<=", Value: *numberRange.Max})
-			} else if numberRange.Upper != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Upper})
+			} else if numberRange.Top != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Top})
 			}
 
 			if len(numQuery) > 0 {
@@ -186,13 +192,13 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 
 			if numberRange.Min != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">=", Value: *numberRange.Min})
-			} else if numberRange.Lower != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Lower})
+			} else if numberRange.Bottom != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: ">", Value: *numberRange.Bottom})
 			}
 			if numberRange.Max != nil {
 				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<=", Value: *numberRange.Max})
-			} else if numberRange.Upper != nil {
-				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Upper})
+			} else if numberRange.Top != nil {
+				numQuery = append(numQuery, f.Query{Path: fsName, Operator: "<", Value: *numberRange.Top})
 			}
 
 			if len(numQuery) > 0 {
@@ -200,20 +206,92 @@ func BuildQueryByType(filter interface{}, resultModelType reflect.Type) ([]f.Que
 			}
 		} else if kind == reflect.Slice {
 			if reflect.Indirect(reflect.ValueOf(x)).Len() > 0 {
-				if operator == "==" {
-					operator = "in"
-				}
-				q := f.Query{Path: fsName, Operator: operator, Value: x}
+				q := f.Query{Path: fsName, Operator: GetSliceOperator(operator, isArray(resultModelType, fsName)), Value: x}
 				query = append(query, q)
 			}
 		} else {
+			if operator == "==" && isArray(resultModelType, fsName) {
+				operator = "array-contains"
+			}
 			q := f.Query{Path: fsName, Operator: operator, Value: x}
 			query = append(query, q)
 		}
+		if group, ok := filterType.Field(i).Tag.Lookup("or"); ok && len(group) > 0 && len(query) > start {
+			var branch f.Query
+			if len(query)-start == 1 {
+				branch = query[start]
+			} else {
+				branch = f.Query{And: append([]f.Query{}, query[start:]...)}
+			}
+			query = query[:start]
+			if _, exist := groups[group]; !exist {
+				groupNames = append(groupNames, group)
+			}
+			groups[group] = append(groups[group], branch)
+		}
+	}
+	for _, name := range groupNames {
+		branches := groups[name]
+		if len(branches) == 1 {
+			if len(branches[0].And) > 0 {
+				query = append(query, branches[0].And...)
+			} else {
+				query = append(query, branches[0])
+			}
+		} else {
+			query = append(query, f.Query{Or: branches})
+		}
 	}
 	return query, fields
 }
 
+// GetSliceOperator maps the operator of a slice filter: "==" is "in" ("array-contains-any" if the field of the model is an array),
+// "!=" is "not-in" and "array-contains" is "array-contains-any".
+func GetSliceOperator(operator string, array bool) string {
+	switch operator {
+	case "==":
+		if array {
+			return "array-contains-any"
+		}
+		return "in"
+	case "!=":
+		return "not-in"
+	case "array-contains":
+		return "array-contains-any"
+	case "in", "not-in", "array-contains-any":
+		return operator
+	default:
+		return "in"
+	}
+}
+func isArray(modelType reflect.Type, fsName string) bool {
+	if modelType == nil {
+		return false
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return false
+	}
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		field := modelType.Field(i)
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("firestore"); ok {
+			name = strings.Split(tag, ",")[0]
+		}
+		if name == fsName {
+			t := field.Type
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+		}
+	}
+	return false
+}
+
 func getFieldByJson(modelType reflect.Type, jsonName string) (int, string, string) {
 	numField := modelType.NumField()
 	for i := 0; i < numField; i++ {
@@ -2,6 +2,7 @@ package firestore
 
 import (
 	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
 	"context"
 	"fmt"
 	"google.golang.org/api/iterator"
@@ -20,9 +21,18 @@ type SearchBuilder[T any, F any] struct {
 	idIndex          int
 	createdTimeIndex int
 	updatedTimeIndex int
+	// BuildSorts builds the sort in the order of the fields. If it is set, BuildSort is not used.
+	BuildSorts func(s string, modelType reflect.Type) []Sort
 }
 
 func NewSearchBuilderWithSort[T any, F any](client *firestore.Client, collectionName string, buildQuery func(F) ([]Query, []string), getSort func(interface{}) string, buildSort func(s string, modelType reflect.Type) map[string]firestore.Direction, mp func(*T), opts ...string) *SearchBuilder[T, F] {
+	builder := NewSearchBuilderWithSorts[T, F](client, collectionName, buildQuery, getSort, nil, mp, opts...)
+	builder.BuildSort = buildSort
+	return builder
+}
+
+// NewSearchBuilderWithSorts creates the search builder which sorts in the order of the fields by buildSort, such as BuildSorts.
+func NewSearchBuilderWithSorts[T any, F any](client *firestore.Client, collectionName string, buildQuery func(F) ([]Query, []string), getSort func(interface{}) string, buildSort func(s string, modelType reflect.Type) []Sort, mp func(*T), opts ...string) *SearchBuilder[T, F] {
 	idx := -1
 	var idFieldName string
 	var createdTimeFieldName string
@@ -61,22 +71,23 @@ func NewSearchBuilderWithSort[T any, F any](client *firestore.Client, collection
 		utIdx, _, _ = FindFieldByName(modelType, updatedTimeFieldName)
 	}
 	collection := client.Collection(collectionName)
-	return &SearchBuilder[T, F]{Collection: collection, ModelType: modelType, BuildQuery: buildQuery, BuildSort: buildSort, GetSort: getSort, Map: mp, idIndex: idx, createdTimeIndex: ctIdx, updatedTimeIndex: utIdx}
+	return &SearchBuilder[T, F]{Collection: collection, ModelType: modelType, BuildQuery: buildQuery, BuildSort: BuildSort, BuildSorts: buildSort, GetSort: getSort, Map: mp, idIndex: idx, createdTimeIndex: ctIdx, updatedTimeIndex: utIdx}
 }
 func NewSearchBuilderWithMap[T any, F any](client *firestore.Client, collectionName string, buildQuery func(F) ([]Query, []string), getSort func(interface{}) string, mp func(*T), opts ...string) *SearchBuilder[T, F] {
-	return NewSearchBuilderWithSort[T, F](client, collectionName, buildQuery, getSort, BuildSort, mp, opts...)
+	return NewSearchBuilderWithSorts[T, F](client, collectionName, buildQuery, getSort, BuildSorts, mp, opts...)
 }
 func NewSearchBuilder[T any, F any](client *firestore.Client, collectionName string, buildQuery func(F) ([]Query, []string), getSort func(interface{}) string, opts ...string) *SearchBuilder[T, F] {
-	return NewSearchBuilderWithSort[T, F](client, collectionName, buildQuery, getSort, BuildSort, nil, opts...)
+	return NewSearchBuilderWithSorts[T, F](client, collectionName, buildQuery, getSort, BuildSorts, nil, opts...)
 }
 
 func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64, nextPageToken string) ([]T, string, error) {
 	query, fields := b.BuildQuery(filter)
 
 	s := b.GetSort(filter)
-	sort := b.BuildSort(s, b.ModelType)
+	sort := b.buildSort(s)
 	var objs []T
-	refId, err := BuildSearchResult(ctx, b.Collection, &objs, query, fields, sort, limit, nextPageToken, b.idIndex, b.createdTimeIndex, b.updatedTimeIndex)
+	refId, err := BuildSearchResultWithSorts(ctx, b.Collection, &objs, query, fields, sort, limit, nextPageToken, b.idIndex, b.createdTimeIndex, b.updatedTimeIndex)
+	err = WrapIndexError(err, b.Collection.ID, query, sort)
 	if b.Map != nil {
 		l := len(objs)
 		for i := 0; i < l; i++ {
@@ -86,11 +97,73 @@ func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64,
 	return objs, refId, err
 }
 
+func (b *SearchBuilder[T, F]) buildSort(s string) []Sort {
+	if b.BuildSorts != nil {
+		return b.BuildSorts(s, b.ModelType)
+	}
+	return toSorts(b.BuildSort(s, b.ModelType))
+}
+
 func BuildSearchResult(ctx context.Context, collection *firestore.CollectionRef, results interface{}, query []Query, fields []string, sort map[string]firestore.Direction, limit int64, refId string, idIndex int, createdTimeIndex int, updatedTimeIndex int) (string, error) {
-	queries, er0 := BuildQuerySearch(ctx, collection, query, fields, sort, int(limit), refId)
+	return BuildSearchResultWithSorts(ctx, collection, results, query, fields, toSorts(sort), limit, refId, idIndex, createdTimeIndex, updatedTimeIndex)
+}
+
+// BuildSearchResultWithSorts is BuildSearchResult, which sorts in the order of the fields.
+func BuildSearchResultWithSorts(ctx context.Context, collection *firestore.CollectionRef, results interface{}, query []Query, fields []string, sort []Sort, limit int64, refId string, idIndex int, createdTimeIndex int, updatedTimeIndex int) (string, error) {
+	queries, er0 := BuildQuerySearchWithSorts(ctx, collection, query, fields, sort, int(limit), refId)
 	if er0 != nil {
 		return "", er0
 	}
+	return scanDocuments(ctx, queries, results, idIndex, createdTimeIndex, updatedTimeIndex)
+}
+
+// SearchWithOffset has the signature of Search[T, F], so that firestore can be used by the SearchHandler, which pages by offset and needs the total.
+func (b *SearchBuilder[T, F]) SearchWithOffset(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
+	query, fields := b.BuildQuery(filter)
+
+	s := b.GetSort(filter)
+	sort := b.buildSort(s)
+	var objs []T
+	total, err := BuildSearchResultWithTotal(ctx, b.Collection, &objs, query, fields, sort, limit, offset, b.idIndex, b.createdTimeIndex, b.updatedTimeIndex)
+	err = WrapIndexError(err, b.Collection.ID, query, sort)
+	if b.Map != nil {
+		l := len(objs)
+		for i := 0; i < l; i++ {
+			b.Map(&objs[i])
+		}
+	}
+	return objs, total, err
+}
+func BuildSearchResultWithTotal(ctx context.Context, collection *firestore.CollectionRef, results interface{}, query []Query, fields []string, sort []Sort, limit int64, offset int64, idIndex int, createdTimeIndex int, updatedTimeIndex int) (int64, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	queries, er0 := BuildQuerySearchWithSorts(ctx, collection, query, fields, sort, int(limit), "", int(offset))
+	if er0 != nil {
+		return 0, er0
+	}
+	_, er1 := scanDocuments(ctx, queries, results, idIndex, createdTimeIndex, updatedTimeIndex)
+	if er1 != nil {
+		return 0, er1
+	}
+	return Count(ctx, collection, query)
+}
+
+// Count counts the documents by the aggregation query, without reading the documents.
+func Count(ctx context.Context, collection *firestore.CollectionRef, query []Query) (int64, error) {
+	q := BuildWhere(collection.Query, query)
+	result, err := q.NewAggregationQuery().WithCount("total").Get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	v, ok := result["total"].(*firestorepb.Value)
+	if !ok {
+		return 0, fmt.Errorf("unexpected count value %v", result["total"])
+	}
+	return v.GetIntegerValue(), nil
+}
+
+func scanDocuments(ctx context.Context, queries firestore.Query, results interface{}, idIndex int, createdTimeIndex int, updatedTimeIndex int) (string, error) {
 	modelType := reflect.TypeOf(results).Elem().Elem()
 	iter := queries.Documents(ctx)
 	var lastId string
@@ -128,23 +201,16 @@ func appendToArray(arr interface{}, item interface{}) interface{} {
 }
 
 func BuildQuerySearch(ctx context.Context, collection *firestore.CollectionRef, queries []Query, fields []string, sort map[string]firestore.Direction, limit int, refId string, options ...int) (firestore.Query, error) {
+	return BuildQuerySearchWithSorts(ctx, collection, queries, fields, toSorts(sort), limit, refId, options...)
+}
+
+// BuildQuerySearchWithSorts is BuildQuerySearch, which sorts in the order of the fields.
+func BuildQuerySearchWithSorts(ctx context.Context, collection *firestore.CollectionRef, queries []Query, fields []string, sort []Sort, limit int, refId string, options ...int) (firestore.Query, error) {
 	q := collection.Query
-	if len(sort) > 0 {
-		i := 0
-		for k, v := range sort {
-			if i == 0 {
-				q = collection.OrderBy(k, v)
-				i++
-				continue
-			}
-			q = q.OrderBy(k, v)
-		}
-	}
-	if len(queries) > 0 {
-		for _, p := range queries {
-			q = q.Where(p.Path, p.Operator, p.Value)
-		}
+	for _, v := range sort {
+		q = q.OrderBy(v.Path, v.Direction)
 	}
+	q = BuildWhere(q, queries)
 	if len(refId) > 0 {
 		lastVisible, err := collection.Doc(refId).Get(ctx)
 		if err != nil {
@@ -167,8 +233,18 @@ func BuildQuerySearch(ctx context.Context, collection *firestore.CollectionRef,
 	return q, nil
 }
 
+// BuildSort builds the sort as the map, which has no order; use BuildSorts to sort by the fields in order.
 func BuildSort(s string, modelType reflect.Type) map[string]firestore.Direction {
-	var sort = make(map[string]firestore.Direction)
+	sort := make(map[string]firestore.Direction)
+	for _, v := range BuildSorts(s, modelType) {
+		sort[v.Path] = v.Direction
+	}
+	return sort
+}
+
+// BuildSorts builds the sort in the order of the fields, such as "-createdDate,id".
+func BuildSorts(s string, modelType reflect.Type) []Sort {
+	var sort = make([]Sort, 0)
 
 	if len(s) == 0 {
 		return sort
@@ -183,10 +259,17 @@ func BuildSort(s string, modelType reflect.Type) map[string]firestore.Direction
 		}
 		columnName := GetColumnName(modelType, fieldName)
 		sortType := GetSortType(c)
-		sort[columnName] = sortType
+		sort = append(sort, Sort{Path: columnName, Direction: sortType})
 	}
 	return sort
 }
+func toSorts(sort map[string]firestore.Direction) []Sort {
+	sorts := make([]Sort, 0, len(sort))
+	for k, v := range sort {
+		sorts = append(sorts, Sort{Path: k, Direction: v})
+	}
+	return sorts
+}
 func GetColumnName(modelType reflect.Type, sortField string) string {
 	sortField = strings.TrimSpace(sortField)
 	idx, fieldName, name := GetFieldByJson(modelType, sortField)
@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	GridGeohash = "geohash"
+	GridGeotile = "geotile"
+)
+
+// Grid is the type and the precision of the cells: the length of the geohash from 1 to 12, or the zoom of the tile from 0 to 29.
+type Grid struct {
+	Type      string `json:"type,omitempty" bson:"type,omitempty"`
+	Precision int    `json:"precision,omitempty" bson:"precision,omitempty"`
+}
+
+// Cell is a cluster of the locations. Latitude and Longitude are the centroid of the locations in the cell.
+type Cell struct {
+	Key       string  `json:"key,omitempty" bson:"key,omitempty"`
+	Count     int64   `json:"count,omitempty" bson:"count,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty" bson:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty" bson:"longitude,omitempty"`
+}
+
+func (g Grid) Validate() error {
+	switch g.Type {
+	case GridGeohash:
+		if g.Precision < 1 || g.Precision > 12 {
+			return fmt.Errorf("precision of geohash grid must be between 1 and 12, not %d", g.Precision)
+		}
+	case GridGeotile:
+		if g.Precision < 0 || g.Precision > 29 {
+			return fmt.Errorf("precision of geotile grid must be between 0 and 29, not %d", g.Precision)
+		}
+	default:
+		return fmt.Errorf("unsupported grid type '%s'", g.Type)
+	}
+	return nil
+}
+
+// Key gets the key of the cell of the point.
+func (g Grid) Key(latitude float64, longitude float64) string {
+	if g.Type == GridGeotile {
+		return EncodeGeotile(latitude, longitude, g.Precision)
+	}
+	return EncodeGeohash(latitude, longitude, g.Precision)
+}
+
+// Cluster groups the models by the cells of the grid, for the stores which cannot aggregate, such as SQL.
+// The models is a slice of structs, with the Latitude and Longitude fields by default; the options are the names of the latitude and longitude fields.
+// The cells are sorted by the count, descending.
+func Cluster(models interface{}, grid Grid, options ...string) ([]Cell, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	v := reflect.Indirect(reflect.ValueOf(models))
+	if v.Kind() != reflect.Slice {
+		return nil, errors.New("models must be a slice")
+	}
+	modelType := v.Type().Elem()
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return nil, errors.New("models must be a slice of structs")
+	}
+	latitudeName := "Latitude"
+	longitudeName := "Longitude"
+	if len(options) >= 1 && len(options[0]) > 0 {
+		latitudeName = options[0]
+	}
+	if len(options) >= 2 && len(options[1]) > 0 {
+		longitudeName = options[1]
+	}
+	latitudeIndex := findFieldIndex(modelType, latitudeName)
+	longitudeIndex := findFieldIndex(modelType, longitudeName)
+	if latitudeIndex < 0 || longitudeIndex < 0 {
+		return nil, fmt.Errorf("%s must have %s and %s fields", modelType.Name(), latitudeName, longitudeName)
+	}
+	c := NewClusterer(grid)
+	for i := 0; i < v.Len(); i++ {
+		item := reflect.Indirect(v.Index(i))
+		if !item.IsValid() {
+			continue
+		}
+		latitude, ok1 := getFloat(item.Field(latitudeIndex))
+		longitude, ok2 := getFloat(item.Field(longitudeIndex))
+		if ok1 && ok2 {
+			c.Add(latitude, longitude)
+		}
+	}
+	return c.Cells(), nil
+}
+
+// Clusterer accumulates the points into the cells of the grid, so the rows can be clustered while they are scanned.
+type Clusterer struct {
+	grid  Grid
+	cells map[string]*cellSum
+}
+type cellSum struct {
+	count     int64
+	latitude  float64
+	longitude float64
+}
+
+func NewClusterer(grid Grid) *Clusterer {
+	return &Clusterer{grid: grid, cells: make(map[string]*cellSum)}
+}
+func (c *Clusterer) Add(latitude float64, longitude float64) {
+	key := c.grid.Key(latitude, longitude)
+	sum, ok := c.cells[key]
+	if !ok {
+		sum = &cellSum{}
+		c.cells[key] = sum
+	}
+	sum.count++
+	sum.latitude += latitude
+	sum.longitude += longitude
+}
+func (c *Clusterer) Cells() []Cell {
+	cells := make([]Cell, 0, len(c.cells))
+	for key, sum := range c.cells {
+		cells = append(cells, Cell{Key: key, Count: sum.count, Latitude: sum.latitude / float64(sum.count), Longitude: sum.longitude / float64(sum.count)})
+	}
+	SortCells(cells)
+	return cells
+}
+
+// SortCells sorts the cells by the count descending, then by the key.
+func SortCells(cells []Cell) {
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Count != cells[j].Count {
+			return cells[i].Count > cells[j].Count
+		}
+		return cells[i].Key < cells[j].Key
+	})
+}
@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LatLon is the object format of the Elasticsearch geo_point.
+type LatLon struct {
+	Lat float64 `json:"lat" bson:"lat"`
+	Lon float64 `json:"lon" bson:"lon"`
+}
+
+// ElasticPointMapper converts the Latitude and Longitude fields to and from the location field of Elasticsearch.
+// If the location field is LatLon, it is a geo_point. If it is Geometry, it is a geo_shape point.
+type ElasticPointMapper struct {
+	locationMapper
+}
+
+// NewElasticMapper creates the mapper. The options are the names of the location, latitude and longitude fields, which are "Location", "Latitude" and "Longitude" by default.
+func NewElasticMapper(modelType reflect.Type, options ...string) *ElasticPointMapper {
+	return &ElasticPointMapper{newLocationMapper(modelType, options, encodeElastic, decodeElastic)}
+}
+
+func encodeElastic(latitude float64, longitude float64, t reflect.Type) (interface{}, error) {
+	point := NewPoint(latitude, longitude)
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	switch t {
+	case reflect.TypeOf(LatLon{}):
+		return LatLon{Lat: latitude, Lon: longitude}, nil
+	case reflect.TypeOf(Geometry{}):
+		return point, nil
+	}
+	return nil, fmt.Errorf("location field must be LatLon or Geometry, not %s", t.String())
+}
+func decodeElastic(v interface{}) (float64, float64, bool) {
+	switch x := v.(type) {
+	case LatLon:
+		return x.Lat, x.Lon, true
+	case Geometry:
+		p, err := x.Point()
+		if err != nil {
+			return 0, 0, false
+		}
+		return p[1], p[0], true
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,106 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// EncodeGeohash encodes the point to the geohash with the precision, from 1 to 12 characters.
+func EncodeGeohash(latitude float64, longitude float64, precision int) string {
+	if precision < 1 {
+		precision = 1
+	} else if precision > 12 {
+		precision = 12
+	}
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+	var sb strings.Builder
+	bit, ch := 0, 0
+	even := true
+	for sb.Len() < precision {
+		if even {
+			mid := (minLng + maxLng) / 2
+			if longitude >= mid {
+				ch = ch<<1 | 1
+				minLng = mid
+			} else {
+				ch = ch << 1
+				maxLng = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if latitude >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch = ch << 1
+				maxLat = mid
+			}
+		}
+		even = !even
+		bit++
+		if bit == 5 {
+			sb.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// DecodeGeohash decodes the geohash to the center of its cell.
+func DecodeGeohash(hash string) (float64, float64, error) {
+	if len(hash) == 0 {
+		return 0, 0, errors.New("geohash is empty")
+	}
+	minLat, maxLat := -90.0, 90.0
+	minLng, maxLng := -180.0, 180.0
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		v := strings.IndexRune(base32, c)
+		if v < 0 {
+			return 0, 0, fmt.Errorf("invalid geohash '%s'", hash)
+		}
+		for i := 4; i >= 0; i-- {
+			b := (v >> uint(i)) & 1
+			if even {
+				mid := (minLng + maxLng) / 2
+				if b == 1 {
+					minLng = mid
+				} else {
+					maxLng = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if b == 1 {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return (minLat + maxLat) / 2, (minLng + maxLng) / 2, nil
+}
+
+// EncodeGeotile encodes the point to the key of the web mercator tile, "zoom/x/y", with the zoom from 0 to 29, as the geotile_grid of Elasticsearch.
+func EncodeGeotile(latitude float64, longitude float64, zoom int) string {
+	if zoom < 0 {
+		zoom = 0
+	} else if zoom > 29 {
+		zoom = 29
+	}
+	n := math.Exp2(float64(zoom))
+	latitude = math.Max(-85.05112878, math.Min(85.05112878, latitude))
+	x := int64(math.Floor((longitude + 180) / 360 * n))
+	r := latitude * math.Pi / 180
+	y := int64(math.Floor((1 - math.Log(math.Tan(r)+1/math.Cos(r))/math.Pi) / 2 * n))
+	max := int64(n) - 1
+	x = int64(math.Max(0, math.Min(float64(max), float64(x))))
+	y = int64(math.Max(0, math.Min(float64(max), float64(y))))
+	return fmt.Sprintf("%d/%d/%d", zoom, x, y)
+}
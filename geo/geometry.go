@@ -0,0 +1,218 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	TypePoint        = "Point"
+	TypeLineString   = "LineString"
+	TypePolygon      = "Polygon"
+	TypeMultiPolygon = "MultiPolygon"
+)
+
+// Geometry is a GeoJSON geometry. The positions are [longitude, latitude].
+// Coordinates is []float64 for Point, [][]float64 for LineString, [][][]float64 for Polygon and [][][][]float64 for MultiPolygon.
+// When it is decoded from JSON or BSON, it is []interface{}, so use the accessors to read it.
+type Geometry struct {
+	Type        string      `json:"type,omitempty" bson:"type,omitempty"`
+	Coordinates interface{} `json:"coordinates,omitempty" bson:"coordinates,omitempty"`
+}
+
+func NewPoint(latitude float64, longitude float64) Geometry {
+	return Geometry{Type: TypePoint, Coordinates: []float64{longitude, latitude}}
+}
+func NewLineString(coordinates [][]float64) Geometry {
+	return Geometry{Type: TypeLineString, Coordinates: coordinates}
+}
+func NewPolygon(coordinates [][][]float64) Geometry {
+	return Geometry{Type: TypePolygon, Coordinates: coordinates}
+}
+func NewMultiPolygon(coordinates [][][][]float64) Geometry {
+	return Geometry{Type: TypeMultiPolygon, Coordinates: coordinates}
+}
+
+func (g Geometry) Point() ([]float64, error) {
+	if g.Type != TypePoint {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, TypePoint)
+	}
+	return toPosition(g.Coordinates)
+}
+func (g Geometry) LineString() ([][]float64, error) {
+	if g.Type != TypeLineString {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, TypeLineString)
+	}
+	return toPositions(g.Coordinates)
+}
+func (g Geometry) Polygon() ([][][]float64, error) {
+	if g.Type != TypePolygon {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, TypePolygon)
+	}
+	return toRings(g.Coordinates)
+}
+func (g Geometry) MultiPolygon() ([][][][]float64, error) {
+	if g.Type != TypeMultiPolygon {
+		return nil, fmt.Errorf("geometry is %s, not %s", g.Type, TypeMultiPolygon)
+	}
+	v, ok := toSlice(g.Coordinates)
+	if !ok {
+		return nil, errors.New("coordinates of MultiPolygon must be an array of polygons")
+	}
+	polygons := make([][][][]float64, 0)
+	for _, p := range v {
+		rings, err := toRings(p)
+		if err != nil {
+			return nil, err
+		}
+		polygons = append(polygons, rings)
+	}
+	return polygons, nil
+}
+
+// Validate checks the type, the number of positions, the ranges of longitude and latitude, and that the rings of the polygons are closed.
+func (g Geometry) Validate() error {
+	switch g.Type {
+	case TypePoint:
+		p, err := g.Point()
+		if err != nil {
+			return err
+		}
+		return validatePosition(p)
+	case TypeLineString:
+		line, err := g.LineString()
+		if err != nil {
+			return err
+		}
+		if len(line) < 2 {
+			return errors.New("LineString must have at least 2 positions")
+		}
+		return validatePositions(line)
+	case TypePolygon:
+		rings, err := g.Polygon()
+		if err != nil {
+			return err
+		}
+		return validateRings(rings)
+	case TypeMultiPolygon:
+		polygons, err := g.MultiPolygon()
+		if err != nil {
+			return err
+		}
+		if len(polygons) == 0 {
+			return errors.New("MultiPolygon must have at least 1 polygon")
+		}
+		for _, rings := range polygons {
+			if err := validateRings(rings); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported geometry type '%s'", g.Type)
+	}
+}
+
+func validatePosition(p []float64) error {
+	if p[0] < -180 || p[0] > 180 {
+		return fmt.Errorf("longitude %v must be between -180 and 180", p[0])
+	}
+	if p[1] < -90 || p[1] > 90 {
+		return fmt.Errorf("latitude %v must be between -90 and 90", p[1])
+	}
+	return nil
+}
+func validatePositions(positions [][]float64) error {
+	for _, p := range positions {
+		if err := validatePosition(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func validateRings(rings [][][]float64) error {
+	if len(rings) == 0 {
+		return errors.New("Polygon must have at least 1 ring")
+	}
+	for _, ring := range rings {
+		if len(ring) < 4 {
+			return errors.New("ring of Polygon must have at least 4 positions")
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return errors.New("ring of Polygon must be closed")
+		}
+		if err := validatePositions(ring); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toSlice(v interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	result := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		result[i] = rv.Index(i).Interface()
+	}
+	return result, true
+}
+func toFloat(v interface{}) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	}
+	return 0, false
+}
+func toPosition(v interface{}) ([]float64, error) {
+	s, ok := toSlice(v)
+	if !ok || len(s) < 2 || len(s) > 3 {
+		return nil, errors.New("position must be an array of 2 or 3 numbers")
+	}
+	p := make([]float64, len(s))
+	for i, x := range s {
+		f, ok := toFloat(x)
+		if !ok {
+			return nil, errors.New("position must be an array of numbers")
+		}
+		p[i] = f
+	}
+	return p, nil
+}
+func toPositions(v interface{}) ([][]float64, error) {
+	s, ok := toSlice(v)
+	if !ok {
+		return nil, errors.New("positions must be an array")
+	}
+	positions := make([][]float64, 0)
+	for _, x := range s {
+		p, err := toPosition(x)
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+func toRings(v interface{}) ([][][]float64, error) {
+	s, ok := toSlice(v)
+	if !ok {
+		return nil, errors.New("coordinates of Polygon must be an array of rings")
+	}
+	rings := make([][][]float64, 0)
+	for _, x := range s {
+		ring, err := toPositions(x)
+		if err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGeoJSONRoundTrip(t *testing.T) {
+	tests := []Geometry{
+		NewPoint(10.8, 106.7),
+		NewLineString([][]float64{{100, 0}, {101.5, 1.25}}),
+		NewPolygon(square),
+		NewMultiPolygon([][][][]float64{square, {{{-1, -1}, {1, -1}, {1, 1}, {-1, -1}}}}),
+	}
+	for _, g := range tests {
+		data, err := json.Marshal(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var g2 Geometry
+		if err = json.Unmarshal(data, &g2); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if err = g2.Validate(); err != nil {
+			t.Errorf("%s: %v", data, err)
+		}
+		// the coordinates are decoded as []interface{}, so they are compared by the WKT
+		wkt, _ := ToWKT(g)
+		if wkt2, err := ToWKT(g2); err != nil || wkt2 != wkt {
+			t.Errorf("%s: WKT = %q, %v, want %q", data, wkt2, err, wkt)
+		}
+	}
+}
+
+func TestValidateMalformedGeoJSON(t *testing.T) {
+	tests := []string{
+		`{"type":"Point","coordinates":[1]}`,
+		`{"type":"Point","coordinates":["1","2"]}`,
+		`{"type":"Point","coordinates":[181,0]}`,
+		`{"type":"Point","coordinates":[0,91]}`,
+		`{"type":"LineString","coordinates":[[1,2]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[1,1],[0,0.5]]]}`,
+		`{"type":"Polygon","coordinates":[[[0,0],[1,0],[0,0]]]}`,
+		`{"type":"Polygon","coordinates":[]}`,
+		`{"type":"MultiPolygon","coordinates":[]}`,
+		`{"type":"MultiPolygon","coordinates":[[1,2]]}`,
+		`{"type":"Circle","coordinates":[1,2]}`,
+		`{"coordinates":[1,2]}`,
+	}
+	for _, s := range tests {
+		var g Geometry
+		if err := json.Unmarshal([]byte(s), &g); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if err := g.Validate(); err == nil {
+			t.Errorf("Validate(%s) = nil, want the error", s)
+		}
+	}
+}
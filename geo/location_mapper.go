@@ -0,0 +1,191 @@
+package geo
+
+import (
+	"context"
+	"reflect"
+)
+
+// locationMapper converts the Latitude and Longitude fields of the model to and from the location field, by encode and decode.
+type locationMapper struct {
+	modelType      reflect.Type
+	latitudeIndex  int
+	longitudeIndex int
+	locationIndex  int
+	encode         func(latitude float64, longitude float64, t reflect.Type) (interface{}, error)
+	decode         func(v interface{}) (float64, float64, bool)
+}
+
+func newLocationMapper(modelType reflect.Type, options []string, encode func(float64, float64, reflect.Type) (interface{}, error), decode func(interface{}) (float64, float64, bool)) locationMapper {
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	locationName := "Location"
+	latitudeName := "Latitude"
+	longitudeName := "Longitude"
+	if len(options) >= 1 && len(options[0]) > 0 {
+		locationName = options[0]
+	}
+	if len(options) >= 2 && len(options[1]) > 0 {
+		latitudeName = options[1]
+	}
+	if len(options) >= 3 && len(options[2]) > 0 {
+		longitudeName = options[2]
+	}
+	return locationMapper{
+		modelType:      modelType,
+		latitudeIndex:  findFieldIndex(modelType, latitudeName),
+		longitudeIndex: findFieldIndex(modelType, longitudeName),
+		locationIndex:  findFieldIndex(modelType, locationName),
+		encode:         encode,
+		decode:         decode,
+	}
+}
+
+func (s *locationMapper) DbToModel(ctx context.Context, model interface{}) (interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(model))
+	if v.Kind() == reflect.Ptr {
+		v = reflect.Indirect(v)
+	}
+	if v.Kind() == reflect.Struct {
+		s.toLatLong(v)
+	}
+	return model, nil
+}
+func (s *locationMapper) DbToModels(ctx context.Context, model interface{}) (interface{}, error) {
+	vo := reflect.Indirect(reflect.ValueOf(model))
+	if vo.Kind() == reflect.Ptr {
+		vo = reflect.Indirect(vo)
+	}
+	if vo.Kind() == reflect.Slice {
+		for i := 0; i < vo.Len(); i++ {
+			s.toLatLong(reflect.Indirect(vo.Index(i)))
+		}
+	}
+	return model, nil
+}
+func (s *locationMapper) ModelToDb(ctx context.Context, model interface{}) (interface{}, error) {
+	if m, ok := model.(map[string]interface{}); ok {
+		return s.fromLatLongMap(m)
+	}
+	v := reflect.Indirect(reflect.ValueOf(model))
+	if v.Kind() == reflect.Ptr {
+		v = reflect.Indirect(v)
+	}
+	if v.Kind() == reflect.Struct {
+		if err := s.fromLatLong(v); err != nil {
+			return model, err
+		}
+	}
+	return model, nil
+}
+func (s *locationMapper) ModelsToDb(ctx context.Context, model interface{}) (interface{}, error) {
+	vo := reflect.Indirect(reflect.ValueOf(model))
+	if vo.Kind() == reflect.Ptr {
+		vo = reflect.Indirect(vo)
+	}
+	if vo.Kind() == reflect.Slice {
+		for i := 0; i < vo.Len(); i++ {
+			if err := s.fromLatLong(reflect.Indirect(vo.Index(i))); err != nil {
+				return model, err
+			}
+		}
+	}
+	return model, nil
+}
+
+func (s *locationMapper) valid() bool {
+	return s.latitudeIndex >= 0 && s.longitudeIndex >= 0 && s.locationIndex >= 0
+}
+func (s *locationMapper) toLatLong(v reflect.Value) {
+	if !s.valid() || v.Kind() != reflect.Struct {
+		return
+	}
+	location := v.Field(s.locationIndex)
+	if location.Kind() == reflect.Ptr && location.IsNil() {
+		return
+	}
+	latitude, longitude, ok := s.decode(reflect.Indirect(location).Interface())
+	if ok {
+		setFloat(v.Field(s.latitudeIndex), latitude)
+		setFloat(v.Field(s.longitudeIndex), longitude)
+	}
+}
+func (s *locationMapper) fromLatLong(v reflect.Value) error {
+	if !s.valid() || v.Kind() != reflect.Struct {
+		return nil
+	}
+	latitude, ok1 := getFloat(v.Field(s.latitudeIndex))
+	longitude, ok2 := getFloat(v.Field(s.longitudeIndex))
+	if !ok1 || !ok2 {
+		return nil
+	}
+	location := v.Field(s.locationIndex)
+	t := location.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	encoded, err := s.encode(latitude, longitude, t)
+	if err != nil {
+		return err
+	}
+	x := reflect.ValueOf(encoded)
+	if location.Kind() == reflect.Ptr {
+		p := reflect.New(t)
+		p.Elem().Set(x)
+		location.Set(p)
+	} else {
+		location.Set(x)
+	}
+	return nil
+}
+func (s *locationMapper) fromLatLongMap(m map[string]interface{}) (map[string]interface{}, error) {
+	if !s.valid() {
+		return m, nil
+	}
+	latitudeJson := getJsonByIndex(s.modelType, s.latitudeIndex)
+	longitudeJson := getJsonByIndex(s.modelType, s.longitudeIndex)
+	locationJson := getJsonByIndex(s.modelType, s.locationIndex)
+	latitude, ok1 := m[latitudeJson].(float64)
+	longitude, ok2 := m[longitudeJson].(float64)
+	if !ok1 || !ok2 || len(locationJson) == 0 {
+		return m, nil
+	}
+	t := s.modelType.Field(s.locationIndex).Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	encoded, err := s.encode(latitude, longitude, t)
+	if err != nil {
+		return m, err
+	}
+	m2 := make(map[string]interface{})
+	m2[locationJson] = encoded
+	for key := range m {
+		if key != latitudeJson && key != longitudeJson {
+			m2[key] = m[key]
+		}
+	}
+	return m2, nil
+}
+
+func getFloat(v reflect.Value) (float64, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Float64 || v.Kind() == reflect.Float32 {
+		return v.Float(), true
+	}
+	return 0, false
+}
+func setFloat(v reflect.Value, f float64) {
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		p.Elem().SetFloat(f)
+		v.Set(p)
+	} else {
+		v.SetFloat(f)
+	}
+}
@@ -6,6 +6,14 @@ import (
 	"strings"
 )
 
+// The orders of the coordinates of the stored points. LatLng is the default, which is the order of the documents written by the previous versions.
+// LngLat is the GeoJSON order, which the 2dsphere indexes of mongo require, so the documents which are written by LatLng must be migrated before it is used,
+// such as by swapping the coordinates with $reverseArray.
+const (
+	LatLng = "latlng"
+	LngLat = "lnglat"
+)
+
 type PointMapper struct {
 	modelType      reflect.Type
 	latitudeIndex  int
@@ -14,6 +22,9 @@ type PointMapper struct {
 	latitudeName   string
 	longitudeName  string
 	bsonName       string
+	geohashIndex   int
+	precision      int
+	lngLat         bool
 }
 
 //For Get By Id
@@ -52,9 +63,10 @@ func getBsonNameByIndex(modelType reflect.Type, fieldIndex int) string {
 func FindGeoIndex(modelType reflect.Type) int {
 	numField := modelType.NumField()
 	k := JSON{}
+	g := Geometry{}
 	for i := 0; i < numField; i++ {
 		t := modelType.Field(i).Type
-		if t == reflect.TypeOf(&k) || t == reflect.TypeOf(k) {
+		if t == reflect.TypeOf(&k) || t == reflect.TypeOf(k) || t == reflect.TypeOf(&g) || t == reflect.TypeOf(g) {
 			return i
 		}
 	}
@@ -93,9 +105,26 @@ func NewMapper(modelType reflect.Type, options ...string) *PointMapper {
 		latitudeName:   latitudeName,
 		longitudeName:  longitudeName,
 		bsonName:       bsonName,
+		geohashIndex:   -1,
 	}
 }
 
+// NewMapperWithGeohash creates the mapper which also writes the geohash of the point to the geohash field, so the documents can be clustered by the geohash prefix.
+// The precision is the length of the geohash, from 1 to 12.
+func NewMapperWithGeohash(modelType reflect.Type, geohashName string, precision int, options ...string) *PointMapper {
+	m := NewMapper(modelType, options...)
+	m.geohashIndex = findFieldIndex(modelType, geohashName)
+	m.precision = precision
+	return m
+}
+
+// NewMapperWithOrder creates the mapper which stores the coordinates by the order, LatLng or LngLat.
+func NewMapperWithOrder(modelType reflect.Type, order string, options ...string) *PointMapper {
+	m := NewMapper(modelType, options...)
+	m.lngLat = order == LngLat
+	return m
+}
+
 func (s *PointMapper) DbToModel(ctx context.Context, model interface{}) (interface{}, error) {
 	valueModelObject := reflect.Indirect(reflect.ValueOf(model))
 	if valueModelObject.Kind() == reflect.Ptr {
@@ -128,7 +157,14 @@ func (s *PointMapper) ModelToDb(ctx context.Context, model interface{}) (interfa
 		latJson := getJsonByIndex(s.modelType, s.latitudeIndex)
 		logJson := getJsonByIndex(s.modelType, s.longitudeIndex)
 		bs := getBsonNameByIndex(s.modelType, s.bsonIndex)
-		m2 := FromPointMap(m, bs, latJson, logJson)
+		m2 := fromPointMap(m, bs, latJson, logJson, s.lngLat)
+		if s.geohashIndex >= 0 {
+			la, ok1 := m[latJson].(float64)
+			lo, ok2 := m[logJson].(float64)
+			if ok1 && ok2 {
+				m2[getBsonNameByIndex(s.modelType, s.geohashIndex)] = EncodeGeohash(la, lo, s.precision)
+			}
+		}
 		return m2, nil
 	}
 	vo := reflect.Indirect(reflect.ValueOf(model))
@@ -137,7 +173,8 @@ func (s *PointMapper) ModelToDb(ctx context.Context, model interface{}) (interfa
 		vo = reflect.Indirect(vo)
 	}
 	if k == reflect.Struct {
-		FromPoint(vo, s.bsonIndex, s.latitudeIndex, s.longitudeIndex)
+		fromPoint(vo, s.bsonIndex, s.latitudeIndex, s.longitudeIndex, s.lngLat)
+		s.setGeohash(vo)
 	}
 	return model, nil
 }
@@ -149,21 +186,44 @@ func (s *PointMapper) ModelsToDb(ctx context.Context, model interface{}) (interf
 
 	if vo.Kind() == reflect.Slice {
 		for i := 0; i < vo.Len(); i++ {
-			FromPoint(vo.Index(i), s.bsonIndex, s.latitudeIndex, s.longitudeIndex)
+			fromPoint(vo.Index(i), s.bsonIndex, s.latitudeIndex, s.longitudeIndex, s.lngLat)
+			s.setGeohash(vo.Index(i))
 		}
 	}
 	return model, nil
 }
 
+func (s *PointMapper) setGeohash(value reflect.Value) {
+	if s.geohashIndex < 0 {
+		return
+	}
+	v := reflect.Indirect(value)
+	la, ok1 := getFloat(v.Field(s.latitudeIndex))
+	lo, ok2 := getFloat(v.Field(s.longitudeIndex))
+	if !ok1 || !ok2 {
+		return
+	}
+	f := v.Field(s.geohashIndex)
+	hash := EncodeGeohash(la, lo, s.precision)
+	if f.Kind() == reflect.Ptr {
+		f.Set(reflect.ValueOf(&hash))
+	} else if f.Kind() == reflect.String {
+		f.SetString(hash)
+	}
+}
+
+// ToPoint reads the coordinates of the point, which are [latitude, longitude], into the latitude and the longitude fields.
 func ToPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeIndex int) {
+	toPoint(value, bsonIndex, latitudeIndex, longitudeIndex, false)
+}
+func toPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeIndex int, lngLat bool) {
 	if value.Kind() == reflect.Struct {
 		x := reflect.Indirect(value)
 		b := x.Field(bsonIndex)
 		k := b.Kind()
 		if k == reflect.Struct || (k == reflect.Ptr && b.IsNil() == false) {
-			arrLatLong := reflect.Indirect(b).FieldByName("GeoJSON").Interface()
-			latitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(0).Interface()
-			longitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(1).Interface()
+			arrLatLong := reflect.Indirect(b).FieldByName("Coordinates").Interface()
+			latitude, longitude := coordinates(reflect.Indirect(reflect.ValueOf(arrLatLong)), lngLat)
 
 			latField := x.Field(latitudeIndex)
 			if latField.Kind() == reflect.Ptr {
@@ -177,7 +237,7 @@ func ToPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeInd
 			lonField := x.Field(longitudeIndex)
 			if lonField.Kind() == reflect.Ptr {
 				var f *float64
-				var f2 = latitude.(float64)
+				var f2 = longitude.(float64)
 				f = &f2
 				lonField.Set(reflect.ValueOf(f))
 			} else {
@@ -193,8 +253,7 @@ func (s *PointMapper) bsonToPoint(value reflect.Value, bsonIndex int, latitudeIn
 		k := b.Kind()
 		if k == reflect.Struct || (k == reflect.Ptr && b.IsNil() == false) {
 			arrLatLong := reflect.Indirect(b).FieldByName("Coordinates").Interface()
-			latitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(0).Interface()
-			longitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(1).Interface()
+			latitude, longitude := coordinates(reflect.Indirect(reflect.ValueOf(arrLatLong)), s.lngLat)
 
 			latField := x.Field(latitudeIndex)
 			if latField.Kind() == reflect.Ptr {
@@ -232,8 +291,7 @@ func (s *PointMapper) bsonToPoint(value reflect.Value, bsonIndex int, latitudeIn
 		}
 
 		arrLatLong := reflect.Indirect(reflect.ValueOf(value.MapIndex(reflect.ValueOf(arrLatLongTag)).Interface())).MapIndex(reflect.ValueOf("coordinates")).Interface()
-		latitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(0).Interface()
-		longitude := reflect.Indirect(reflect.ValueOf(arrLatLong)).Index(1).Interface()
+		latitude, longitude := coordinates(reflect.Indirect(reflect.ValueOf(arrLatLong)), s.lngLat)
 
 		value.SetMapIndex(reflect.ValueOf(latitudeTag), reflect.ValueOf(latitude))
 		value.SetMapIndex(reflect.ValueOf(longitudeTag), reflect.ValueOf(longitude))
@@ -242,15 +300,32 @@ func (s *PointMapper) bsonToPoint(value reflect.Value, bsonIndex int, latitudeIn
 		value.SetMapIndex(reflect.ValueOf(arrLatLongTag), reflect.Value{})
 	}
 }
+// coordinates returns the latitude and the longitude of the coordinates by the order.
+func coordinates(arr reflect.Value, lngLat bool) (interface{}, interface{}) {
+	if lngLat {
+		return arr.Index(1).Interface(), arr.Index(0).Interface()
+	}
+	return arr.Index(0).Interface(), arr.Index(1).Interface()
+}
+func point(latitude float64, longitude float64, lngLat bool) []float64 {
+	if lngLat {
+		return []float64{longitude, latitude}
+	}
+	return []float64{latitude, longitude}
+}
+
+// FromPointMap writes the latitude and the longitude of the map as the point, which coordinates are [latitude, longitude].
 func FromPointMap(m map[string]interface{}, bsonName string, latitudeJson string, longitudeJson string) map[string]interface{} {
+	return fromPointMap(m, bsonName, latitudeJson, longitudeJson, false)
+}
+func fromPointMap(m map[string]interface{}, bsonName string, latitudeJson string, longitudeJson string, lngLat bool) map[string]interface{} {
 	latV, ok1 := m[latitudeJson]
 	logV, ok2 := m[longitudeJson]
 	if ok1 && ok2 && len(bsonName) > 0 {
 		la, ok3 := latV.(float64)
 		lo, ok4 := logV.(float64)
 		if ok3 && ok4 {
-			var arr []float64
-			arr = append(arr, la, lo)
+			arr := point(la, lo, lngLat)
 			ml := JSON{Type: "Point", Coordinates: arr}
 			m2 := make(map[string]interface{})
 			m2[bsonName] = ml
@@ -264,7 +339,11 @@ func FromPointMap(m map[string]interface{}, bsonName string, latitudeJson string
 	}
 	return m
 }
+// FromPoint writes the latitude and the longitude fields as the point, which coordinates are [latitude, longitude].
 func FromPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeIndex int) {
+	fromPoint(value, bsonIndex, latitudeIndex, longitudeIndex, false)
+}
+func fromPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeIndex int, lngLat bool) {
 	v := reflect.Indirect(value)
 	latitudeField := v.Field(latitudeIndex)
 	latNil := false
@@ -288,8 +367,7 @@ func FromPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeI
 		la, ok3 := latitude.(float64)
 		lo, ok4 := longitude.(float64)
 		if ok3 && ok4 {
-			var arr []float64
-			arr = append(arr, la, lo)
+			arr := point(la, lo, lngLat)
 			coordinatesField := v.Field(bsonIndex)
 			if coordinatesField.Kind() == reflect.Ptr {
 				m := &JSON{Type: "Point", Coordinates: arr}
@@ -297,7 +375,7 @@ func FromPoint(value reflect.Value, bsonIndex int, latitudeIndex int, longitudeI
 			} else {
 				x := coordinatesField.FieldByName("Type")
 				x.Set(reflect.ValueOf("Point"))
-				y := coordinatesField.FieldByName("Geo")
+				y := coordinatesField.FieldByName("Coordinates")
 				y.Set(reflect.ValueOf(arr))
 			}
 		}
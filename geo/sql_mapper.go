@@ -0,0 +1,71 @@
+package geo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SqlPointMapper converts the Latitude and Longitude fields to and from the location column.
+// If the location field is a string, it is the WKT, such as ST_AsText(location). If it is []byte, it is the WKB, such as ST_AsBinary(location).
+// When reading, the hex WKB of PostGIS and the WKB with the SRID prefix of MySQL are also accepted.
+type SqlPointMapper struct {
+	locationMapper
+}
+
+// NewSqlMapper creates the mapper. The options are the names of the location, latitude and longitude fields, which are "Location", "Latitude" and "Longitude" by default.
+func NewSqlMapper(modelType reflect.Type, options ...string) *SqlPointMapper {
+	return &SqlPointMapper{newLocationMapper(modelType, options, encodeSql, decodeSql)}
+}
+
+func encodeSql(latitude float64, longitude float64, t reflect.Type) (interface{}, error) {
+	point := NewPoint(latitude, longitude)
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	if t.Kind() == reflect.String {
+		s, err := ToWKT(point)
+		return reflect.ValueOf(s).Convert(t).Interface(), err
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		data, err := ToWKB(point)
+		return reflect.ValueOf(data).Convert(t).Interface(), err
+	}
+	return nil, fmt.Errorf("location field must be string or []byte, not %s", t.String())
+}
+func decodeSql(v interface{}) (float64, float64, bool) {
+	var g Geometry
+	var err error
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.String {
+		s := strings.TrimSpace(rv.String())
+		if len(s) == 0 {
+			return 0, 0, false
+		}
+		if _, er0 := hex.DecodeString(s); er0 == nil {
+			g, err = FromHexWKB(s)
+		} else {
+			g, err = FromWKT(s)
+		}
+	} else if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		data := rv.Bytes()
+		if len(data) == 0 {
+			return 0, 0, false
+		}
+		g, err = FromWKB(data)
+		if err != nil && len(data) > 4 {
+			g, err = FromWKB(data[4:])
+		}
+	} else {
+		return 0, 0, false
+	}
+	if err != nil {
+		return 0, 0, false
+	}
+	p, err := g.Point()
+	if err != nil {
+		return 0, 0, false
+	}
+	return p[1], p[0], true
+}
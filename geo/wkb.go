@@ -0,0 +1,248 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	wkbPoint        = 1
+	wkbLineString   = 2
+	wkbPolygon      = 3
+	wkbMultiPolygon = 6
+	ewkbZ           = 0x80000000
+	ewkbSRID        = 0x20000000
+)
+
+// ToWKB converts the geometry to the little endian Well-Known Binary.
+func ToWKB(g Geometry) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	switch g.Type {
+	case TypePoint:
+		p, err := g.Point()
+		if err != nil {
+			return nil, err
+		}
+		writeHeader(buf, wkbPoint)
+		writePosition(buf, p)
+	case TypeLineString:
+		line, err := g.LineString()
+		if err != nil {
+			return nil, err
+		}
+		writeHeader(buf, wkbLineString)
+		writePositions(buf, line)
+	case TypePolygon:
+		rings, err := g.Polygon()
+		if err != nil {
+			return nil, err
+		}
+		writeHeader(buf, wkbPolygon)
+		writeRings(buf, rings)
+	case TypeMultiPolygon:
+		polygons, err := g.MultiPolygon()
+		if err != nil {
+			return nil, err
+		}
+		writeHeader(buf, wkbMultiPolygon)
+		binary.Write(buf, binary.LittleEndian, uint32(len(polygons)))
+		for _, rings := range polygons {
+			writeHeader(buf, wkbPolygon)
+			writeRings(buf, rings)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported geometry type '%s'", g.Type)
+	}
+	return buf.Bytes(), nil
+}
+
+// ToHexWKB converts the geometry to the hex string of the Well-Known Binary, which can be passed to ST_GeomFromWKB(decode(?, 'hex')) or returned by PostGIS.
+func ToHexWKB(g Geometry) (string, error) {
+	data, err := ToWKB(g)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// FromWKB parses the Well-Known Binary, including the Extended WKB of PostGIS with SRID. The Z values are kept as the third value of the positions.
+func FromWKB(data []byte) (Geometry, error) {
+	r := &wkbReader{data: data}
+	g, err := r.geometry()
+	if err != nil {
+		return Geometry{}, err
+	}
+	if r.i != len(data) {
+		return Geometry{}, errors.New("invalid WKB: unexpected data at the end")
+	}
+	return g, nil
+}
+
+// FromHexWKB parses the hex string of the Well-Known Binary, which is the text format of the PostGIS geometry columns.
+func FromHexWKB(s string) (Geometry, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return Geometry{}, errors.New("invalid hex WKB")
+	}
+	return FromWKB(data)
+}
+
+func writeHeader(buf *bytes.Buffer, t uint32) {
+	buf.WriteByte(1)
+	binary.Write(buf, binary.LittleEndian, t)
+}
+func writePosition(buf *bytes.Buffer, p []float64) {
+	binary.Write(buf, binary.LittleEndian, p[0])
+	binary.Write(buf, binary.LittleEndian, p[1])
+}
+func writePositions(buf *bytes.Buffer, positions [][]float64) {
+	binary.Write(buf, binary.LittleEndian, uint32(len(positions)))
+	for _, p := range positions {
+		writePosition(buf, p)
+	}
+}
+func writeRings(buf *bytes.Buffer, rings [][][]float64) {
+	binary.Write(buf, binary.LittleEndian, uint32(len(rings)))
+	for _, ring := range rings {
+		writePositions(buf, ring)
+	}
+}
+
+type wkbReader struct {
+	data  []byte
+	i     int
+	order binary.ByteOrder
+	z     bool
+}
+
+func (r *wkbReader) uint32() (uint32, error) {
+	if r.i+4 > len(r.data) {
+		return 0, errors.New("invalid WKB: unexpected end of data")
+	}
+	v := r.order.Uint32(r.data[r.i:])
+	r.i += 4
+	return v, nil
+}
+func (r *wkbReader) float64() (float64, error) {
+	if r.i+8 > len(r.data) {
+		return 0, errors.New("invalid WKB: unexpected end of data")
+	}
+	v := math.Float64frombits(r.order.Uint64(r.data[r.i:]))
+	r.i += 8
+	return v, nil
+}
+func (r *wkbReader) header() (uint32, error) {
+	if r.i >= len(r.data) {
+		return 0, errors.New("invalid WKB: unexpected end of data")
+	}
+	switch r.data[r.i] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return 0, errors.New("invalid WKB byte order")
+	}
+	r.i++
+	t, err := r.uint32()
+	if err != nil {
+		return 0, err
+	}
+	if t&ewkbSRID != 0 {
+		if _, err = r.uint32(); err != nil {
+			return 0, err
+		}
+	}
+	r.z = t&ewkbZ != 0
+	t = t &^ (ewkbZ | ewkbSRID | 0x40000000)
+	if t > 1000 && t < 2000 {
+		r.z = true
+		t = t - 1000
+	}
+	return t, nil
+}
+func (r *wkbReader) position() ([]float64, error) {
+	n := 2
+	if r.z {
+		n = 3
+	}
+	p := make([]float64, n)
+	for i := 0; i < n; i++ {
+		v, err := r.float64()
+		if err != nil {
+			return nil, err
+		}
+		p[i] = v
+	}
+	return p, nil
+}
+func (r *wkbReader) positions() ([][]float64, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	positions := make([][]float64, 0)
+	for i := uint32(0); i < n; i++ {
+		p, err := r.position()
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+func (r *wkbReader) rings() ([][][]float64, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	rings := make([][][]float64, 0)
+	for i := uint32(0); i < n; i++ {
+		ring, err := r.positions()
+		if err != nil {
+			return nil, err
+		}
+		rings = append(rings, ring)
+	}
+	return rings, nil
+}
+func (r *wkbReader) geometry() (Geometry, error) {
+	t, err := r.header()
+	if err != nil {
+		return Geometry{}, err
+	}
+	switch t {
+	case wkbPoint:
+		p, err := r.position()
+		return Geometry{Type: TypePoint, Coordinates: p}, err
+	case wkbLineString:
+		line, err := r.positions()
+		return Geometry{Type: TypeLineString, Coordinates: line}, err
+	case wkbPolygon:
+		rings, err := r.rings()
+		return Geometry{Type: TypePolygon, Coordinates: rings}, err
+	case wkbMultiPolygon:
+		n, err := r.uint32()
+		if err != nil {
+			return Geometry{}, err
+		}
+		polygons := make([][][][]float64, 0)
+		for i := uint32(0); i < n; i++ {
+			sub, err := r.geometry()
+			if err != nil {
+				return Geometry{}, err
+			}
+			if sub.Type != TypePolygon {
+				return Geometry{}, errors.New("invalid WKB: MultiPolygon must contain polygons")
+			}
+			polygons = append(polygons, sub.Coordinates.([][][]float64))
+		}
+		return Geometry{Type: TypeMultiPolygon, Coordinates: polygons}, nil
+	default:
+		return Geometry{}, fmt.Errorf("unsupported WKB type %d", t)
+	}
+}
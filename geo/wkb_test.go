@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWKBRoundTrip(t *testing.T) {
+	tests := []Geometry{
+		NewPoint(10.8, 106.7),
+		NewLineString([][]float64{{100, 0}, {101.5, 1.25}}),
+		NewPolygon(square),
+		NewMultiPolygon([][][][]float64{square, {{{-1, -1}, {1, -1}, {1, 1}, {-1, -1}}}}),
+	}
+	for _, g := range tests {
+		data, err := ToWKB(g)
+		if err != nil {
+			t.Fatalf("%s: %v", g.Type, err)
+		}
+		g2, err := FromWKB(data)
+		if err != nil {
+			t.Fatalf("FromWKB of %s: %v", g.Type, err)
+		}
+		if !reflect.DeepEqual(g2, g) {
+			t.Errorf("FromWKB = %v, want %v", g2, g)
+		}
+		s, err := ToHexWKB(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g2, err = FromHexWKB(s); err != nil || !reflect.DeepEqual(g2, g) {
+			t.Errorf("FromHexWKB(%q) = %v, %v, want %v", s, g2, err, g)
+		}
+	}
+}
+
+func TestFromHexWKB(t *testing.T) {
+	tests := []struct {
+		hex      string
+		geometry Geometry
+	}{
+		// POINT(1 2), big endian
+		{"00000000013ff00000000000004000000000000000", Geometry{Type: TypePoint, Coordinates: []float64{1, 2}}},
+		// SRID=4326;POINT(1 2) of PostGIS
+		{"0101000020e6100000000000000000f03f0000000000000040", Geometry{Type: TypePoint, Coordinates: []float64{1, 2}}},
+		// POINT Z (1 2 3) of ISO WKB
+		{"01e9030000000000000000f03f00000000000000400000000000000840", Geometry{Type: TypePoint, Coordinates: []float64{1, 2, 3}}},
+	}
+	for _, tt := range tests {
+		g, err := FromHexWKB(tt.hex)
+		if err != nil {
+			t.Fatalf("FromHexWKB(%q): %v", tt.hex, err)
+		}
+		if !reflect.DeepEqual(g, tt.geometry) {
+			t.Errorf("FromHexWKB(%q) = %v, want %v", tt.hex, g, tt.geometry)
+		}
+	}
+}
+
+func TestFromMalformedWKB(t *testing.T) {
+	tests := []string{
+		"",
+		"zz",
+		// invalid byte order
+		"0201000000000000000000f03f0000000000000040",
+		// unsupported type 4, MultiPoint
+		"010400000000000000",
+		// POINT(1 2) without the last byte
+		"0101000000000000000000f03f00000000000000",
+		// POINT(1 2) with an extra byte
+		"0101000000000000000000f03f000000000000004000",
+		// LINESTRING of 2 positions with 1 position
+		"010200000002000000000000000000f03f0000000000000040",
+		// MULTIPOLYGON which contains a point
+		"01060000000100000001010000000000000000000000000000000000000000",
+	}
+	for _, s := range tests {
+		if g, err := FromHexWKB(s); err == nil {
+			t.Errorf("FromHexWKB(%q) = %v, want the error", s, g)
+		}
+	}
+}
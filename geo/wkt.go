@@ -0,0 +1,189 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ToWKT converts the geometry to the Well-Known Text, such as "POINT(106.7 10.8)".
+func ToWKT(g Geometry) (string, error) {
+	switch g.Type {
+	case TypePoint:
+		p, err := g.Point()
+		if err != nil {
+			return "", err
+		}
+		return "POINT(" + wktPosition(p) + ")", nil
+	case TypeLineString:
+		line, err := g.LineString()
+		if err != nil {
+			return "", err
+		}
+		return "LINESTRING" + wktPositions(line), nil
+	case TypePolygon:
+		rings, err := g.Polygon()
+		if err != nil {
+			return "", err
+		}
+		return "POLYGON" + wktRings(rings), nil
+	case TypeMultiPolygon:
+		polygons, err := g.MultiPolygon()
+		if err != nil {
+			return "", err
+		}
+		s := make([]string, 0)
+		for _, rings := range polygons {
+			s = append(s, wktRings(rings))
+		}
+		return "MULTIPOLYGON(" + strings.Join(s, ",") + ")", nil
+	default:
+		return "", fmt.Errorf("unsupported geometry type '%s'", g.Type)
+	}
+}
+
+// FromWKT parses the Well-Known Text. The SRID prefix of the Extended WKT, such as "SRID=4326;", is ignored.
+func FromWKT(s string) (Geometry, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		if i := strings.Index(s, ";"); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	i := strings.Index(s, "(")
+	if i < 0 {
+		return Geometry{}, fmt.Errorf("invalid WKT '%s'", s)
+	}
+	name := strings.ToUpper(strings.TrimSpace(s[:i]))
+	name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(name, "ZM"), "Z"))
+	var t string
+	var depth int
+	switch name {
+	case "POINT":
+		t, depth = TypePoint, 1
+	case "LINESTRING":
+		t, depth = TypeLineString, 1
+	case "POLYGON":
+		t, depth = TypePolygon, 2
+	case "MULTIPOLYGON":
+		t, depth = TypeMultiPolygon, 3
+	default:
+		return Geometry{}, fmt.Errorf("unsupported WKT type '%s'", name)
+	}
+	p := &wktParser{s: s, i: i}
+	v, err := p.list(depth)
+	if err != nil {
+		return Geometry{}, err
+	}
+	p.skipSpaces()
+	if p.i < len(p.s) {
+		return Geometry{}, fmt.Errorf("invalid WKT '%s'", s)
+	}
+	g := Geometry{Type: t, Coordinates: v}
+	switch t {
+	case TypePoint:
+		points, er1 := toPositions(v)
+		if er1 != nil || len(points) != 1 {
+			return Geometry{}, fmt.Errorf("invalid WKT '%s'", s)
+		}
+		return Geometry{Type: t, Coordinates: points[0]}, nil
+	case TypeLineString:
+		line, er1 := g.LineString()
+		return Geometry{Type: t, Coordinates: line}, er1
+	case TypePolygon:
+		rings, er1 := g.Polygon()
+		return Geometry{Type: t, Coordinates: rings}, er1
+	default:
+		polygons, er1 := g.MultiPolygon()
+		return Geometry{Type: t, Coordinates: polygons}, er1
+	}
+}
+
+type wktParser struct {
+	s string
+	i int
+}
+
+func (p *wktParser) skipSpaces() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t' || p.s[p.i] == '\n' || p.s[p.i] == '\r') {
+		p.i++
+	}
+}
+
+// list parses "(item, item)". The items of depth 1 are the positions, such as "106.7 10.8".
+func (p *wktParser) list(depth int) ([]interface{}, error) {
+	p.skipSpaces()
+	if p.i >= len(p.s) || p.s[p.i] != '(' {
+		return nil, errors.New("invalid WKT: '(' is expected")
+	}
+	p.i++
+	items := make([]interface{}, 0)
+	for {
+		var item interface{}
+		var err error
+		if depth > 1 {
+			item, err = p.list(depth - 1)
+		} else {
+			item, err = p.position()
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpaces()
+		if p.i >= len(p.s) {
+			return nil, errors.New("invalid WKT: ')' is expected")
+		}
+		if p.s[p.i] == ',' {
+			p.i++
+			continue
+		}
+		if p.s[p.i] == ')' {
+			p.i++
+			return items, nil
+		}
+		return nil, fmt.Errorf("invalid WKT: unexpected '%c'", p.s[p.i])
+	}
+}
+func (p *wktParser) position() ([]float64, error) {
+	start := p.i
+	for p.i < len(p.s) && p.s[p.i] != ',' && p.s[p.i] != ')' {
+		p.i++
+	}
+	fields := strings.Fields(p.s[start:p.i])
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("invalid WKT position '%s'", strings.TrimSpace(p.s[start:p.i]))
+	}
+	position := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WKT number '%s'", f)
+		}
+		position[i] = v
+	}
+	return position, nil
+}
+
+func wktPosition(p []float64) string {
+	s := make([]string, len(p))
+	for i, v := range p {
+		s[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(s, " ")
+}
+func wktPositions(positions [][]float64) string {
+	s := make([]string, len(positions))
+	for i, p := range positions {
+		s[i] = wktPosition(p)
+	}
+	return "(" + strings.Join(s, ",") + ")"
+}
+func wktRings(rings [][][]float64) string {
+	s := make([]string, len(rings))
+	for i, ring := range rings {
+		s[i] = wktPositions(ring)
+	}
+	return "(" + strings.Join(s, ",") + ")"
+}
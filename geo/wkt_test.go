@@ -0,0 +1,85 @@
+package geo
+
+import (
+	"reflect"
+	"testing"
+)
+
+var square = [][][]float64{{{100, 0}, {101, 0}, {101, 1}, {100, 1}, {100, 0}}}
+
+func TestWKTRoundTrip(t *testing.T) {
+	tests := []struct {
+		geometry Geometry
+		wkt      string
+	}{
+		{NewPoint(10.8, 106.7), "POINT(106.7 10.8)"},
+		{NewLineString([][]float64{{100, 0}, {101.5, 1.25}}), "LINESTRING(100 0,101.5 1.25)"},
+		{NewPolygon(square), "POLYGON((100 0,101 0,101 1,100 1,100 0))"},
+		{NewMultiPolygon([][][][]float64{square, {{{-1, -1}, {1, -1}, {1, 1}, {-1, -1}}}}), "MULTIPOLYGON(((100 0,101 0,101 1,100 1,100 0)),((-1 -1,1 -1,1 1,-1 -1)))"},
+	}
+	for _, tt := range tests {
+		wkt, err := ToWKT(tt.geometry)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.geometry.Type, err)
+		}
+		if wkt != tt.wkt {
+			t.Errorf("ToWKT = %q, want %q", wkt, tt.wkt)
+		}
+		g, err := FromWKT(wkt)
+		if err != nil {
+			t.Fatalf("FromWKT(%q): %v", wkt, err)
+		}
+		if !reflect.DeepEqual(g, tt.geometry) {
+			t.Errorf("FromWKT(%q) = %v, want %v", wkt, g, tt.geometry)
+		}
+	}
+}
+
+func TestFromWKTOfExtendedText(t *testing.T) {
+	g, err := FromWKT(" SRID=4326; point z ( 106.7  10.8 5 ) ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Geometry{Type: TypePoint, Coordinates: []float64{106.7, 10.8, 5}}); !reflect.DeepEqual(g, want) {
+		t.Errorf("FromWKT = %v, want %v", g, want)
+	}
+}
+
+func TestFromMalformedWKT(t *testing.T) {
+	tests := []string{
+		"",
+		"POINT EMPTY",
+		"POINT()",
+		"POINT(1)",
+		"POINT(1 2 3 4)",
+		"POINT(1 2",
+		"POINT(1 2) x",
+		"POINT(1 2, 3 4)",
+		"POINT(a 2)",
+		"LINESTRING(1 2,)",
+		"LINESTRING 1 2, 3 4",
+		"POLYGON(1 2, 3 4)",
+		"POLYGON((1 2, 3 4)",
+		"MULTIPOLYGON((1 2, 3 4))",
+		"CIRCLE(1 2)",
+	}
+	for _, s := range tests {
+		if g, err := FromWKT(s); err == nil {
+			t.Errorf("FromWKT(%q) = %v, want the error", s, g)
+		}
+	}
+}
+
+func TestToWKTOfInvalidGeometry(t *testing.T) {
+	tests := []Geometry{
+		{Type: "Circle", Coordinates: []float64{1, 2}},
+		{Type: TypePoint, Coordinates: []float64{1}},
+		{Type: TypeLineString, Coordinates: []float64{1, 2}},
+		{Type: TypePolygon, Coordinates: "x"},
+	}
+	for _, g := range tests {
+		if s, err := ToWKT(g); err == nil {
+			t.Errorf("ToWKT(%v) = %q, want the error", g, s)
+		}
+	}
+}
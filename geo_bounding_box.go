@@ -0,0 +1,13 @@
+package search
+
+// GeoBoundingBox filters the locations in the box. Top and Bottom are latitudes, Left and Right are longitudes.
+type GeoBoundingBox struct {
+	Top    float64 `yaml:"top" mapstructure:"top" json:"top,omitempty" gorm:"column:top" bson:"top,omitempty" dynamodbav:"top,omitempty" firestore:"top,omitempty"`
+	Left   float64 `yaml:"left" mapstructure:"left" json:"left,omitempty" gorm:"column:left" bson:"left,omitempty" dynamodbav:"left,omitempty" firestore:"left,omitempty"`
+	Bottom float64 `yaml:"bottom" mapstructure:"bottom" json:"bottom,omitempty" gorm:"column:bottom" bson:"bottom,omitempty" dynamodbav:"bottom,omitempty" firestore:"bottom,omitempty"`
+	Right  float64 `yaml:"right" mapstructure:"right" json:"right,omitempty" gorm:"column:right" bson:"right,omitempty" dynamodbav:"right,omitempty" firestore:"right,omitempty"`
+}
+
+func (b GeoBoundingBox) IsEmpty() bool {
+	return b.Top == 0 && b.Left == 0 && b.Bottom == 0 && b.Right == 0
+}
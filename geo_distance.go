@@ -0,0 +1,11 @@
+package search
+
+// Distance is the sort field to order by the distance from the point of GeoDistance, such as "distance" or "-distance".
+const Distance = "distance"
+
+// GeoDistance filters the locations within Radius meters from the point.
+type GeoDistance struct {
+	Latitude  float64 `yaml:"latitude" mapstructure:"latitude" json:"latitude,omitempty" gorm:"column:latitude" bson:"latitude,omitempty" dynamodbav:"latitude,omitempty" firestore:"latitude,omitempty"`
+	Longitude float64 `yaml:"longitude" mapstructure:"longitude" json:"longitude,omitempty" gorm:"column:longitude" bson:"longitude,omitempty" dynamodbav:"longitude,omitempty" firestore:"longitude,omitempty"`
+	Radius    float64 `yaml:"radius" mapstructure:"radius" json:"radius,omitempty" gorm:"column:radius" bson:"radius,omitempty" dynamodbav:"radius,omitempty" firestore:"radius,omitempty"`
+}
@@ -0,0 +1,9 @@
+package search
+
+import "github.com/core-go/search/geo"
+
+// GeoWithin filters the locations within the polygon or the multi polygon.
+type GeoWithin geo.Geometry
+
+// GeoIntersects filters the locations or the shapes which intersect the geometry.
+type GeoIntersects geo.Geometry
@@ -0,0 +1,341 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/core-go/search"
+)
+
+// Index is the inverted index in memory of the string fields of T with the q tag, such as `q:"like"` or `q:"like,weight=3"`, which ranks the documents by BM25.
+// The id of the document is the field with the bson tag "_id", or the json tag "id". It is safe for the concurrent use.
+type Index[T any] struct {
+	// K1 is the saturation of the term frequency, and B is the normalization of the length of the document. The defaults are 1.2 and 0.75.
+	K1       float64
+	B        float64
+	Tokenize Tokenizer
+	// MatchAll requires the documents to match all the words of the keyword, instead of any of them.
+	MatchAll bool
+
+	fields      []field
+	idIndex     int
+	mu          sync.RWMutex
+	documents   map[string]*document[T]
+	postings    map[string]map[string]float64
+	totalLength float64
+	seq         int64
+}
+
+// Hit is the document which matches the keyword, with its BM25 score.
+type Hit[T any] struct {
+	Id    string
+	Score float64
+	Model T
+}
+
+type field struct {
+	index  int
+	weight float64
+}
+type document[T any] struct {
+	Model T
+	Seq   int64
+	// Terms is the weighted frequency of the terms, and Length is the weighted number of the terms.
+	Terms  map[string]float64
+	Length float64
+}
+
+// NewIndex creates the index of T, which must have the id field and at least one field with the q tag.
+func NewIndex[T any](options ...func(*Index[T])) (*Index[T], error) {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return nil, errors.New("index: the model must be a struct")
+	}
+	idIndex := search.FindIdField(modelType)
+	if idIndex < 0 {
+		return nil, fmt.Errorf("index: %s has no id field", modelType.Name())
+	}
+	fields := getFields(modelType)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("index: %s has no string field with the q tag", modelType.Name())
+	}
+	x := &Index[T]{K1: 1.2, B: 0.75, Tokenize: Tokenize, fields: fields, idIndex: idIndex}
+	x.reset()
+	for _, opt := range options {
+		if opt != nil {
+			opt(x)
+		}
+	}
+	return x, nil
+}
+
+// Add adds the models to the index. The model which has the same id as a document of the index replaces it.
+func (x *Index[T]) Add(models ...T) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for _, model := range models {
+		id := x.getId(model)
+		var seq int64
+		if old, ok := x.documents[id]; ok {
+			seq = old.Seq
+			x.remove(id, old)
+		} else {
+			x.seq++
+			seq = x.seq
+		}
+		x.insert(id, x.analyze(model, seq))
+	}
+}
+
+// Update replaces the document of the model, and returns false if there is no document with the same id.
+func (x *Index[T]) Update(model T) bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	id := x.getId(model)
+	old, ok := x.documents[id]
+	if !ok {
+		return false
+	}
+	x.remove(id, old)
+	x.insert(id, x.analyze(model, old.Seq))
+	return true
+}
+
+// Delete deletes the documents of the ids, and returns the number of the deleted documents.
+func (x *Index[T]) Delete(ids ...string) int64 {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var count int64
+	for _, id := range ids {
+		if doc, ok := x.documents[id]; ok {
+			x.remove(id, doc)
+			count++
+		}
+	}
+	return count
+}
+
+// Get gets the model of the id, or nil if it is not in the index.
+func (x *Index[T]) Get(id string) *T {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if doc, ok := x.documents[id]; ok {
+		model := doc.Model
+		return &model
+	}
+	return nil
+}
+
+// Len returns the number of the documents.
+func (x *Index[T]) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.documents)
+}
+
+// Search searches the words of the keyword, and returns the hits which pass the filter, from the greatest score.
+// If the keyword is empty, all the documents which pass the filter are returned in the order they were added.
+func (x *Index[T]) Search(keyword string, filter func(*T) bool) []Hit[T] {
+	tokens := x.Tokenize(keyword)
+	terms := make([][]string, len(tokens))
+	for i, t := range tokens {
+		terms[i] = []string{t}
+	}
+	return x.SearchTerms(terms, filter)
+}
+
+// SearchTerms searches the groups of the terms of the keyword expanded by a search.Expander, such as [["tv", "television"], ["show"]].
+// The score of a group is the greatest score of its terms, and a term of some words, such as "united states", matches if the document has all of them.
+func (x *Index[T]) SearchTerms(terms [][]string, filter func(*T) bool) []Hit[T] {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	groups := make([][][]string, 0, len(terms))
+	for _, group := range terms {
+		g := make([][]string, 0, len(group))
+		for _, term := range group {
+			if tokens := x.Tokenize(term); len(tokens) > 0 {
+				g = append(g, tokens)
+			}
+		}
+		if len(g) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	hits := make([]Hit[T], 0)
+	if len(groups) == 0 {
+		for id, doc := range x.documents {
+			if filter == nil || filter(&doc.Model) {
+				hits = append(hits, Hit[T]{Id: id, Model: doc.Model})
+			}
+		}
+		x.sortBySeq(hits)
+		return hits
+	}
+	scores := make(map[string]float64)
+	matches := make(map[string]int)
+	for _, group := range groups {
+		groupScores := make(map[string]float64)
+		for _, tokens := range group {
+			for id, score := range x.scoreTokens(tokens) {
+				if score > groupScores[id] {
+					groupScores[id] = score
+				}
+			}
+		}
+		for id, score := range groupScores {
+			scores[id] += score
+			matches[id]++
+		}
+	}
+	for id, score := range scores {
+		if x.MatchAll && matches[id] < len(groups) {
+			continue
+		}
+		doc := x.documents[id]
+		if filter == nil || filter(&doc.Model) {
+			hits = append(hits, Hit[T]{Id: id, Score: score, Model: doc.Model})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return x.documents[hits[i].Id].Seq < x.documents[hits[j].Id].Seq
+	})
+	return hits
+}
+
+// scoreTokens computes the BM25 scores of the documents which have all the tokens, which is the sum of the scores of the tokens.
+func (x *Index[T]) scoreTokens(tokens []string) map[string]float64 {
+	scores := make(map[string]float64)
+	n := float64(len(x.documents))
+	if n == 0 {
+		return scores
+	}
+	avgLength := x.totalLength / n
+	counts := make(map[string]int)
+	for _, token := range tokens {
+		postings := x.postings[token]
+		df := float64(len(postings))
+		if df == 0 {
+			return make(map[string]float64)
+		}
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			norm := 1 - x.B
+			if avgLength > 0 {
+				norm += x.B * x.documents[id].Length / avgLength
+			}
+			scores[id] += idf * tf * (x.K1 + 1) / (tf + x.K1*norm)
+			counts[id]++
+		}
+	}
+	for id := range scores {
+		if counts[id] < len(tokens) {
+			delete(scores, id)
+		}
+	}
+	return scores
+}
+
+func (x *Index[T]) analyze(model T, seq int64) *document[T] {
+	doc := &document[T]{Model: model, Seq: seq, Terms: make(map[string]float64)}
+	value := reflect.ValueOf(model)
+	for _, f := range x.fields {
+		for _, text := range getTexts(value.Field(f.index)) {
+			for _, t := range x.Tokenize(text) {
+				doc.Terms[t] += f.weight
+				doc.Length += f.weight
+			}
+		}
+	}
+	return doc
+}
+func (x *Index[T]) insert(id string, doc *document[T]) {
+	x.documents[id] = doc
+	x.totalLength += doc.Length
+	for t, tf := range doc.Terms {
+		postings, ok := x.postings[t]
+		if !ok {
+			postings = make(map[string]float64)
+			x.postings[t] = postings
+		}
+		postings[id] = tf
+	}
+}
+func (x *Index[T]) remove(id string, doc *document[T]) {
+	delete(x.documents, id)
+	x.totalLength -= doc.Length
+	for t := range doc.Terms {
+		if postings, ok := x.postings[t]; ok {
+			delete(postings, id)
+			if len(postings) == 0 {
+				delete(x.postings, t)
+			}
+		}
+	}
+}
+func (x *Index[T]) reset() {
+	x.documents = make(map[string]*document[T])
+	x.postings = make(map[string]map[string]float64)
+	x.totalLength = 0
+	x.seq = 0
+}
+func (x *Index[T]) sortBySeq(hits []Hit[T]) {
+	sort.Slice(hits, func(i, j int) bool {
+		return x.documents[hits[i].Id].Seq < x.documents[hits[j].Id].Seq
+	})
+}
+func (x *Index[T]) getId(model T) string {
+	v := reflect.Indirect(reflect.ValueOf(model).Field(x.idIndex))
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func getFields(modelType reflect.Type) []field {
+	fields := make([]field, 0)
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		sf := modelType.Field(i)
+		tag, ok := sf.Tag.Lookup("q")
+		if !ok || tag == "-" || !sf.IsExported() || !isText(sf.Type) {
+			continue
+		}
+		weight := float64(1)
+		if s, ok := search.GetOption(tag, search.Weight); ok {
+			if w, err := strconv.ParseFloat(s, 64); err == nil && w > 0 {
+				weight = w
+			}
+		}
+		fields = append(fields, field{index: i, weight: weight})
+	}
+	return fields
+}
+func isText(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+func getTexts(v reflect.Value) []string {
+	v = reflect.Indirect(v)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Slice {
+		texts := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			texts[i] = v.Index(i).String()
+		}
+		return texts
+	}
+	return []string{v.String()}
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+type article struct {
+	Id     string   `json:"id"`
+	Title  string   `json:"title" q:"like,weight=3"`
+	Body   string   `json:"body" q:"like"`
+	Tags   []string `json:"tags" q:"like"`
+	Rating int      `json:"rating"`
+}
+
+var articles = []article{
+	{Id: "1", Title: "Go in action", Body: "concurrency and channels", Rating: 3},
+	{Id: "2", Title: "Rust book", Body: "go is mentioned once, ownership and borrowing", Rating: 5},
+	{Id: "3", Title: "Java", Body: "the virtual machine", Tags: []string{"jvm", "go"}, Rating: 4},
+	{Id: "4", Title: "Cooking", Body: "pasta and pizza", Rating: 1},
+}
+
+func newArticleIndex(t *testing.T, models ...article) *Index[article] {
+	x, err := NewIndex[article]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Add(models...)
+	return x
+}
+func ids(hits []Hit[article]) []string {
+	s := make([]string, len(hits))
+	for i, h := range hits {
+		s[i] = h.Id
+	}
+	return s
+}
+
+func TestNewIndexOfInvalidModel(t *testing.T) {
+	type noId struct {
+		Name string `json:"name" q:"like"`
+	}
+	type noText struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if _, err := NewIndex[noId](); err == nil {
+		t.Error("expected the error of the model without id")
+	}
+	if _, err := NewIndex[noText](); err == nil {
+		t.Error("expected the error of the model without the q tag")
+	}
+}
+
+func TestSearchByBM25(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	// the weight of the title is 3, so "go" of the title ranks first; "2" has the longer document than "3", so it ranks last
+	hits := x.Search("Go", nil)
+	if got, want := ids(hits), []string{"1", "3", "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Search(go) = %v, want %v", got, want)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i-1].Score <= hits[i].Score {
+			t.Errorf("score of %s = %v, not greater than %v of %s", hits[i-1].Id, hits[i-1].Score, hits[i].Score, hits[i].Id)
+		}
+	}
+	// the rare term has the greater idf than the common term
+	hits = x.Search("go pasta", nil)
+	if len(hits) != 4 || hits[0].Id != "4" {
+		t.Errorf("Search(go pasta) = %v, want 4 first", ids(hits))
+	}
+	x.MatchAll = true
+	if got := ids(x.Search("go ownership", nil)); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("Search(go ownership) with MatchAll = %v, want [2]", got)
+	}
+	x.MatchAll = false
+	if got := ids(x.Search("go", func(a *article) bool { return a.Rating >= 4 })); !reflect.DeepEqual(got, []string{"3", "2"}) {
+		t.Errorf("Search(go) with filter = %v, want [3 2]", got)
+	}
+	if got := ids(x.Search("", nil)); !reflect.DeepEqual(got, []string{"1", "2", "3", "4"}) {
+		t.Errorf("Search of empty keyword = %v, want the order of Add", got)
+	}
+	if hits := x.Search("python", nil); len(hits) != 0 {
+		t.Errorf("Search(python) = %v, want no hit", ids(hits))
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	// a term of some words matches only the documents which have all of them
+	if got := ids(x.SearchTerms([][]string{{"virtual machine", "pizza"}}, nil)); len(got) != 2 || got[0] == got[1] {
+		t.Errorf("SearchTerms = %v, want 3 and 4", got)
+	}
+	if got := ids(x.SearchTerms([][]string{{"machine virtual pasta"}}, nil)); len(got) != 0 {
+		t.Errorf("SearchTerms = %v, want no hit", got)
+	}
+}
+
+func TestUpdateAndDelete(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	updated := article{Id: "2", Title: "Rust", Body: "ownership"}
+	if !x.Update(updated) {
+		t.Fatal("Update returns false")
+	}
+	if x.Update(article{Id: "9", Title: "none"}) {
+		t.Error("Update of the unknown id returns true")
+	}
+	if n := x.Delete("4", "9"); n != 1 {
+		t.Errorf("Delete = %d, want 1", n)
+	}
+	x.Add(article{Id: "1", Title: "Go in practice"})
+	expected := newArticleIndex(t, article{Id: "1", Title: "Go in practice"}, updated, articles[2])
+	if !reflect.DeepEqual(x.postings, expected.postings) {
+		t.Errorf("postings = %v, want %v", x.postings, expected.postings)
+	}
+	if math.Abs(x.totalLength-expected.totalLength) > 1e-9 {
+		t.Errorf("totalLength = %v, want %v", x.totalLength, expected.totalLength)
+	}
+	if _, ok := x.postings["pasta"]; ok {
+		t.Error("the postings of the deleted document are kept")
+	}
+	if x.Len() != 3 || x.Get("4") != nil || x.Get("2").Title != "Rust" {
+		t.Errorf("unexpected documents after Update and Delete")
+	}
+	// the updated documents keep their order
+	if got := ids(x.Search("", nil)); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("Search of empty keyword = %v, want [1 2 3]", got)
+	}
+	h1, h2 := x.Search("go rust", nil), expected.Search("go rust", nil)
+	if !reflect.DeepEqual(h1, h2) {
+		t.Errorf("Search = %v, want %v", h1, h2)
+	}
+	x.Delete("1", "2", "3")
+	if len(x.postings) != 0 || x.totalLength != 0 {
+		t.Errorf("postings = %v and totalLength = %v of the empty index", x.postings, x.totalLength)
+	}
+}
@@ -0,0 +1,166 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/core-go/search"
+)
+
+// SearchBuilder searches the index by Q or Terms of the filter, so the index can be used by the search handlers instead of a database.
+// The results are ordered by the relevance if the sort is empty, or by the sort of the json names, such as "-relevance,name".
+type SearchBuilder[T any, F any] struct {
+	Index *Index[T]
+	// Match checks the other conditions of the filter, which are not in the index, such as the ranges.
+	Match   func(*T, F) bool
+	GetSort func(m interface{}) string
+	Map     func(*T)
+}
+
+func NewSearchBuilder[T any, F any](index *Index[T], match func(*T, F) bool, getSort func(interface{}) string, options ...func(*T)) *SearchBuilder[T, F] {
+	var mp func(*T)
+	if len(options) > 0 && options[0] != nil {
+		mp = options[0]
+	}
+	return &SearchBuilder[T, F]{Index: index, Match: match, GetSort: getSort, Map: mp}
+}
+
+func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
+	var keyword string
+	var terms [][]string
+	var excluding []string
+	if f := search.GetFilter(filter); f != nil {
+		keyword, terms, excluding = f.Q, f.Terms, f.Excluding
+	}
+	match := func(model *T) bool {
+		if len(excluding) > 0 && search.Contains(excluding, b.Index.getId(*model)) {
+			return false
+		}
+		return b.Match == nil || b.Match(model, filter)
+	}
+	var hits []Hit[T]
+	if len(terms) > 0 {
+		hits = b.Index.SearchTerms(terms, match)
+	} else {
+		hits = b.Index.Search(keyword, match)
+	}
+	if b.GetSort != nil {
+		if s := b.GetSort(filter); len(s) > 0 {
+			SortHits(hits, s)
+		}
+	}
+	total := int64(len(hits))
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	results := make([]T, 0, end-offset)
+	for _, hit := range hits[offset:end] {
+		model := hit.Model
+		if b.Map != nil {
+			b.Map(&model)
+		}
+		results = append(results, model)
+	}
+	return results, total, nil
+}
+
+// SortHits sorts the hits by the sort of the json names of the model, such as "-relevance,name". The prefix "-" is descending.
+func SortHits[T any](hits []Hit[T], s string) {
+	type sortField struct {
+		index int
+		desc  bool
+	}
+	var t T
+	modelType := reflect.TypeOf(t)
+	fields := make([]sortField, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		desc := item[0] == '-'
+		if item[0] == '-' || item[0] == '+' {
+			item = item[1:]
+		}
+		if item == search.Relevance {
+			fields = append(fields, sortField{index: -1, desc: desc})
+		} else if i := search.FindFieldByJson(modelType, item); i >= 0 {
+			fields = append(fields, sortField{index: i, desc: desc})
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		for _, f := range fields {
+			var c int
+			if f.index < 0 {
+				c = compareFloat(hits[i].Score, hits[j].Score)
+			} else {
+				c = compare(reflect.ValueOf(hits[i].Model).Field(f.index), reflect.ValueOf(hits[j].Model).Field(f.index))
+			}
+			if c != 0 {
+				return (c < 0) != f.desc
+			}
+		}
+		return false
+	})
+}
+
+func compare(a reflect.Value, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return compareBool(!a.IsNil(), !b.IsNil())
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareFloat(float64(a.Int()), float64(b.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareFloat(float64(a.Uint()), float64(b.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(a.Float(), b.Float())
+	case reflect.Bool:
+		return compareBool(a.Bool(), b.Bool())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	}
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb := b.Interface().(time.Time)
+		if ta.Before(tb) {
+			return -1
+		} else if ta.After(tb) {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+func compareFloat(a float64, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+func compareBool(a bool, b bool) int {
+	if a == b {
+		return 0
+	} else if a {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,69 @@
+package index
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/core-go/search"
+)
+
+type articleFilter struct {
+	*search.Filter
+	MinRating int `json:"minRating"`
+}
+
+func getSort(m interface{}) string {
+	return m.(articleFilter).Sort
+}
+func idsOf(models []article) []string {
+	s := make([]string, len(models))
+	for i, m := range models {
+		s[i] = m.Id
+	}
+	return s
+}
+
+func TestSearchBuilder(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	b := NewSearchBuilder[article, articleFilter](x, func(a *article, f articleFilter) bool { return a.Rating >= f.MinRating }, getSort)
+	tests := []struct {
+		name   string
+		filter articleFilter
+		limit  int64
+		offset int64
+		ids    []string
+		total  int64
+	}{
+		{"relevance", articleFilter{Filter: &search.Filter{Q: "go"}}, 0, 0, []string{"1", "3", "2"}, 3},
+		{"first page", articleFilter{Filter: &search.Filter{Q: "go"}}, 2, 0, []string{"1", "3"}, 3},
+		{"last page", articleFilter{Filter: &search.Filter{Q: "go"}}, 2, 2, []string{"2"}, 3},
+		{"offset after the end", articleFilter{Filter: &search.Filter{Q: "go"}}, 2, 5, []string{}, 3},
+		{"sort", articleFilter{Filter: &search.Filter{Q: "go", Sort: "-rating"}}, 0, 0, []string{"2", "3", "1"}, 3},
+		{"sort by relevance then title", articleFilter{Filter: &search.Filter{Sort: "relevance,title"}}, 0, 0, []string{"4", "1", "3", "2"}, 4},
+		{"match", articleFilter{Filter: &search.Filter{Q: "go"}, MinRating: 4}, 0, 0, []string{"3", "2"}, 2},
+		{"excluding", articleFilter{Filter: &search.Filter{Q: "go", Excluding: []string{"1", "2"}}}, 0, 0, []string{"3"}, 1},
+		{"terms", articleFilter{Filter: &search.Filter{Q: "tv", Terms: [][]string{{"pizza", "jvm"}}}}, 0, 0, []string{"4", "3"}, 2},
+	}
+	for _, tt := range tests {
+		models, total, err := b.Search(context.Background(), tt.filter, tt.limit, tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := idsOf(models); total != tt.total || !reflect.DeepEqual(got, tt.ids) {
+			t.Errorf("%s: Search = %v, %d, want %v, %d", tt.name, got, total, tt.ids, tt.total)
+		}
+	}
+}
+
+func TestSearchBuilderMap(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	b := NewSearchBuilder[article, articleFilter](x, nil, getSort, func(a *article) { a.Body = "" })
+	models, _, err := b.Search(context.Background(), articleFilter{Filter: &search.Filter{Q: "pasta"}}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Body != "" || x.Get("4").Body == "" {
+		t.Errorf("Search = %v: Map must change the results only", models)
+	}
+}
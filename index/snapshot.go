@@ -0,0 +1,68 @@
+package index
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// snapshot is the documents of the index, which are encoded by gob. The postings are rebuilt from the terms of the documents when the snapshot is loaded.
+type snapshot[T any] struct {
+	Seq       int64
+	Documents map[string]*document[T]
+}
+
+// Save writes the snapshot of the index. The model must be encoded by gob, so the interface fields must be registered by gob.Register.
+func (x *Index[T]) Save(w io.Writer) error {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(snapshot[T]{Seq: x.seq, Documents: x.documents})
+}
+
+// Load replaces the documents of the index by the snapshot. The terms are not tokenized again, so the snapshot must be saved with the same tokenizer and fields.
+func (x *Index[T]) Load(r io.Reader) error {
+	var s snapshot[T]
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.reset()
+	for id, doc := range s.Documents {
+		if doc.Terms == nil {
+			doc.Terms = make(map[string]float64)
+		}
+		x.insert(id, doc)
+	}
+	x.seq = s.Seq
+	return nil
+}
+
+// SaveFile writes the snapshot to a temporary file, then renames it to the file, so the file is never partially written.
+func (x *Index[T]) SaveFile(filename string) error {
+	file, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := file.Name()
+	err = x.Save(file)
+	if err1 := file.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// LoadFile loads the snapshot of the file.
+func (x *Index[T]) LoadFile(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return x.Load(file)
+}
@@ -0,0 +1,61 @@
+package index
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	x.Delete("2")
+	var buf bytes.Buffer
+	if err := x.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	y := newArticleIndex(t, article{Id: "9", Title: "replaced"})
+	if err := y.Load(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(y.documents, x.documents) || !reflect.DeepEqual(y.postings, x.postings) || y.totalLength != x.totalLength || y.seq != x.seq {
+		t.Fatal("the loaded index is not the saved index")
+	}
+	if h1, h2 := y.Search("go", nil), x.Search("go", nil); !reflect.DeepEqual(h1, h2) {
+		t.Errorf("Search = %v, want %v", ids(h1), ids(h2))
+	}
+	// the new document is after the loaded documents
+	y.Add(article{Id: "5", Title: "Go"})
+	if got := ids(y.Search("", nil)); !reflect.DeepEqual(got, []string{"1", "3", "4", "5"}) {
+		t.Errorf("Search of empty keyword = %v, want [1 3 4 5]", got)
+	}
+}
+
+func TestSaveFileAndLoadFile(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	filename := filepath.Join(t.TempDir(), "articles.gob")
+	if err := x.SaveFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	y := newArticleIndex(t)
+	if err := y.LoadFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if y.Len() != len(articles) || !reflect.DeepEqual(y.postings, x.postings) {
+		t.Error("the loaded file is not the saved index")
+	}
+	if err := y.LoadFile(filename + ".none"); err == nil {
+		t.Error("expected the error of the file which does not exist")
+	}
+}
+
+func TestLoadInvalidSnapshot(t *testing.T) {
+	x := newArticleIndex(t, articles...)
+	if err := x.Load(strings.NewReader("not a snapshot")); err == nil {
+		t.Fatal("expected the error of the invalid snapshot")
+	}
+	if x.Len() != len(articles) {
+		t.Errorf("Len = %d, want %d: the index is changed by the invalid snapshot", x.Len(), len(articles))
+	}
+}
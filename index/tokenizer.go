@@ -0,0 +1,38 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/core-go/search"
+)
+
+// Tokenizer splits the text into the terms of the index.
+type Tokenizer func(text string) []string
+
+// Tokenize normalizes the text by search.Normalize, which folds the unicode, the diacritics and the case, and splits it into the words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(search.Normalize(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// NewStopWordTokenizer wraps the tokenizer to drop the stop words, which are compared after the normalization.
+func NewStopWordTokenizer(tokenize Tokenizer, stopWords ...string) Tokenizer {
+	stops := make(map[string]bool)
+	for _, w := range stopWords {
+		for _, t := range tokenize(w) {
+			stops[t] = true
+		}
+	}
+	return func(text string) []string {
+		tokens := tokenize(text)
+		terms := make([]string, 0, len(tokens))
+		for _, t := range tokens {
+			if !stops[t] {
+				terms = append(terms, t)
+			}
+		}
+		return terms
+	}
+}
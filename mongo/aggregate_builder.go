@@ -0,0 +1,156 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AggregateBuilder[T any, F any] struct {
+	Collection *mongo.Collection
+	BuildQuery func(m F) (bson.D, bson.M)
+	GetSort    func(m interface{}) string
+	BuildSort  func(s string, modelType reflect.Type) bson.D
+	// Lookups are always joined, so the joined documents can be returned in the result
+	Lookups []Lookup
+	// FilterLookups are joined only if the query, the sort or the fields refer to them
+	FilterLookups []Lookup
+	Map           func(*T)
+	Collation     *options.Collation
+}
+
+func NewAggregateBuilderWithSort[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, buildSort func(string, reflect.Type) bson.D, lookups []Lookup, opts ...func(*T)) *AggregateBuilder[T, F] {
+	var mp func(*T)
+	if len(opts) > 0 && opts[0] != nil {
+		mp = opts[0]
+	}
+	var f F
+	filterLookups := GetLookups(reflect.TypeOf(f))
+	collection := db.Collection(collectionName)
+	return &AggregateBuilder[T, F]{Collection: collection, BuildQuery: buildQuery, GetSort: getSort, BuildSort: buildSort, Lookups: lookups, FilterLookups: filterLookups, Map: mp}
+}
+func NewAggregateBuilder[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, lookups []Lookup, opts ...func(*T)) *AggregateBuilder[T, F] {
+	return NewAggregateBuilderWithSort[T, F](db, collectionName, buildQuery, getSort, BuildSort, lookups, opts...)
+}
+
+func (b *AggregateBuilder[T, F]) Search(ctx context.Context, m F, limit int64, skip int64) ([]T, int64, error) {
+	var objs []T
+	query, fields := b.BuildQuery(m)
+	s := b.GetSort(m)
+	modelType := reflect.TypeOf(&objs).Elem().Elem()
+	sort := b.BuildSort(s, modelType)
+	if len(sort) == 0 && HasText(query) {
+		sort = BuildTextScoreSort()
+	}
+	lookups := make([]Lookup, 0)
+	for _, l := range b.Lookups {
+		lookups = AppendLookup(lookups, l)
+	}
+	for _, l := range UsedLookups(b.FilterLookups, query, sort, fields) {
+		lookups = AppendLookup(lookups, l)
+	}
+	pipeline := BuildPipeline(query, fields, sort, lookups, limit, skip)
+	total, err := BuildAggregateResult(ctx, b.Collection, &objs, pipeline, b.Collation)
+	if b.Map != nil {
+		l := len(objs)
+		for i := 0; i < l; i++ {
+			b.Map(&objs[i])
+		}
+	}
+	return objs, total, err
+}
+
+// BuildPipeline builds the stages: $match on the collection, $lookup and $unwind, $match on the joined collections, $sort,
+// then $facet with the paged list and the total, so that both are returned in the same round trip.
+func BuildPipeline(query bson.D, fields bson.M, sort bson.D, lookups []Lookup, limit int64, skip int64) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	base, joined := SplitQuery(query, lookups)
+	if len(base) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: base}})
+	}
+	pipeline = append(pipeline, BuildLookupStages(lookups)...)
+	if len(joined) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: joined}})
+	}
+	if len(sort) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+	list := bson.A{}
+	if skip > 0 {
+		list = append(list, bson.D{{Key: "$skip", Value: skip}})
+	}
+	if limit > 0 {
+		list = append(list, bson.D{{Key: "$limit", Value: limit}})
+	}
+	if len(fields) > 0 {
+		if isMetaOnly(fields) {
+			list = append(list, bson.D{{Key: "$addFields", Value: fields}})
+		} else {
+			list = append(list, bson.D{{Key: "$project", Value: fields}})
+		}
+	}
+	if len(list) == 0 {
+		list = append(list, bson.D{{Key: "$match", Value: bson.D{}}})
+	}
+	facet := bson.D{
+		{Key: "list", Value: list},
+		{Key: "total", Value: bson.A{bson.D{{Key: "$count", Value: "total"}}}},
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: facet}})
+	return pipeline
+}
+
+func BuildAggregateResult(ctx context.Context, collection *mongo.Collection, results interface{}, pipeline interface{}, opts ...*options.Collation) (int64, error) {
+	optionsAggregate := options.Aggregate()
+	if len(opts) > 0 && opts[0] != nil {
+		optionsAggregate.SetCollation(opts[0])
+	}
+	cursor, er0 := collection.Aggregate(ctx, pipeline, optionsAggregate)
+	if er0 != nil {
+		return 0, er0
+	}
+	defer cursor.Close(ctx)
+	if !cursor.Next(ctx) {
+		return 0, cursor.Err()
+	}
+	list, er1 := cursor.Current.LookupErr("list")
+	if er1 != nil {
+		return 0, er1
+	}
+	er2 := list.Unmarshal(results)
+	if er2 != nil {
+		return 0, er2
+	}
+	var total int64
+	if counts, ok := cursor.Current.Lookup("total").ArrayOK(); ok {
+		values, er3 := counts.Values()
+		if er3 != nil {
+			return 0, er3
+		}
+		if len(values) > 0 {
+			if doc, ok := values[0].DocumentOK(); ok {
+				if t, ok := doc.Lookup("total").AsInt64OK(); ok {
+					total = t
+				}
+			}
+		}
+	}
+	return total, nil
+}
+
+// isMetaOnly checks if the projection has only $meta fields, such as the text score. Such projection must not exclude the other fields.
+func isMetaOnly(fields bson.M) bool {
+	for _, v := range fields {
+		m, ok := v.(bson.M)
+		if !ok {
+			return false
+		}
+		if _, ok := m["$meta"]; !ok {
+			return false
+		}
+	}
+	return true
+}
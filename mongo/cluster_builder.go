@@ -0,0 +1,114 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/core-go/search/geo"
+)
+
+// ClusterBuilder counts the documents of the filter by the geohash prefix. The geohash must be stored in the documents, such as by geo.NewMapperWithGeohash,
+// with the precision which is not less than the precision of the grid.
+type ClusterBuilder[T any, F any] struct {
+	Collection *mongo.Collection
+	BuildQuery func(m F) (bson.D, bson.M)
+	// Location is the bson name of the GeoJSON point, which is used to calculate the centroid
+	Location string
+	// Geohash is the bson name of the geohash
+	Geohash string
+	// Order is the order of the coordinates of the location, geo.LatLng or geo.LngLat, as the geo.PointMapper which writes them. The default is geo.LatLng.
+	Order     string
+	Collation *options.Collation
+}
+
+// NewClusterBuilder creates the builder. If the location is not declared, it is the geo.JSON field of T.
+// The second option is the order of the coordinates, geo.LatLng or geo.LngLat.
+func NewClusterBuilder[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), geohash string, opts ...string) *ClusterBuilder[T, F] {
+	var location string
+	if len(opts) > 0 && len(opts[0]) > 0 {
+		location = opts[0]
+	} else {
+		var t T
+		modelType := reflect.TypeOf(t)
+		if modelType.Kind() == reflect.Ptr {
+			modelType = modelType.Elem()
+		}
+		if i := geo.FindGeoIndex(modelType); i >= 0 {
+			if tag, ok := modelType.Field(i).Tag.Lookup("bson"); ok {
+				location = strings.Split(tag, ",")[0]
+			}
+		}
+	}
+	order := geo.LatLng
+	if len(opts) > 1 && len(opts[1]) > 0 {
+		order = opts[1]
+	}
+	collection := db.Collection(collectionName)
+	return &ClusterBuilder[T, F]{Collection: collection, BuildQuery: buildQuery, Location: location, Geohash: geohash, Order: order}
+}
+
+func (b *ClusterBuilder[T, F]) Cluster(ctx context.Context, filter F, grid geo.Grid) ([]geo.Cell, error) {
+	if grid.Type != geo.GridGeohash {
+		return nil, errors.New("mongo supports the geohash grid only")
+	}
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	if len(b.Location) == 0 || len(b.Geohash) == 0 {
+		return nil, errors.New("location and geohash are required")
+	}
+	query, _ := b.BuildQuery(filter)
+	pipeline := BuildClusterPipeline(query, b.Location, b.Geohash, grid.Precision, b.Order)
+	optionsAggregate := options.Aggregate()
+	if b.Collation != nil {
+		optionsAggregate.SetCollation(b.Collation)
+	}
+	cursor, err := b.Collection.Aggregate(ctx, pipeline, optionsAggregate)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var cells []geo.Cell
+	for cursor.Next(ctx) {
+		var cell struct {
+			Key       string  `bson:"_id"`
+			Count     int64   `bson:"count"`
+			Latitude  float64 `bson:"latitude"`
+			Longitude float64 `bson:"longitude"`
+		}
+		if err = cursor.Decode(&cell); err != nil {
+			return nil, err
+		}
+		cells = append(cells, geo.Cell{Key: cell.Key, Count: cell.Count, Latitude: cell.Latitude, Longitude: cell.Longitude})
+	}
+	return cells, cursor.Err()
+}
+
+// BuildClusterPipeline groups the documents by the prefix of the geohash, with the count and the average of the coordinates.
+// The order is the order of the coordinates, geo.LatLng or geo.LngLat.
+func BuildClusterPipeline(query bson.D, location string, geohash string, precision int, order string) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if len(query) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: query}})
+	}
+	coordinates := "$" + location + ".coordinates"
+	latitude, longitude := 0, 1
+	if order == geo.LngLat {
+		latitude, longitude = 1, 0
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.D{{Key: geohash, Value: bson.D{{Key: "$type", Value: "string"}}}}}})
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: bson.D{{Key: "$substrCP", Value: bson.A{"$" + geohash, 0, precision}}}},
+		{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "longitude", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{coordinates, longitude}}}}}},
+		{Key: "latitude", Value: bson.D{{Key: "$avg", Value: bson.D{{Key: "$arrayElemAt", Value: bson.A{coordinates, latitude}}}}}},
+	}}})
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}})
+	return pipeline
+}
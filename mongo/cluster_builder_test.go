@@ -0,0 +1,71 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/core-go/search/geo"
+)
+
+func TestBuildClusterPipelineByOrder(t *testing.T) {
+	tests := []struct {
+		order     string
+		latitude  int
+		longitude int
+	}{
+		{geo.LatLng, 0, 1},
+		{geo.LngLat, 1, 0},
+		{"", 0, 1},
+	}
+	for _, tt := range tests {
+		pipeline := BuildClusterPipeline(nil, "location", "geohash", 5, tt.order)
+		if len(pipeline) != 3 {
+			t.Fatalf("order %q: stages = %d, want 3", tt.order, len(pipeline))
+		}
+		group := pipeline[1][0].Value.(bson.D)
+		for _, e := range group {
+			want := -1
+			if e.Key == "latitude" {
+				want = tt.latitude
+			} else if e.Key == "longitude" {
+				want = tt.longitude
+			} else {
+				continue
+			}
+			at := e.Value.(bson.D)[0].Value.(bson.D)[0].Value.(bson.A)
+			if at[0] != "$location.coordinates" || at[1] != want {
+				t.Errorf("order %q: %s = %v, want [$location.coordinates %d]", tt.order, e.Key, at, want)
+			}
+		}
+	}
+}
+
+func TestClusterPipelineOfMapper(t *testing.T) {
+	type place struct {
+		Id        string    `json:"id" bson:"_id"`
+		Latitude  float64   `json:"latitude" bson:"-"`
+		Longitude float64   `json:"longitude" bson:"-"`
+		Location  *geo.JSON `json:"-" bson:"location"`
+	}
+	for _, order := range []string{geo.LatLng, geo.LngLat} {
+		p := &place{Id: "1", Latitude: 10.5, Longitude: 106.7}
+		m := geo.NewMapperWithOrder(reflect.TypeOf(place{}), order)
+		if _, err := m.ModelToDb(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+		group := BuildClusterPipeline(nil, "location", "geohash", 5, order)[1][0].Value.(bson.D)
+		for _, e := range group {
+			if e.Key != "latitude" && e.Key != "longitude" {
+				continue
+			}
+			i := e.Value.(bson.D)[0].Value.(bson.D)[0].Value.(bson.A)[1].(int)
+			v := p.Location.Coordinates[i]
+			if e.Key == "latitude" && v != p.Latitude || e.Key == "longitude" && v != p.Longitude {
+				t.Errorf("order %q: %s is coordinates[%d] = %v", order, e.Key, i, v)
+			}
+		}
+	}
+}
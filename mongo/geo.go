@@ -0,0 +1,63 @@
+package mongo
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/core-go/search"
+	"github.com/core-go/search/mongo/query"
+)
+
+// BuildNearQuery converts the $geoWithin $centerSphere condition of the query to $nearSphere if the sort starts with the ascending distance,
+// because $nearSphere returns the nearest documents first. The other sort fields are removed, because they override the order of $nearSphere.
+// If there is no such condition, or the distance is not the first ascending sort field, the distance is only removed from the sort.
+// $nearSphere cannot be counted, so the count must use the original query.
+func BuildNearQuery(q bson.D, sort bson.D) (bson.D, bson.D) {
+	index := -1
+	var center bson.A
+	var radians float64
+	for i, e := range q {
+		if c, r, ok := getCenterSphere(e.Value); ok {
+			index, center, radians = i, c, r
+			break
+		}
+	}
+	if index < 0 {
+		return q, sort
+	}
+	others := bson.D{}
+	for _, e := range sort {
+		if e.Key != search.Distance {
+			others = append(others, e)
+		}
+	}
+	if len(sort) == 0 || sort[0].Key != search.Distance || sort[0].Value != 1 {
+		return q, others
+	}
+	near := bson.M{
+		"$geometry":    bson.M{"type": "Point", "coordinates": center},
+		"$maxDistance": radians * query.EarthRadius,
+	}
+	result := append(bson.D{}, q...)
+	result[index] = bson.E{Key: q[index].Key, Value: bson.M{"$nearSphere": near}}
+	return result, bson.D{}
+}
+func getCenterSphere(v interface{}) (bson.A, float64, bool) {
+	m, ok := v.(bson.M)
+	if !ok {
+		return nil, 0, false
+	}
+	within, ok := m["$geoWithin"].(bson.M)
+	if !ok {
+		return nil, 0, false
+	}
+	sphere, ok := within["$centerSphere"].(bson.A)
+	if !ok || len(sphere) != 2 {
+		return nil, 0, false
+	}
+	center, ok1 := sphere[0].(bson.A)
+	radians, ok2 := sphere[1].(float64)
+	if !ok1 || !ok2 {
+		return nil, 0, false
+	}
+	return center, radians, true
+}
@@ -0,0 +1,201 @@
+package mongo
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Lookup describes a $lookup join. The joined document is put into As, so the fields of the joined collection are queried as "As.field".
+type Lookup struct {
+	From         string `yaml:"from" mapstructure:"from" json:"from,omitempty" gorm:"column:from" bson:"from,omitempty" dynamodbav:"from,omitempty" firestore:"from,omitempty"`
+	LocalField   string `yaml:"local_field" mapstructure:"local_field" json:"localField,omitempty" gorm:"column:localfield" bson:"localField,omitempty" dynamodbav:"localField,omitempty" firestore:"localField,omitempty"`
+	ForeignField string `yaml:"foreign_field" mapstructure:"foreign_field" json:"foreignField,omitempty" gorm:"column:foreignfield" bson:"foreignField,omitempty" dynamodbav:"foreignField,omitempty" firestore:"foreignField,omitempty"`
+	As           string `yaml:"as" mapstructure:"as" json:"as,omitempty" gorm:"column:as" bson:"as,omitempty" dynamodbav:"as,omitempty" firestore:"as,omitempty"`
+	Array        bool   `yaml:"array" mapstructure:"array" json:"array,omitempty" gorm:"column:array" bson:"array,omitempty" dynamodbav:"array,omitempty" firestore:"array,omitempty"`
+}
+
+// GetLookups reads the lookup tags of the filter, such as `bson:"user.name" lookup:"from:users;local:userId;foreign:_id;as:user"`.
+// If "as" is not declared, it is the first part of the bson name of the field.
+func GetLookups(filterType reflect.Type) []Lookup {
+	if filterType.Kind() == reflect.Ptr {
+		filterType = filterType.Elem()
+	}
+	lookups := make([]Lookup, 0)
+	if filterType.Kind() != reflect.Struct {
+		return lookups
+	}
+	numField := filterType.NumField()
+	for i := 0; i < numField; i++ {
+		field := filterType.Field(i)
+		tag, ok := field.Tag.Lookup("lookup")
+		if !ok || len(tag) == 0 {
+			continue
+		}
+		lookup := Lookup{}
+		properties := strings.Split(tag, ";")
+		for _, property := range properties {
+			kv := strings.SplitN(property, ":", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			key := strings.TrimSpace(kv[0])
+			v := strings.TrimSpace(kv[1])
+			switch key {
+			case "from":
+				lookup.From = v
+			case "local", "localField":
+				lookup.LocalField = v
+			case "foreign", "foreignField":
+				lookup.ForeignField = v
+			case "as":
+				lookup.As = v
+			case "array":
+				lookup.Array = v == "true"
+			}
+		}
+		if len(lookup.As) == 0 {
+			if bsonTag, ok := field.Tag.Lookup("bson"); ok {
+				lookup.As = strings.Split(strings.Split(bsonTag, ",")[0], ".")[0]
+			}
+		}
+		if len(lookup.From) == 0 || len(lookup.LocalField) == 0 || len(lookup.As) == 0 {
+			continue
+		}
+		if len(lookup.ForeignField) == 0 {
+			lookup.ForeignField = "_id"
+		}
+		lookups = AppendLookup(lookups, lookup)
+	}
+	return lookups
+}
+
+// AppendLookup appends the lookup if there is no lookup with the same "as".
+func AppendLookup(lookups []Lookup, lookup Lookup) []Lookup {
+	for _, l := range lookups {
+		if l.As == lookup.As {
+			return lookups
+		}
+	}
+	return append(lookups, lookup)
+}
+
+// BuildLookupStages builds $lookup and $unwind stages. A lookup which is not an array is unwound, keeping the documents without joined document.
+func BuildLookupStages(lookups []Lookup) []bson.D {
+	stages := make([]bson.D, 0)
+	for _, l := range lookups {
+		stages = append(stages, bson.D{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: l.From},
+			{Key: "localField", Value: l.LocalField},
+			{Key: "foreignField", Value: l.ForeignField},
+			{Key: "as", Value: l.As},
+		}}})
+		if !l.Array {
+			stages = append(stages, bson.D{{Key: "$unwind", Value: bson.D{
+				{Key: "path", Value: "$" + l.As},
+				{Key: "preserveNullAndEmptyArrays", Value: true},
+			}}})
+		}
+	}
+	return stages
+}
+
+// SplitQuery splits the query into the conditions on the collection and the conditions on the joined collections.
+func SplitQuery(query bson.D, lookups []Lookup) (bson.D, bson.D) {
+	base := bson.D{}
+	joined := bson.D{}
+	for _, e := range query {
+		if refer(e, lookups) {
+			joined = append(joined, e)
+		} else {
+			base = append(base, e)
+		}
+	}
+	return base, joined
+}
+
+// UsedLookups returns the lookups which are referred by the query, the sort or the projection.
+func UsedLookups(lookups []Lookup, query bson.D, sort bson.D, fields bson.M) []Lookup {
+	used := make([]Lookup, 0)
+	for _, l := range lookups {
+		ls := []Lookup{l}
+		found := false
+		for _, e := range query {
+			if refer(e, ls) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			for _, e := range sort {
+				if refer(e, ls) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			for k := range fields {
+				if isJoinedKey(k, ls) {
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			used = append(used, l)
+		}
+	}
+	return used
+}
+
+func refer(e bson.E, lookups []Lookup) bool {
+	if isJoinedKey(e.Key, lookups) {
+		return true
+	}
+	return referValue(e.Value, lookups)
+}
+func referValue(v interface{}, lookups []Lookup) bool {
+	switch x := v.(type) {
+	case bson.D:
+		for _, e := range x {
+			if refer(e, lookups) {
+				return true
+			}
+		}
+	case bson.M:
+		for k, sv := range x {
+			if isJoinedKey(k, lookups) || referValue(sv, lookups) {
+				return true
+			}
+		}
+	case []bson.M:
+		for _, m := range x {
+			if referValue(m, lookups) {
+				return true
+			}
+		}
+	case []bson.D:
+		for _, d := range x {
+			if referValue(d, lookups) {
+				return true
+			}
+		}
+	case bson.A:
+		for _, sv := range x {
+			if referValue(sv, lookups) {
+				return true
+			}
+		}
+	}
+	return false
+}
+func isJoinedKey(key string, lookups []Lookup) bool {
+	for _, l := range lookups {
+		if key == l.As || strings.HasPrefix(key, l.As+".") {
+			return true
+		}
+	}
+	return false
+}
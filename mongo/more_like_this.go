@@ -0,0 +1,39 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/core-go/search"
+	"github.com/core-go/search/mongo/query"
+)
+
+// BuildScorePipeline builds the aggregation which adds the score to the documents of the query, sorts by the descending score, then pages as BuildPipeline.
+func BuildScorePipeline(q bson.D, fields bson.M, scoreName string, score bson.D, limit int64, skip int64) mongo.Pipeline {
+	pipeline := mongo.Pipeline{}
+	if len(q) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: q}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.D{{Key: scoreName, Value: score}}}})
+	return append(pipeline, BuildPipeline(bson.D{}, fields, bson.D{{Key: scoreName, Value: -1}}, nil, limit, skip)...)
+}
+
+// BuildMoreLikeThisResult ranks the documents by the weighted number of the matched fields of MoreLikeThis of the filter.
+// It returns false if the filter has no MoreLikeThis, so the documents are searched without ranking.
+func BuildMoreLikeThisResult(ctx context.Context, collection *mongo.Collection, results interface{}, filter interface{}, modelType reflect.Type, q bson.D, fields bson.M, limit int64, skip int64, opts ...*options.Collation) (int64, bool, error) {
+	m := search.GetMoreLikeThis(filter)
+	if m == nil || len(m.Fields) == 0 || len(m.Like) == 0 {
+		return 0, false, nil
+	}
+	score := query.BuildLikeScore(*m, modelType)
+	if len(score) == 0 {
+		return 0, false, nil
+	}
+	pipeline := BuildScorePipeline(q, fields, query.LikeScore, score, limit, skip)
+	total, err := BuildAggregateResult(ctx, collection, results, pipeline, opts...)
+	return total, true, err
+}
@@ -0,0 +1,247 @@
+package mongo
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NextSearchBuilder pages by the values of the sort fields of the last document, instead of skip.
+// "_id" is always added as the last sort field, so the order is unique even when the sort fields have the same values.
+type NextSearchBuilder[T any, F any] struct {
+	Collection *mongo.Collection
+	BuildQuery func(m F) (bson.D, bson.M)
+	GetSort    func(m interface{}) string
+	BuildSort  func(s string, modelType reflect.Type) bson.D
+	Map        func(*T)
+	Collation  *options.Collation
+}
+
+func NewNextSearchBuilderWithSort[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, buildSort func(string, reflect.Type) bson.D, opts ...func(*T)) *NextSearchBuilder[T, F] {
+	var mp func(*T)
+	if len(opts) > 0 && opts[0] != nil {
+		mp = opts[0]
+	}
+	collection := db.Collection(collectionName)
+	return &NextSearchBuilder[T, F]{Collection: collection, BuildQuery: buildQuery, GetSort: getSort, BuildSort: buildSort, Map: mp}
+}
+func NewNextSearchBuilder[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, opts ...func(*T)) *NextSearchBuilder[T, F] {
+	return NewNextSearchBuilderWithSort[T, F](db, collectionName, buildQuery, getSort, BuildSort, opts...)
+}
+
+func (b *NextSearchBuilder[T, F]) Search(ctx context.Context, m F, limit int64, next string) ([]T, string, error) {
+	var objs []T
+	query, fields := b.BuildQuery(m)
+	s := b.GetSort(m)
+	modelType := reflect.TypeOf(&objs).Elem().Elem()
+	sort, er0 := BuildSeekSort(b.BuildSort(s, modelType))
+	if er0 != nil {
+		return objs, "", er0
+	}
+	nextPageToken, err := BuildNextSearchResult(ctx, b.Collection, &objs, query, fields, sort, limit, next, b.Collation)
+	if b.Map != nil {
+		l := len(objs)
+		for i := 0; i < l; i++ {
+			b.Map(&objs[i])
+		}
+	}
+	return objs, nextPageToken, err
+}
+
+func BuildNextSearchResult(ctx context.Context, collection *mongo.Collection, results interface{}, query bson.D, fields bson.M, sort bson.D, limit int64, next string, opts ...*options.Collation) (string, error) {
+	if len(next) > 0 {
+		values, er0 := DecodeNextToken(next)
+		if er0 != nil {
+			return "", er0
+		}
+		if len(values) != len(sort) {
+			return "", errors.New("the next page token does not match the sort")
+		}
+		seek := BuildSeekQuery(sort, values)
+		if len(query) > 0 {
+			query = bson.D{{Key: "$and", Value: bson.A{query, seek}}}
+		} else {
+			query = seek
+		}
+	}
+	optionsFind := options.Find()
+	if fields != nil {
+		optionsFind.Projection = BuildSeekProjection(fields, sort)
+	}
+	if limit > 0 {
+		optionsFind.SetLimit(limit + 1)
+	}
+	optionsFind.SetSort(sort)
+	if len(opts) > 0 && opts[0] != nil {
+		optionsFind.SetCollation(opts[0])
+	}
+	cursor, er1 := collection.Find(ctx, query, optionsFind)
+	if er1 != nil {
+		return "", er1
+	}
+	defer cursor.Close(ctx)
+	arr := reflect.Indirect(reflect.ValueOf(results))
+	elemType := arr.Type().Elem()
+	var last bson.Raw
+	var i int64
+	for cursor.Next(ctx) {
+		if limit > 0 && i >= limit {
+			values := make(bson.A, 0)
+			for _, e := range sort {
+				v, er2 := last.LookupErr(strings.Split(e.Key, ".")...)
+				if er2 != nil || v.Type == bsontype.Null || v.Type == bsontype.Undefined {
+					values = append(values, nil)
+				} else {
+					values = append(values, v)
+				}
+			}
+			return EncodeNextToken(values)
+		}
+		obj := reflect.New(elemType)
+		if er3 := cursor.Decode(obj.Interface()); er3 != nil {
+			return "", er3
+		}
+		arr.Set(reflect.Append(arr, obj.Elem()))
+		last = append(bson.Raw{}, cursor.Current...)
+		i++
+	}
+	return "", cursor.Err()
+}
+
+// BuildSeekSort appends "_id" to the sort if it is not in the sort.
+// The direction of each field must be a number, so the sort cannot have the expressions such as {$meta: "textScore"}.
+func BuildSeekSort(sort bson.D) (bson.D, error) {
+	seek := bson.D{}
+	for _, e := range sort {
+		if _, ok := sortDirection(e.Value); !ok {
+			return nil, fmt.Errorf("cannot page by the next page token with the sort of '%s'", e.Key)
+		}
+		seek = append(seek, e)
+		if e.Key == "_id" {
+			return seek, nil
+		}
+	}
+	return append(seek, bson.E{Key: "_id", Value: 1}), nil
+}
+
+// BuildSeekProjection copies the projection, so the sort fields are returned to build the next page token.
+// For the inclusion projection, the sort fields are included; for the exclusion projection, the sort fields are not excluded.
+func BuildSeekProjection(fields bson.M, sort bson.D) bson.M {
+	projection := bson.M{}
+	for k, v := range fields {
+		projection[k] = v
+	}
+	if len(fields) == 0 || isMetaOnly(fields) {
+		return projection
+	}
+	exclusion := isExclusion(fields)
+	for _, e := range sort {
+		if exclusion {
+			delete(projection, e.Key)
+		} else {
+			projection[e.Key] = 1
+		}
+	}
+	return projection
+}
+
+// BuildSeekQuery builds the query of the documents after the values, with the ties of the previous sort fields:
+// (k1 > v1) or (k1 = v1 and k2 > v2) or ... For a descending field, "$lt" is used instead of "$gt".
+// As MongoDB sorts null and missing fields before the other values, a null value is after by {$ne: null} in the ascending order,
+// and there is nothing after it in the descending order; a value which is not null is followed by the nulls in the descending order.
+func BuildSeekQuery(sort bson.D, values bson.A) bson.D {
+	or := bson.A{}
+	for i, e := range sort {
+		c := bson.D{}
+		for j := 0; j < i; j++ {
+			c = append(c, bson.E{Key: sort[j].Key, Value: values[j]})
+		}
+		direction, _ := sortDirection(e.Value)
+		if values[i] == nil {
+			if direction < 0 {
+				continue
+			}
+			c = append(c, bson.E{Key: e.Key, Value: bson.D{{Key: "$ne", Value: nil}}})
+		} else if direction < 0 {
+			c = append(c, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: e.Key, Value: bson.D{{Key: "$lt", Value: values[i]}}}},
+				bson.D{{Key: e.Key, Value: nil}},
+			}})
+		} else {
+			c = append(c, bson.E{Key: e.Key, Value: bson.D{{Key: "$gt", Value: values[i]}}})
+		}
+		or = append(or, c)
+	}
+	if len(or) == 0 {
+		return bson.D{{Key: "_id", Value: bson.D{{Key: "$exists", Value: false}}}}
+	}
+	return bson.D{{Key: "$or", Value: or}}
+}
+func sortDirection(v interface{}) (int, bool) {
+	switch d := v.(type) {
+	case int:
+		return d, true
+	case int32:
+		return int(d), true
+	case int64:
+		return int(d), true
+	case float64:
+		return int(d), true
+	}
+	return 0, false
+}
+
+// isExclusion checks if the projection does not include any field, such as {password: 0} or {_id: 0}.
+func isExclusion(fields bson.M) bool {
+	for k, v := range fields {
+		if k == "_id" {
+			continue
+		}
+		switch x := v.(type) {
+		case bool:
+			if x {
+				return false
+			}
+		case int, int32, int64, float64:
+			if d, _ := sortDirection(x); d != 0 {
+				return false
+			}
+		case bson.M:
+			if _, ok := x["$meta"]; !ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func EncodeNextToken(values bson.A) (string, error) {
+	data, err := bson.Marshal(bson.D{{Key: "v", Value: values}})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+func DecodeNextToken(next string) (bson.A, error) {
+	data, err := base64.RawURLEncoding.DecodeString(next)
+	if err != nil {
+		return nil, errors.New("invalid next page token")
+	}
+	var token struct {
+		V bson.A `bson:"v"`
+	}
+	if err = bson.Unmarshal(data, &token); err != nil {
+		return nil, errors.New("invalid next page token")
+	}
+	return token.V, nil
+}
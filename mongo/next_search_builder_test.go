@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNextToken(t *testing.T) {
+	id := primitive.NewObjectID()
+	date := primitive.NewDateTimeFromTime(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))
+	doc, err := bson.Marshal(bson.D{{Key: "name", Value: "tom"}, {Key: "age", Value: int32(20)}, {Key: "date", Value: date}, {Key: "_id", Value: id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := bson.Raw(doc)
+	values := bson.A{raw.Lookup("name"), raw.Lookup("age"), nil, raw.Lookup("date"), raw.Lookup("_id")}
+	next, err := EncodeNextToken(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeNextToken(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bson.A{"tom", int32(20), nil, date, id}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeNextToken(EncodeNextToken(%v)) = %v, want %v", values, got, want)
+	}
+}
+
+func TestDecodeInvalidNextToken(t *testing.T) {
+	for _, next := range []string{"not a token!", "YWJj", "dGhlIHRva2Vu"} {
+		if _, err := DecodeNextToken(next); err == nil {
+			t.Errorf("DecodeNextToken(%q) error = nil, want an error", next)
+		}
+	}
+}
+
+func TestBuildSeekSort(t *testing.T) {
+	tests := []struct {
+		sort bson.D
+		want bson.D
+	}{
+		{bson.D{}, bson.D{{Key: "_id", Value: 1}}},
+		{bson.D{{Key: "name", Value: 1}}, bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{bson.D{{Key: "_id", Value: -1}, {Key: "name", Value: 1}}, bson.D{{Key: "_id", Value: -1}}},
+		{bson.D{{Key: "age", Value: int32(-1)}, {Key: "_id", Value: 1}}, bson.D{{Key: "age", Value: int32(-1)}, {Key: "_id", Value: 1}}},
+	}
+	for _, tt := range tests {
+		got, err := BuildSeekSort(tt.sort)
+		if err != nil {
+			t.Errorf("BuildSeekSort(%v) error: %v", tt.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BuildSeekSort(%v) = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+	if _, err := BuildSeekSort(bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}}); err == nil {
+		t.Errorf("BuildSeekSort of the text score error = nil, want an error")
+	}
+}
+
+func TestBuildSeekQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		sort   bson.D
+		values bson.A
+		want   string
+	}{
+		{
+			"asc",
+			bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			bson.A{"tom", 5},
+			`{"$or":[{"name":{"$gt":"tom"}},{"name":"tom","_id":{"$gt":5}}]}`,
+		},
+		{
+			"mixed asc and desc",
+			bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			bson.A{20, "tom", 5},
+			`{"$or":[{"$or":[{"age":{"$lt":20}},{"age":null}]},{"age":20,"name":{"$gt":"tom"}},{"age":20,"name":"tom","_id":{"$gt":5}}]}`,
+		},
+		{
+			"desc id",
+			bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: -1}},
+			bson.A{"tom", 5},
+			`{"$or":[{"name":{"$gt":"tom"}},{"name":"tom","$or":[{"_id":{"$lt":5}},{"_id":null}]}]}`,
+		},
+		{
+			"null asc",
+			bson.D{{Key: "age", Value: 1}, {Key: "_id", Value: 1}},
+			bson.A{nil, 5},
+			`{"$or":[{"age":{"$ne":null}},{"age":null,"_id":{"$gt":5}}]}`,
+		},
+		{
+			"null desc",
+			bson.D{{Key: "age", Value: -1}, {Key: "_id", Value: 1}},
+			bson.A{nil, 5},
+			`{"$or":[{"age":null,"_id":{"$gt":5}}]}`,
+		},
+		{
+			"mixed with nulls",
+			bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}},
+			bson.A{nil, nil, 5},
+			`{"$or":[{"age":null,"name":{"$ne":null}},{"age":null,"name":null,"_id":{"$gt":5}}]}`,
+		},
+		{
+			"nothing after",
+			bson.D{{Key: "_id", Value: -1}},
+			bson.A{nil},
+			`{"_id":{"$exists":false}}`,
+		},
+	}
+	for _, tt := range tests {
+		data, err := bson.MarshalExtJSON(BuildSeekQuery(tt.sort, tt.values), false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s: BuildSeekQuery = %s, want %s", tt.name, data, tt.want)
+		}
+	}
+}
+
+func TestBuildSeekProjection(t *testing.T) {
+	sort := bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}
+	tests := []struct {
+		fields bson.M
+		want   bson.M
+	}{
+		{bson.M{}, bson.M{}},
+		{bson.M{"age": 1}, bson.M{"age": 1, "name": 1, "_id": 1}},
+		{bson.M{"password": 0, "name": 0}, bson.M{"password": 0}},
+		{bson.M{"score": bson.M{"$meta": "textScore"}}, bson.M{"score": bson.M{"$meta": "textScore"}}},
+	}
+	for _, tt := range tests {
+		if got := BuildSeekProjection(tt.fields, sort); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BuildSeekProjection(%v) = %v, want %v", tt.fields, got, tt.want)
+		}
+	}
+}
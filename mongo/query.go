@@ -8,9 +8,18 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/core-go/search/mongo/query"
 )
 
+// TextScore is the field of the text score, which is projected by the query builder.
+const TextScore = query.TextScore
+
 func BuildSearchResult(ctx context.Context, collection *mongo.Collection, results interface{}, query bson.D, fields bson.M, sort bson.D, limit int64, skip int64) (int64, error) {
+	return BuildSearchResultWithCollation(ctx, collection, results, query, fields, sort, limit, skip, nil)
+}
+func BuildSearchResultWithCollation(ctx context.Context, collection *mongo.Collection, results interface{}, query bson.D, fields bson.M, sort bson.D, limit int64, skip int64, collation *options.Collation) (int64, error) {
+	findQuery, sort := BuildNearQuery(query, sort)
 	optionsFind := options.Find()
 	if fields != nil {
 		optionsFind.Projection = fields
@@ -24,8 +33,11 @@ func BuildSearchResult(ctx context.Context, collection *mongo.Collection, result
 	if sort != nil {
 		optionsFind.SetSort(sort)
 	}
+	if collation != nil {
+		optionsFind.SetCollation(collation)
+	}
 
-	cursor, er0 := collection.Find(ctx, query, optionsFind)
+	cursor, er0 := collection.Find(ctx, findQuery, optionsFind)
 	if er0 != nil {
 		return 0, er0
 	}
@@ -34,8 +46,29 @@ func BuildSearchResult(ctx context.Context, collection *mongo.Collection, result
 	if er1 != nil {
 		return 0, er1
 	}
-	options := options.Count()
-	return collection.CountDocuments(ctx, query, options)
+	optionsCount := options.Count()
+	if collation != nil {
+		optionsCount.SetCollation(collation)
+	}
+	return collection.CountDocuments(ctx, query, optionsCount)
+}
+
+// NewCollation creates the collation for a locale. Strength 1 ignores case and diacritics, strength 2 ignores case only.
+func NewCollation(locale string, strength int) *options.Collation {
+	return &options.Collation{Locale: locale, Strength: strength}
+}
+
+// HasText checks if the query is a $text query, which can be sorted by the text score.
+func HasText(query bson.D) bool {
+	for _, e := range query {
+		if e.Key == "$text" {
+			return true
+		}
+	}
+	return false
+}
+func BuildTextScoreSort() bson.D {
+	return bson.D{{Key: TextScore, Value: bson.M{"$meta": "textScore"}}}
 }
 
 func BuildSort(s string, modelType reflect.Type) bson.D {
@@ -0,0 +1,60 @@
+package query
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/core-go/search"
+	"github.com/core-go/search/geo"
+)
+
+// EarthRadius is the equatorial radius of the earth in meters, used by MongoDB to convert the distances to radians.
+const EarthRadius = 6378100.0
+
+// BuildGeoDistance builds the condition of the locations within the radius. $geoWithin is used instead of $nearSphere, so the query can be counted.
+func BuildGeoDistance(d search.GeoDistance) bson.M {
+	center := bson.A{d.Longitude, d.Latitude}
+	return bson.M{"$geoWithin": bson.M{"$centerSphere": bson.A{center, d.Radius / EarthRadius}}}
+}
+
+// BuildGeoBoundingBox builds the condition of the locations in the box, as a GeoJSON polygon.
+func BuildGeoBoundingBox(b search.GeoBoundingBox) bson.M {
+	ring := bson.A{
+		bson.A{b.Left, b.Bottom},
+		bson.A{b.Right, b.Bottom},
+		bson.A{b.Right, b.Top},
+		bson.A{b.Left, b.Top},
+		bson.A{b.Left, b.Bottom},
+	}
+	polygon := bson.M{"type": "Polygon", "coordinates": bson.A{ring}}
+	return bson.M{"$geoWithin": bson.M{"$geometry": polygon}}
+}
+
+// BuildGeoShape builds the condition of the operator, "$geoWithin" or "$geoIntersects", with the GeoJSON geometry.
+func BuildGeoShape(operator string, g geo.Geometry) bson.M {
+	return bson.M{operator: bson.M{"$geometry": g}}
+}
+
+// getGeoBsonName gets the bson name of the geo.JSON field of the model, which has the 2dsphere index.
+func getGeoBsonName(modelType reflect.Type) string {
+	if modelType == nil || modelType.Kind() != reflect.Struct {
+		return ""
+	}
+	i := geo.FindGeoIndex(modelType)
+	if i < 0 {
+		return ""
+	}
+	return getBson(modelType, i)
+}
+
+// getGeoName gets the bson name of the geo filter: the bson tag of the filter field, or the geo.JSON field of the model.
+func getGeoName(filterType reflect.Type, i int, bsonName string, modelType reflect.Type) string {
+	if len(getBson(filterType, i)) > 0 {
+		return bsonName
+	}
+	if geoName := getGeoBsonName(modelType); len(geoName) > 0 {
+		return geoName
+	}
+	return bsonName
+}
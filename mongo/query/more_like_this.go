@@ -0,0 +1,70 @@
+package query
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/core-go/search"
+)
+
+// LikeScore is the field of the weighted number of the matched fields of MoreLikeThis, which is added by the aggregation to sort the results.
+const LikeScore = "_likeScore"
+
+// BuildMoreLikeThis builds the $expr condition of MoreLikeThis: the number of the matched fields is at least MinMatch, which is 1 by default.
+func BuildMoreLikeThis(m search.MoreLikeThis, modelType reflect.Type) bson.D {
+	counts := buildLikeExpressions(m, modelType, false)
+	if len(counts) == 0 {
+		return nil
+	}
+	minMatch := m.MinMatch
+	if minMatch < 1 {
+		minMatch = 1
+	}
+	return bson.D{{Key: "$gte", Value: bson.A{bson.D{{Key: "$add", Value: counts}}, minMatch}}}
+}
+
+// BuildLikeScore builds the expression of the weighted number of the matched fields: a value matches if it is equal, and a slice by each of the common items.
+func BuildLikeScore(m search.MoreLikeThis, modelType reflect.Type) bson.D {
+	scores := buildLikeExpressions(m, modelType, true)
+	if len(scores) == 0 {
+		return nil
+	}
+	return bson.D{{Key: "$add", Value: scores}}
+}
+
+func buildLikeExpressions(m search.MoreLikeThis, modelType reflect.Type, weighted bool) bson.A {
+	expressions := bson.A{}
+	for _, field := range m.Fields {
+		name, weight := search.ParseField(field)
+		like, ok := m.Like[name]
+		if !ok || like == nil {
+			continue
+		}
+		i, _, bsonName := getFieldByJson(modelType, name)
+		if i < 0 {
+			continue
+		}
+		if len(bsonName) == 0 {
+			bsonName = name
+		}
+		path := "$" + bsonName
+		var matched interface{}
+		if reflect.ValueOf(like).Kind() == reflect.Slice {
+			items := bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$isArray", Value: path}}, path, bson.A{path}}}}
+			size := bson.D{{Key: "$size", Value: bson.D{{Key: "$setIntersection", Value: bson.A{items, like}}}}}
+			if weighted {
+				matched = size
+			} else {
+				matched = bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{size, 0}}}, 1, 0}}}
+			}
+		} else {
+			matched = bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$eq", Value: bson.A{path, like}}}, 1, 0}}}
+		}
+		if weighted && weight != 1 {
+			matched = bson.D{{Key: "$multiply", Value: bson.A{matched, weight}}}
+		}
+		expressions = append(expressions, matched)
+	}
+	return expressions
+}
@@ -3,12 +3,19 @@ package query
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/core-go/search"
+	"github.com/core-go/search/geo"
+)
+
+const (
+	Text      = "text"
+	TextScore = "score"
 )
 
 var Operators = map[string]string{
@@ -43,21 +50,49 @@ func (b *Builder[F]) BuildQuery(filter F) (bson.D, bson.M) {
 	return Build(filter, b.ModelType)
 }
 
+// UseQueryWithError is UseQuery, which returns the error of the invalid filter, such as the invalid shape of GeoWithin.
+func UseQueryWithError[T any, F any]() func(filter F) (bson.D, bson.M, error) {
+	var t T
+	resultModelType := reflect.TypeOf(t)
+	if resultModelType.Kind() == reflect.Ptr {
+		resultModelType = resultModelType.Elem()
+	}
+	b := NewBuilder[F](resultModelType)
+	return b.BuildQueryWithError
+}
+func (b *Builder[F]) BuildQueryWithError(filter F) (bson.D, bson.M, error) {
+	return BuildWithError(filter, b.ModelType)
+}
+
+// Build builds the query as BuildWithError. If the filter is invalid, it builds the query which matches no documents; use BuildWithError to get the error.
 func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
+	query, fields, err := BuildWithError(filter, resultModelType)
+	if err != nil {
+		return bson.D{{Key: "$expr", Value: false}}, bson.M{}
+	}
+	return query, fields
+}
+
+// BuildWithError builds the query and the projection of the filter, and returns the error of the invalid filter, such as the invalid shape of GeoWithin or GeoIntersects.
+func BuildWithError(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M, error) {
 	var query = bson.D{}
 	queryQ := make([]bson.M, 0)
+	qNames := make([]string, 0)
+	qTags := make([]string, 0)
 	hasQ := false
+	hasText := false
 	var fields = bson.M{}
 	var excluding []string
 
 	if _, ok := filter.(*search.Filter); ok {
-		return query, fields
+		return query, fields, nil
 	}
 
 	value := reflect.Indirect(reflect.ValueOf(filter))
 	filterType := value.Type()
 	numField := value.NumField()
 	var keyword string
+	var terms [][]string
 	for i := 0; i < numField; i++ {
 		bsonName := getBson(filterType, i)
 		if bsonName == "-" {
@@ -98,18 +133,23 @@ func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
 		}
 		if isContinue {
 			if len(keyword) > 0 {
-				qMatch, isQ := tf.Tag.Lookup("q")
+				qTag, isQ := tf.Tag.Lookup("q")
 				if isQ {
+					qMatch, options := GetMatch(qTag)
+					if qMatch == Text {
+						hasText = true
+						continue
+					}
 					hasQ = true
 					queryQ1 := bson.M{}
-					if qMatch == "=" {
-						queryQ1[bsonName] = keyword
-					} else if qMatch == "like" {
-						queryQ1[bsonName] = primitive.Regex{Pattern: fmt.Sprintf("\\w*%v\\w*", keyword)}
+					if isNormalized(qTag) {
+						queryQ1[bsonName] = BuildUnaccentMatch(qMatch, keyword)
 					} else {
-						queryQ1[bsonName] = primitive.Regex{Pattern: fmt.Sprintf("^%v", keyword)}
+						queryQ1[bsonName] = BuildMatch(qMatch, keyword, options)
 					}
 					queryQ = append(queryQ, queryQ1)
+					qNames = append(qNames, bsonName)
+					qTags = append(qTags, qTag)
 				}
 			}
 			continue
@@ -130,6 +170,7 @@ func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
 			}
 			if len(v.Q) > 0 {
 				keyword = strings.TrimSpace(v.Q)
+				terms = v.Terms
 			}
 			continue
 		} else if len(psv) > 0 {
@@ -137,12 +178,11 @@ func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
 			if !ok {
 				key, _ = tf.Tag.Lookup("q")
 			}
-			if key == "=" {
-				query = append(query, bson.E{Key: bsonName, Value: psv})
-			} else if key == "like" {
-				query = append(query, bson.E{Key: bsonName, Value: primitive.Regex{Pattern: fmt.Sprintf("\\w*%v\\w*", psv)}})
+			match, options := GetMatch(key)
+			if isNormalized(key) {
+				query = append(query, bson.E{Key: bsonName, Value: BuildUnaccentMatch(match, psv)})
 			} else {
-				query = append(query, bson.E{Key: bsonName, Value: primitive.Regex{Pattern: fmt.Sprintf("^%v", psv)}})
+				query = append(query, bson.E{Key: bsonName, Value: BuildMatch(match, psv, options)})
 			}
 		} else if rangeTime, ok := x.(search.TimeRange); ok {
 			timeQuery := bson.M{}
@@ -230,6 +270,42 @@ func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
 				dateQuery["$gte"] = rangeDate.Min
 			}
 			query = append(query, bson.E{Key: bsonName, Value: dateQuery})
+		} else if geoDistance, ok := x.(search.GeoDistance); ok {
+			if geoDistance.Radius > 0 {
+				if geoName := getGeoName(filterType, i, bsonName, resultModelType); len(geoName) > 0 {
+					query = append(query, bson.E{Key: geoName, Value: BuildGeoDistance(geoDistance)})
+				}
+			}
+		} else if box, ok := x.(search.GeoBoundingBox); ok {
+			if !box.IsEmpty() {
+				if geoName := getGeoName(filterType, i, bsonName, resultModelType); len(geoName) > 0 {
+					query = append(query, bson.E{Key: geoName, Value: BuildGeoBoundingBox(box)})
+				}
+			}
+		} else if within, ok := x.(search.GeoWithin); ok {
+			if len(within.Type) > 0 {
+				if err := geo.Geometry(within).Validate(); err != nil {
+					return nil, nil, err
+				}
+				if geoName := getGeoName(filterType, i, bsonName, resultModelType); len(geoName) > 0 {
+					query = append(query, bson.E{Key: geoName, Value: BuildGeoShape("$geoWithin", geo.Geometry(within))})
+				}
+			}
+		} else if intersects, ok := x.(search.GeoIntersects); ok {
+			if len(intersects.Type) > 0 {
+				if err := geo.Geometry(intersects).Validate(); err != nil {
+					return nil, nil, err
+				}
+				if geoName := getGeoName(filterType, i, bsonName, resultModelType); len(geoName) > 0 {
+					query = append(query, bson.E{Key: geoName, Value: BuildGeoShape("$geoIntersects", geo.Geometry(intersects))})
+				}
+			}
+		} else if mlt, ok := x.(search.MoreLikeThis); ok {
+			if len(mlt.Fields) > 0 && len(mlt.Like) > 0 {
+				if expr := BuildMoreLikeThis(mlt, resultModelType); len(expr) > 0 {
+					query = append(query, bson.E{Key: "$expr", Value: expr})
+				}
+			}
 		} else if kind == reflect.Slice {
 			if field.Len() > 0 {
 				arrQuery := bson.M{}
@@ -255,14 +331,99 @@ func Build(filter interface{}, resultModelType reflect.Type) (bson.D, bson.M) {
 		}
 	}
 	if hasQ {
-		query = append(query, bson.E{Key: "$or", Value: queryQ})
+		if len(terms) > 0 {
+			query = append(query, bson.E{Key: "$and", Value: BuildTerms(qNames, qTags, terms)})
+		} else {
+			query = append(query, bson.E{Key: "$or", Value: queryQ})
+		}
+	}
+	if hasText {
+		query = append(query, bson.E{Key: "$text", Value: bson.M{"$search": keyword}})
+		fields[TextScore] = bson.M{"$meta": "textScore"}
 	}
 	if excluding != nil && len(excluding) > 0 {
 		exQuery := bson.M{}
 		exQuery["$nin"] = excluding
 		query = append(query, bson.E{Key: "_id", Value: exQuery})
 	}
-	return query, fields
+	return query, fields, nil
+}
+
+// GetMatch splits a q/operator tag such as "like,i" into the match type and the regex options.
+func GetMatch(tag string) (string, string) {
+	tags := strings.Split(tag, ",")
+	options := ""
+	for i := 1; i < len(tags); i++ {
+		option := strings.TrimSpace(tags[i])
+		if isRegexOptions(option) {
+			options = options + option
+		}
+	}
+	return strings.TrimSpace(tags[0]), options
+}
+func isRegexOptions(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if c != 'i' && c != 'm' && c != 'x' && c != 's' {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildMatch builds the condition of a string field. The keyword is escaped, so it is always matched literally.
+func BuildMatch(match string, keyword string, options string) interface{} {
+	pattern := regexp.QuoteMeta(keyword)
+	if match == "=" {
+		if len(options) == 0 {
+			return keyword
+		}
+		return primitive.Regex{Pattern: fmt.Sprintf("^%s$", pattern), Options: options}
+	} else if match == "like" {
+		return primitive.Regex{Pattern: pattern, Options: options}
+	}
+	return primitive.Regex{Pattern: fmt.Sprintf("^%s", pattern), Options: options}
+}
+
+// BuildUnaccentMatch builds the case and accent insensitive regex of the keyword, for the tags with the unaccent or fuzzy option.
+// MongoDB has no fuzzy match without Atlas Search, so fuzzy is accent insensitive only. The collation with strength 1 is not used, because it does not apply to $regex;
+// use NewCollation(locale, 1) of the search builder for the equality and the sort.
+func BuildUnaccentMatch(match string, keyword string) primitive.Regex {
+	pattern := search.AccentPattern(keyword)
+	if match == "=" {
+		return primitive.Regex{Pattern: fmt.Sprintf("^%s$", pattern), Options: "i"}
+	} else if match == "like" {
+		return primitive.Regex{Pattern: pattern, Options: "i"}
+	}
+	return primitive.Regex{Pattern: fmt.Sprintf("^%s", pattern), Options: "i"}
+}
+
+// BuildTerms builds the conditions of the terms of the expanded keyword: each group is an $or of the q fields and its terms, such as
+// [{$or: [{name: /tv/}, {name: /television/}]}, {$or: [{name: /show/}]}] for [["tv", "television"], ["show"]].
+func BuildTerms(names []string, tags []string, terms [][]string) []bson.M {
+	groups := make([]bson.M, 0, len(terms))
+	for _, group := range terms {
+		or := make([]bson.M, 0)
+		for _, term := range group {
+			for i, name := range names {
+				match, options := GetMatch(tags[i])
+				if isNormalized(tags[i]) {
+					or = append(or, bson.M{name: BuildUnaccentMatch(match, term)})
+				} else {
+					or = append(or, bson.M{name: BuildMatch(match, term, options)})
+				}
+			}
+		}
+		groups = append(groups, bson.M{"$or": or})
+	}
+	return groups
+}
+func isNormalized(tag string) bool {
+	_, unaccent := search.GetOption(tag, search.Unaccent)
+	_, fuzzy := search.GetOption(tag, search.Fuzzy)
+	return unaccent || fuzzy
 }
 
 func getFieldByJson(modelType reflect.Type, jsonName string) (int, string, string) {
@@ -6,6 +6,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SearchBuilder[T any, F any] struct {
@@ -14,6 +15,9 @@ type SearchBuilder[T any, F any] struct {
 	GetSort    func(m interface{}) string
 	BuildSort  func(s string, modelType reflect.Type) bson.D
 	Map        func(*T)
+	Collation  *options.Collation
+	// BuildQueryWithError builds the query, and returns the error of the invalid filter, which is returned by Search. If it is set, BuildQuery is not used.
+	BuildQueryWithError func(m F) (bson.D, bson.M, error)
 }
 
 func NewSearchQueryWithSort[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, buildSort func(string, reflect.Type) bson.D, options ...func(*T)) *SearchBuilder[T, F] {
@@ -31,22 +35,55 @@ func NewSearchBuilderWithSort[T any, F any](db *mongo.Database, collectionName s
 	builder := &SearchBuilder[T, F]{Collection: collection, BuildQuery: buildQuery, GetSort: getSort, BuildSort: buildSort, Map: mp}
 	return builder
 }
+func NewSearchBuilderWithCollation[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, collation *options.Collation, opts ...func(*T)) *SearchBuilder[T, F] {
+	builder := NewSearchBuilderWithSort[T, F](db, collectionName, buildQuery, getSort, BuildSort, opts...)
+	builder.Collation = collation
+	return builder
+}
 func NewSearchBuilder[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M), getSort func(interface{}) string, options ...func(*T)) *SearchBuilder[T, F] {
 	return NewSearchBuilderWithSort[T, F](db, collectionName, buildQuery, getSort, BuildSort, options...)
 }
 
+// NewSearchBuilderWithError creates the search builder by buildQuery, which returns the error of the invalid filter, such as query.UseQueryWithError.
+func NewSearchBuilderWithError[T any, F any](db *mongo.Database, collectionName string, buildQuery func(F) (bson.D, bson.M, error), getSort func(interface{}) string, options ...func(*T)) *SearchBuilder[T, F] {
+	builder := NewSearchBuilderWithSort[T, F](db, collectionName, nil, getSort, BuildSort, options...)
+	builder.BuildQueryWithError = buildQuery
+	return builder
+}
+
 func (b *SearchBuilder[T, F]) Search(ctx context.Context, m F, limit int64, skip int64) ([]T, int64, error) {
 	var objs []T
-	query, fields := b.BuildQuery(m)
+	var query bson.D
+	var fields bson.M
+	if b.BuildQueryWithError != nil {
+		var er0 error
+		query, fields, er0 = b.BuildQueryWithError(m)
+		if er0 != nil {
+			return objs, -1, er0
+		}
+	} else {
+		query, fields = b.BuildQuery(m)
+	}
 
 	var sort = bson.D{}
 	s := b.GetSort(m)
 	modelType := reflect.TypeOf(&objs).Elem().Elem()
 	sort = b.BuildSort(s, modelType)
+	if len(sort) == 0 && HasText(query) {
+		sort = BuildTextScoreSort()
+	}
 	if skip < 0 {
 		skip = 0
 	}
-	total, err := BuildSearchResult(ctx, b.Collection, &objs, query, fields, sort, limit, skip)
+	var total int64
+	var err error
+	ranked := false
+	if len(s) == 0 {
+		total, ranked, err = BuildMoreLikeThisResult(ctx, b.Collection, &objs, m, modelType, query, fields, limit, skip, b.Collation)
+	}
+	if !ranked {
+		total, err = BuildSearchResultWithCollation(ctx, b.Collection, &objs, query, fields, sort, limit, skip, b.Collation)
+	}
 	if b.Map != nil {
 		l := len(objs)
 		for i := 0; i < l; i++ {
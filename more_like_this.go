@@ -0,0 +1,229 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MoreLikeThis filters the records which are similar to the record of Id, or to the values of Like, on the fields. The fields are the json names,
+// which can be weighted, such as "category^2". The records which match less than MinMatch fields are excluded, and the default MinMatch is 1.
+// The results are ranked by the weighted number of the matched fields, and the source record is excluded by Filter.Excluding.
+type MoreLikeThis struct {
+	Id       string                 `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
+	Fields   []string               `yaml:"fields" mapstructure:"fields" json:"fields,omitempty" gorm:"column:fields" bson:"fields,omitempty" dynamodbav:"fields,omitempty" firestore:"fields,omitempty"`
+	MinMatch int                    `yaml:"min_match" mapstructure:"min_match" json:"minMatch,omitempty" gorm:"column:minmatch" bson:"minMatch,omitempty" dynamodbav:"minMatch,omitempty" firestore:"minMatch,omitempty"`
+	Like     map[string]interface{} `yaml:"like" mapstructure:"like" json:"like,omitempty" gorm:"column:like" bson:"like,omitempty" dynamodbav:"like,omitempty" firestore:"like,omitempty"`
+}
+
+func (m MoreLikeThis) IsEmpty() bool {
+	return len(m.Fields) == 0 || (len(m.Id) == 0 && len(m.Like) == 0)
+}
+
+// ParseField splits the weighted field, such as "category^2", into the name and the weight. The default weight is 1.
+func ParseField(field string) (string, float64) {
+	field = strings.TrimSpace(field)
+	if i := strings.LastIndex(field, "^"); i > 0 {
+		if w, err := strconv.ParseFloat(field[i+1:], 64); err == nil && w > 0 {
+			return field[:i], w
+		}
+	}
+	return field, 1
+}
+
+// GetLike gets the values of the fields of the model by the json names. The empty values are skipped, because they are not similar to anything.
+func GetLike(model interface{}, fields []string) map[string]interface{} {
+	like := make(map[string]interface{})
+	value := reflect.Indirect(reflect.ValueOf(model))
+	if value.Kind() != reflect.Struct {
+		return like
+	}
+	for _, field := range fields {
+		name, _ := ParseField(field)
+		i := FindFieldByJson(value.Type(), name)
+		if i < 0 {
+			continue
+		}
+		v := reflect.Indirect(value.Field(i))
+		if !v.IsValid() || v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			continue
+		}
+		like[name] = v.Interface()
+	}
+	return like
+}
+
+// GetMoreLikeThis gets the MoreLikeThis of the filter, which is a field of the type MoreLikeThis or *MoreLikeThis.
+func GetMoreLikeThis(filter interface{}) *MoreLikeThis {
+	value := reflect.Indirect(reflect.ValueOf(filter))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+	numField := value.NumField()
+	for i := 0; i < numField; i++ {
+		field := value.Field(i)
+		if m, ok := field.Interface().(*MoreLikeThis); ok {
+			return m
+		}
+		if field.CanAddr() {
+			if m, ok := field.Addr().Interface().(*MoreLikeThis); ok {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// UseMoreLikeThis wraps the search function: if MoreLikeThis has Id without Like, the record is loaded to get Like, and Id is added to Filter.Excluding.
+// The filter can be a struct or a pointer to a struct. Load can be nil for the stores which find the record by Id, such as Elasticsearch.
+// If the record of Id cannot be loaded, the error is returned.
+func UseMoreLikeThis[T any, F any](search func(context.Context, F, int64, int64) ([]T, int64, error), load func(context.Context, string) (*T, error)) func(context.Context, F, int64, int64) ([]T, int64, error) {
+	return func(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
+		target := addressOf(&filter)
+		m := GetMoreLikeThis(target)
+		if m != nil && len(m.Id) > 0 {
+			if len(m.Like) == 0 && load != nil {
+				model, err := load(ctx, m.Id)
+				if err != nil {
+					return nil, 0, err
+				}
+				if model == nil {
+					return nil, 0, fmt.Errorf("cannot find the record '%s' of MoreLikeThis", m.Id)
+				}
+				m.Like = GetLike(model, m.Fields)
+			}
+			if f := GetFilter(target); f != nil {
+				f.Excluding = append(f.Excluding, m.Id)
+			}
+		}
+		return search(ctx, filter, limit, offset)
+	}
+}
+
+// LikeScore computes the weighted number of the fields of the model which match Like, and the number of the matched fields.
+// A value matches if it is equal, and a slice matches by each of the common items.
+func LikeScore(model interface{}, m MoreLikeThis) (float64, int) {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	if value.Kind() != reflect.Struct {
+		return 0, 0
+	}
+	var score float64
+	count := 0
+	for _, field := range m.Fields {
+		name, weight := ParseField(field)
+		like, ok := m.Like[name]
+		if !ok {
+			continue
+		}
+		i := FindFieldByJson(value.Type(), name)
+		if i < 0 {
+			continue
+		}
+		if n := overlap(reflect.Indirect(value.Field(i)), reflect.ValueOf(like)); n > 0 {
+			score += weight * float64(n)
+			count++
+		}
+	}
+	return score, count
+}
+
+// SearchMoreLikeThis is the search in memory: it excludes the models whose ids are in excluding, and ranks the others by LikeScore.
+func SearchMoreLikeThis[T any](models []T, m MoreLikeThis, excluding []string) []T {
+	type scored struct {
+		index int
+		score float64
+	}
+	minMatch := m.MinMatch
+	if minMatch < 1 {
+		minMatch = 1
+	}
+	var t T
+	idIndex := FindIdField(reflect.Indirect(reflect.ValueOf(&t).Elem()).Type())
+	candidates := make([]scored, 0)
+	for i := range models {
+		value := reflect.Indirect(reflect.ValueOf(&models[i]).Elem())
+		if idIndex >= 0 && value.Kind() == reflect.Struct && Contains(excluding, fmt.Sprint(reflect.Indirect(value.Field(idIndex)).Interface())) {
+			continue
+		}
+		score, count := LikeScore(value.Interface(), m)
+		if count >= minMatch {
+			candidates = append(candidates, scored{index: i, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	results := make([]T, 0, len(candidates))
+	for _, c := range candidates {
+		results = append(results, models[c.index])
+	}
+	return results
+}
+
+func overlap(v reflect.Value, like reflect.Value) int {
+	if !v.IsValid() || !like.IsValid() {
+		return 0
+	}
+	if v.Kind() != reflect.Slice && like.Kind() != reflect.Slice {
+		if fmt.Sprint(v.Interface()) == fmt.Sprint(like.Interface()) {
+			return 1
+		}
+		return 0
+	}
+	values := toStrings(v)
+	n := 0
+	for _, s := range toStrings(like) {
+		if Contains(values, s) {
+			n++
+		}
+	}
+	return n
+}
+func toStrings(v reflect.Value) []string {
+	if v.Kind() != reflect.Slice {
+		return []string{fmt.Sprint(v.Interface())}
+	}
+	s := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		s[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return s
+}
+
+// Contains checks if the strings contain the value.
+func Contains(s []string, v string) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// FindFieldByJson finds the index of the field whose json name is the name, or -1 if there is no such field.
+func FindFieldByJson(modelType reflect.Type, name string) int {
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		if strings.Split(modelType.Field(i).Tag.Get("json"), ",")[0] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindIdField finds the index of the field whose bson name is "_id", or whose json name is "id", or -1 if there is no such field.
+func FindIdField(modelType reflect.Type) int {
+	if modelType.Kind() != reflect.Struct {
+		return -1
+	}
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		if strings.Split(modelType.Field(i).Tag.Get("bson"), ",")[0] == "_id" {
+			return i
+		}
+	}
+	return FindFieldByJson(modelType, "id")
+}
@@ -0,0 +1,252 @@
+package search
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Unaccent is the option of the q tag to match without the diacritics and the case, such as `q:"like,unaccent"`.
+	Unaccent = "unaccent"
+	// Fuzzy is the option of the q tag to match with typos, such as `q:"like,fuzzy"` or `q:"like,fuzzy=2"`.
+	Fuzzy = "fuzzy"
+)
+
+// Normalizer is a step of the normalization pipeline of the keyword.
+type Normalizer func(string) string
+
+// accents are the letters with diacritics. The first letter of each item is the letter without diacritics.
+var accents = []string{
+	"AÀÁÂÃÄÅĀĂĄǍǞǠǺȀȂȦḀẠẢẤẦẨẪẬẮẰẲẴẶ",
+	"BḂḄḆ",
+	"CÇĆĈĊČḈ",
+	"DĎĐḊḌḎḐḒ",
+	"EÈÉÊËĒĔĖĘĚȄȆȨḔḖḘḚḜẸẺẼẾỀỂỄỆ",
+	"FḞ",
+	"GĜĞĠĢǦǴḠ",
+	"HĤĦȞḢḤḦḨḪ",
+	"IÌÍÎÏĨĪĬĮİǏȈȊḬḮỈỊ",
+	"JĴ",
+	"KĶǨḰḲḴ",
+	"LĹĻĽŁḶḸḺḼ",
+	"MḾṀṂ",
+	"NÑŃŅŇǸṄṆṈṊ",
+	"OÒÓÔÕÖØŌŎŐƠǑǪǬȌȎȪȬȮȰṌṎṐṒỌỎỐỒỔỖỘỚỜỞỠỢ",
+	"PṔṖ",
+	"RŔŖŘȐȒṘṚṜṞ",
+	"SŚŜŞŠȘṠṢṤṦṨ",
+	"TŢŤŦȚṪṬṮṰ",
+	"UÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖṲṴṶṸṺỤỦỨỪỬỮỰ",
+	"VṼṾ",
+	"WŴẀẂẄẆẈ",
+	"XẊẌ",
+	"YÝŶŸȲẎỲỴỶỸ",
+	"ZŹŻŽƵẐẒẔ",
+	"aàáâãäåāăąǎǟǡǻȁȃȧḁạảấầẩẫậắằẳẵặ",
+	"bƀḃḅḇ",
+	"cçćĉċčḉ",
+	"dďđḋḍḏḑḓ",
+	"eèéêëēĕėęěȅȇȩḕḗḙḛḝẹẻẽếềểễệ",
+	"fḟ",
+	"gĝğġģǧǵḡ",
+	"hĥħȟḣḥḧḩḫẖ",
+	"iìíîïĩīĭįıǐȉȋɨḭḯỉị",
+	"jĵǰ",
+	"kķǩḱḳḵ",
+	"lĺļľłḷḹḻḽ",
+	"mḿṁṃ",
+	"nñńņňǹṅṇṉṋ",
+	"oòóôõöøōŏőơǒǫǭȍȏȫȭȯȱṍṏṑṓọỏốồổỗộớờởỡợ",
+	"pṕṗ",
+	"rŕŗřȑȓṙṛṝṟ",
+	"sśŝşšșṡṣṥṧṩ",
+	"tţťŧțṫṭṯṱẗ",
+	"uùúûüũūŭůűųưǔǖǘǚǜȕȗṳṵṷṹṻụủứừửữự",
+	"vṽṿ",
+	"wŵẁẃẅẇẉẘ",
+	"xẋẍ",
+	"yýÿŷȳẏẙỳỵỷỹ",
+	"zźżžƶẑẓẕ",
+}
+var folding map[rune]rune
+var variants map[rune]string
+
+func init() {
+	folding = make(map[rune]rune)
+	variants = make(map[rune]string)
+	for _, s := range accents {
+		letters := []rune(s)
+		for _, r := range letters[1:] {
+			folding[r] = letters[0]
+		}
+		variants[letters[0]] = s
+	}
+}
+
+// Normalize runs the normalizers on the keyword. If there is no normalizer, it runs FoldUnicode, StripDiacritics and FoldCase.
+func Normalize(s string, normalizers ...Normalizer) string {
+	if len(normalizers) == 0 {
+		normalizers = []Normalizer{FoldUnicode, StripDiacritics, FoldCase}
+	}
+	for _, n := range normalizers {
+		s = n(s)
+	}
+	return s
+}
+
+var ligatures = map[rune]string{'ß': "ss", 'æ': "ae", 'Æ': "AE", 'œ': "oe", 'Œ': "OE", 'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl", 'ﬅ': "st", 'ﬆ': "st"}
+
+// FoldUnicode converts the full width letters and digits to ASCII, expands the ligatures, removes the combining marks and the control characters,
+// and collapses the spaces.
+func FoldUnicode(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range s {
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r = r - 0xFF01 + '!'
+		}
+		if unicode.IsSpace(r) {
+			space = sb.Len() > 0
+			continue
+		}
+		if unicode.Is(unicode.Mn, r) || unicode.IsControl(r) {
+			continue
+		}
+		if space {
+			sb.WriteRune(' ')
+			space = false
+		}
+		if l, ok := ligatures[r]; ok {
+			sb.WriteString(l)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// StripDiacritics removes the diacritics of the Latin letters, including the Vietnamese letters, such as "Nguyễn Đức" to "Nguyen Duc".
+func StripDiacritics(s string) string {
+	return strings.Map(func(r rune) rune {
+		if f, ok := folding[r]; ok {
+			return f
+		}
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// FoldCase converts the keyword to lower case.
+func FoldCase(s string) string {
+	return strings.ToLower(s)
+}
+
+// AccentPattern builds the regular expression which matches the keyword with or without the diacritics, such as "an" to "[aàáâ...][nñńņ...]".
+// The keyword is normalized and escaped, so the pattern is used with the case insensitive option.
+func AccentPattern(keyword string) string {
+	var sb strings.Builder
+	for _, r := range Normalize(keyword) {
+		if v, ok := variants[r]; ok {
+			sb.WriteString("[" + v + strings.ToUpper(v) + "]")
+		} else if strings.ContainsRune(`\.+*?()|[]{}^$`, r) {
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// GetOption gets the option of the q tag, such as "fuzzy=2" of `q:"like,fuzzy=2"`. The value is empty if the option has no value.
+func GetOption(tag string, option string) (string, bool) {
+	options := strings.Split(tag, ",")
+	for i := 1; i < len(options); i++ {
+		kv := strings.SplitN(strings.TrimSpace(options[i]), "=", 2)
+		if kv[0] == option {
+			if len(kv) == 2 {
+				return strings.TrimSpace(kv[1]), true
+			}
+			return "", true
+		}
+	}
+	return "", false
+}
+
+// FoldContains checks if the text contains the keyword, without the diacritics and the case. It is for the stores which cannot fold, such as in memory.
+func FoldContains(text string, keyword string) bool {
+	return strings.Contains(Normalize(text), Normalize(keyword))
+}
+
+// FuzzyContains checks if a word of the text is within the edit distance of each word of the keyword, without the diacritics and the case.
+// If the distance is negative, it is 0 for the words up to 2 letters, 1 up to 5 letters, and 2 for the longer words, as the AUTO fuzziness of Elasticsearch.
+func FuzzyContains(text string, keyword string, distance int) bool {
+	words := strings.Fields(Normalize(text))
+	for _, k := range strings.Fields(Normalize(keyword)) {
+		d := distance
+		if d < 0 {
+			d = autoFuzziness(k)
+		}
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, k) || levenshtein(w, k) <= d {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetFuzziness gets the edit distance of the fuzzy option: the number of "fuzzy=1", or -1 for AUTO.
+func GetFuzziness(value string) int {
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+		return n
+	}
+	return -1
+}
+
+func autoFuzziness(word string) int {
+	n := len([]rune(word))
+	if n <= 2 {
+		return 0
+	} else if n <= 5 {
+		return 1
+	}
+	return 2
+}
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,44 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// buildTerms builds the condition of the terms of the expanded keyword: each group must match any q column by any of its terms,
+// such as ((name like ? or name like ?) and (name like ?)) for [["tv", "television"], ["show"]].
+func buildTerms(driver string, qCols []string, qMatches []string, normalizedCols []string, normalizedTags []string, terms [][]string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	operator := like
+	if driver == driverPostgres {
+		operator = "ilike"
+	}
+	groups := make([]string, 0, len(terms))
+	values := make([]interface{}, 0)
+	for _, group := range terms {
+		conditions := make([]string, 0)
+		for _, term := range group {
+			for i, col := range qCols {
+				param := buildParam(marker + 1)
+				conditions = append(conditions, fmt.Sprintf("%s %s %s", col, operator, param))
+				if qMatches[i] == "=" {
+					values = append(values, term)
+				} else if qMatches[i] == "like" {
+					values = append(values, buildQ(term))
+				} else {
+					values = append(values, prefix(term))
+				}
+				marker++
+			}
+			for i, col := range normalizedCols {
+				condition, vs, next := buildNormalized(driver, col, normalizedTags[i], term, marker, buildParam)
+				conditions = append(conditions, condition)
+				values = append(values, vs...)
+				marker = next
+			}
+		}
+		if len(conditions) > 0 {
+			groups = append(groups, "("+strings.Join(conditions, " or ")+")")
+		}
+	}
+	return " (" + strings.Join(groups, " and ") + ") ", values, marker
+}
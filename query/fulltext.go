@@ -0,0 +1,178 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+const fulltext = "fulltext"
+
+// fulltextColumn is a column of the tag `q:"fulltext"`. The option after the comma is the language: the text search config of Postgres,
+// such as `q:"fulltext,english"`, or the language of SQL Server. For MySQL, it is the mode: "boolean" or "expansion".
+type fulltextColumn struct {
+	Column   string
+	Language string
+}
+
+// fulltextSearch is the full text search of the keyword on the columns.
+type fulltextSearch struct {
+	Columns []fulltextColumn
+	Keyword string
+}
+
+// getFulltext checks if the q/operator tag is the full text mode, and gets the option.
+func getFulltext(tag string) (string, bool) {
+	tags := strings.SplitN(tag, ",", 2)
+	if strings.TrimSpace(tags[0]) != fulltext {
+		return "", false
+	}
+	if len(tags) > 1 {
+		return strings.TrimSpace(tags[1]), true
+	}
+	return "", true
+}
+
+// buildFulltext builds the condition of the full text search by the driver:
+// Postgres to_tsvector @@ websearch_to_tsquery, MySQL MATCH AGAINST, SQL Server CONTAINS and SQLite FTS5 MATCH.
+// For the other drivers, it is like. The columns are OR, except MySQL, which matches all columns together, so the FULLTEXT index must have all of them.
+func buildFulltext(driver string, columns []fulltextColumn, keyword string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	values := make([]interface{}, 0)
+	conditions := make([]string, 0)
+	switch driver {
+	case driverMysql:
+		param := buildParam(marker + 1)
+		values = append(values, keyword)
+		return matchAgainst(columns, param), values, marker + 1
+	case driverPostgres:
+		for _, c := range columns {
+			param := buildParam(marker + 1)
+			conditions = append(conditions, fmt.Sprintf("%s @@ %s", toTsvector(c), toTsquery(c, param)))
+			values = append(values, keyword)
+			marker++
+		}
+	case driverMssql:
+		condition := buildContainsCondition(keyword)
+		for _, c := range columns {
+			param := buildParam(marker + 1)
+			if len(c.Language) > 0 {
+				conditions = append(conditions, fmt.Sprintf("contains(%s, %s, language '%s')", c.Column, param, sanitize(c.Language)))
+			} else {
+				conditions = append(conditions, fmt.Sprintf("contains(%s, %s)", c.Column, param))
+			}
+			values = append(values, condition)
+			marker++
+		}
+	case driverSqlite3:
+		condition := buildMatchCondition(keyword)
+		for _, c := range columns {
+			param := buildParam(marker + 1)
+			conditions = append(conditions, fmt.Sprintf("%s match %s", c.Column, param))
+			values = append(values, condition)
+			marker++
+		}
+	default:
+		for _, c := range columns {
+			param := buildParam(marker + 1)
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", c.Column, like, param))
+			values = append(values, buildQ(keyword))
+			marker++
+		}
+	}
+	if len(conditions) == 1 {
+		return conditions[0], values, marker
+	}
+	return "(" + strings.Join(conditions, " or ") + ")", values, marker
+}
+
+// buildRelevance builds the expression of the relevance, the greater the better: the sum of ts_rank for Postgres, MATCH AGAINST for MySQL,
+// and the negative bm25 of the table for SQLite. It returns empty for the drivers which cannot rank in the select, such as SQL Server.
+func buildRelevance(driver string, tableName string, searches []fulltextSearch, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	values := make([]interface{}, 0)
+	ranks := make([]string, 0)
+	switch driver {
+	case driverMysql:
+		for _, s := range searches {
+			param := buildParam(marker + 1)
+			ranks = append(ranks, matchAgainst(s.Columns, param))
+			values = append(values, s.Keyword)
+			marker++
+		}
+	case driverPostgres:
+		for _, s := range searches {
+			for _, c := range s.Columns {
+				param := buildParam(marker + 1)
+				ranks = append(ranks, fmt.Sprintf("ts_rank(%s, %s)", toTsvector(c), toTsquery(c, param)))
+				values = append(values, s.Keyword)
+				marker++
+			}
+		}
+	case driverSqlite3:
+		if len(searches) > 0 {
+			ranks = append(ranks, fmt.Sprintf("-bm25(%s)", tableName))
+		}
+	}
+	if len(ranks) == 0 {
+		return "", values, marker
+	}
+	if len(ranks) == 1 {
+		return ranks[0], values, marker
+	}
+	return "(" + strings.Join(ranks, " + ") + ")", values, marker
+}
+
+func matchAgainst(columns []fulltextColumn, param string) string {
+	cols := make([]string, 0)
+	mode := "in natural language mode"
+	for _, c := range columns {
+		cols = append(cols, c.Column)
+		if c.Language == "boolean" {
+			mode = "in boolean mode"
+		} else if c.Language == "expansion" {
+			mode = "with query expansion"
+		}
+	}
+	return fmt.Sprintf("match(%s) against(%s %s)", strings.Join(cols, ","), param, mode)
+}
+func toTsvector(c fulltextColumn) string {
+	if len(c.Language) > 0 {
+		return fmt.Sprintf("to_tsvector('%s', %s)", sanitize(c.Language), c.Column)
+	}
+	return fmt.Sprintf("to_tsvector(%s)", c.Column)
+}
+func toTsquery(c fulltextColumn, param string) string {
+	if len(c.Language) > 0 {
+		return fmt.Sprintf("websearch_to_tsquery('%s', %s)", sanitize(c.Language), param)
+	}
+	return fmt.Sprintf("websearch_to_tsquery(%s)", param)
+}
+
+// buildContainsCondition converts the keyword to the search condition of CONTAINS, such as "word1" AND "word2", so the keyword cannot break its syntax.
+func buildContainsCondition(keyword string) string {
+	words := strings.Fields(keyword)
+	terms := make([]string, 0)
+	for _, w := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// buildMatchCondition converts the keyword to the FTS5 query of the quoted words, which are AND.
+func buildMatchCondition(keyword string) string {
+	words := strings.Fields(keyword)
+	terms := make([]string, 0)
+	for _, w := range words {
+		terms = append(terms, `"`+strings.ReplaceAll(w, `"`, `""`)+`"`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// sanitize keeps the letters, the digits and the underscores of the language, which is put into the sql.
+func sanitize(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' {
+			sb.WriteRune(c)
+		}
+	}
+	return sb.String()
+}
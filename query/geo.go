@@ -0,0 +1,148 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	s "github.com/core-go/search"
+	"github.com/core-go/search/geo"
+)
+
+// EarthRadius is the mean radius of the earth in meters, used by the Haversine formula.
+const EarthRadius = 6371000.0
+
+const radian = "0.017453292519943295"
+
+// spatial is the option of the sql_builder tag of the geo filters, such as `sql_builder:"spatial"`, if the column of the field is a spatial column, such as the PostGIS geography.
+const spatial = "spatial"
+
+// getGeoColumns gets the latitude and longitude columns from the tag, such as `sql_builder:"latitude:lat;longitude:lng"`.
+// If they are not declared, the columns of the Latitude and Longitude fields of the model are used. The last result is true if the tag has the spatial option.
+func getGeoColumns(typeOfField reflect.StructField, modelType reflect.Type) (string, string, bool) {
+	isSpatial := false
+	for _, property := range strings.Split(typeOfField.Tag.Get("sql_builder"), ";") {
+		if strings.TrimSpace(property) == spatial {
+			isSpatial = true
+		}
+	}
+	latitude := getStringFromTag(typeOfField, "sql_builder", "latitude:")
+	longitude := getStringFromTag(typeOfField, "sql_builder", "longitude:")
+	if latitude != nil && longitude != nil {
+		return *latitude, *longitude, isSpatial
+	}
+	latitudeColumn, ok1 := getColumnName(modelType, "Latitude")
+	if !ok1 || len(latitudeColumn) == 0 {
+		latitudeColumn = "latitude"
+	}
+	longitudeColumn, ok2 := getColumnName(modelType, "Longitude")
+	if !ok2 || len(longitudeColumn) == 0 {
+		longitudeColumn = "longitude"
+	}
+	return latitudeColumn, longitudeColumn, isSpatial
+}
+
+// usePostGIS checks if the location column is a PostGIS geography column: the driver is postgres, and the column is spatial.
+func usePostGIS(driver string, isSpatial bool) bool {
+	return driver == driverPostgres && isSpatial
+}
+
+// BuildDistance builds the expression of the distance in meters from the point.
+// For PostGIS, it is ST_Distance of the spatial column. Otherwise, it is the Haversine formula of the latitude and longitude columns.
+func BuildDistance(driver string, column string, latitude string, longitude string, lat float64, lng float64, isSpatial bool) string {
+	if usePostGIS(driver, isSpatial) {
+		return fmt.Sprintf("ST_Distance(%s, %s)", column, makePoint(lat, lng))
+	}
+	return fmt.Sprintf("%s * asin(sqrt(power(sin((%s - %s) * %s / 2), 2) + cos(%s * %s) * cos(%s * %s) * power(sin((%s - %s) * %s / 2), 2)))",
+		formatFloat(2*EarthRadius), latitude, formatFloat(lat), radian, formatFloat(lat), radian, latitude, radian, longitude, formatFloat(lng), radian)
+}
+
+// BuildGeoDistance builds the condition of the locations within the radius. PostGIS uses ST_DWithin, which can use the spatial index.
+func BuildGeoDistance(driver string, column string, latitude string, longitude string, d s.GeoDistance, isSpatial bool) string {
+	if usePostGIS(driver, isSpatial) {
+		return fmt.Sprintf("ST_DWithin(%s, %s, %s)", column, makePoint(d.Latitude, d.Longitude), formatFloat(d.Radius))
+	}
+	return fmt.Sprintf("%s <= %s", BuildDistance(driver, column, latitude, longitude, d.Latitude, d.Longitude, isSpatial), formatFloat(d.Radius))
+}
+
+// BuildGeoBoundingBox builds the condition of the locations in the box. If Left is greater than Right, the box crosses the antimeridian.
+func BuildGeoBoundingBox(driver string, column string, latitude string, longitude string, b s.GeoBoundingBox, isSpatial bool) string {
+	if usePostGIS(driver, isSpatial) {
+		if b.Left > b.Right {
+			return fmt.Sprintf("(%s or %s)", makeEnvelope(column, b.Left, b.Bottom, 180, b.Top), makeEnvelope(column, -180, b.Bottom, b.Right, b.Top))
+		}
+		return makeEnvelope(column, b.Left, b.Bottom, b.Right, b.Top)
+	}
+	condition := fmt.Sprintf("%s >= %s and %s <= %s", latitude, formatFloat(b.Bottom), latitude, formatFloat(b.Top))
+	if b.Left > b.Right {
+		return fmt.Sprintf("%s and (%s >= %s or %s <= %s)", condition, longitude, formatFloat(b.Left), longitude, formatFloat(b.Right))
+	}
+	return fmt.Sprintf("%s and %s >= %s and %s <= %s", condition, longitude, formatFloat(b.Left), longitude, formatFloat(b.Right))
+}
+
+// BuildGeoShape builds the spatial condition of the location and the WKT parameter. The relation is "within" or "intersects".
+// If the column is spatial, it is the spatial column of the driver; otherwise the point is built from the latitude and longitude columns.
+func BuildGeoShape(driver string, column string, latitude string, longitude string, relation string, param string, isSpatial bool) string {
+	location := column
+	switch driver {
+	case driverPostgres:
+		if isSpatial {
+			location = column + "::geometry"
+		} else {
+			location = fmt.Sprintf("ST_SetSRID(ST_MakePoint(%s, %s), 4326)", longitude, latitude)
+		}
+		return fmt.Sprintf("%s(%s, ST_GeomFromText(%s, 4326))", stFunction(relation), location, param)
+	case driverMysql:
+		if !isSpatial {
+			location = fmt.Sprintf("ST_GeomFromText(concat('POINT(', %s, ' ', %s, ')'), 4326, 'axis-order=long-lat')", longitude, latitude)
+		}
+		return fmt.Sprintf("%s(%s, ST_GeomFromText(%s, 4326, 'axis-order=long-lat'))", stFunction(relation), location, param)
+	case driverMssql:
+		if !isSpatial {
+			location = fmt.Sprintf("geography::Point(%s, %s, 4326)", latitude, longitude)
+		}
+		method := "STIntersects"
+		if relation == "within" {
+			method = "STWithin"
+		}
+		return fmt.Sprintf("%s.%s(geography::STGeomFromText(%s, 4326)) = 1", location, method, param)
+	case driverOracle:
+		if !isSpatial {
+			location = fmt.Sprintf("SDO_GEOMETRY(2001, 4326, SDO_POINT_TYPE(%s, %s, NULL), NULL, NULL)", longitude, latitude)
+		}
+		mask := "ANYINTERACT"
+		if relation == "within" {
+			mask = "INSIDE+COVEREDBY"
+		}
+		return fmt.Sprintf("SDO_GEOM.RELATE(%s, '%s', SDO_GEOMETRY(%s, 4326), 0.005) <> 'FALSE'", location, mask, param)
+	default:
+		if !isSpatial {
+			location = fmt.Sprintf("MakePoint(%s, %s, 4326)", longitude, latitude)
+		}
+		return fmt.Sprintf("%s(%s, GeomFromText(%s, 4326))", stFunction(relation), location, param)
+	}
+}
+func stFunction(relation string) string {
+	if relation == "within" {
+		return "ST_Within"
+	}
+	return "ST_Intersects"
+}
+func makeEnvelope(column string, left float64, bottom float64, right float64, top float64) string {
+	return fmt.Sprintf("ST_Intersects(%s::geometry, ST_MakeEnvelope(%s, %s, %s, %s, 4326))", column, formatFloat(left), formatFloat(bottom), formatFloat(right), formatFloat(top))
+}
+func makePoint(lat float64, lng float64) string {
+	return fmt.Sprintf("ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography", formatFloat(lng), formatFloat(lat))
+}
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// toWKT validates the shape, and formats it as WKT.
+func toWKT(g geo.Geometry) (string, error) {
+	if err := g.Validate(); err != nil {
+		return "", err
+	}
+	return geo.ToWKT(g)
+}
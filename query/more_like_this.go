@@ -0,0 +1,97 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	s "github.com/core-go/search"
+)
+
+// buildLikeConditions builds the expression of each field of MoreLikeThis, with the weight of the field.
+// If rank is false, the expression is the condition: "=" for a value, "in" for a slice of the values of a column, and the overlap for an array column of postgres.
+// If rank is true, the expression is the number of the matched values: 0 or 1 for a column, and the number of the shared elements for an array column of postgres.
+func buildLikeConditions(m s.MoreLikeThis, modelType reflect.Type, driver string, rank bool, marker int, buildParam func(int) string) ([]string, []float64, []interface{}, int) {
+	conditions := make([]string, 0)
+	weights := make([]float64, 0)
+	values := make([]interface{}, 0)
+	for _, field := range m.Fields {
+		name, weight := s.ParseField(field)
+		like, ok := m.Like[name]
+		if !ok || like == nil {
+			continue
+		}
+		i, _, column := getFieldByJson(modelType, name)
+		if i < 0 {
+			continue
+		}
+		if len(column) == 0 {
+			column = name
+		}
+		var condition string
+		counted := false
+		v := reflect.ValueOf(like)
+		if v.Kind() == reflect.Slice {
+			if v.Len() == 0 {
+				continue
+			}
+			params := buildParametersFrom(marker, v.Len(), buildParam)
+			if driver == driverPostgres && isArray(modelType.Field(i).Type) {
+				if rank {
+					condition = fmt.Sprintf("(select count(distinct e) from unnest(%s) e where e %s (%s))", column, in, params)
+					counted = true
+				} else {
+					condition = fmt.Sprintf("exists (select 1 from unnest(%s) e where e %s (%s))", column, in, params)
+				}
+			} else {
+				condition = fmt.Sprintf("%s %s (%s)", column, in, params)
+			}
+			values = extractArray(values, like)
+			marker += v.Len()
+		} else {
+			condition = fmt.Sprintf("%s = %s", column, buildParam(marker+1))
+			values = append(values, like)
+			marker++
+		}
+		if rank && !counted {
+			condition = fmt.Sprintf("case when %s then 1 else 0 end", condition)
+		}
+		conditions = append(conditions, condition)
+		weights = append(weights, weight)
+	}
+	return conditions, weights, values, marker
+}
+func isArray(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() != reflect.Uint8
+}
+
+// buildMoreLikeThis builds the condition of MoreLikeThis: any of the fields matches, or the number of the matched fields is at least MinMatch.
+func buildMoreLikeThis(m s.MoreLikeThis, modelType reflect.Type, driver string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	conditions, _, values, next := buildLikeConditions(m, modelType, driver, false, marker, buildParam)
+	if len(conditions) == 0 {
+		return "", values, marker
+	}
+	if m.MinMatch <= 1 {
+		return "(" + strings.Join(conditions, " or ") + ")", values, next
+	}
+	counts := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		counts = append(counts, fmt.Sprintf("case when %s then 1 else 0 end", c))
+	}
+	return fmt.Sprintf("(%s) >= %d", strings.Join(counts, " + "), m.MinMatch), values, next
+}
+
+// buildLikeRank builds the weighted number of the matched values of the fields of MoreLikeThis, the greater the more similar.
+func buildLikeRank(m s.MoreLikeThis, modelType reflect.Type, driver string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	ranks, weights, values, next := buildLikeConditions(m, modelType, driver, true, marker, buildParam)
+	if len(ranks) == 0 {
+		return "", values, marker
+	}
+	for i, r := range ranks {
+		ranks[i] = fmt.Sprintf("%s * %s", r, formatWeight(weights[i]))
+	}
+	return "(" + strings.Join(ranks, " + ") + ")", values, next
+}
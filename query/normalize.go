@@ -0,0 +1,60 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	s "github.com/core-go/search"
+)
+
+// isNormalized checks if the q/operator tag has the unaccent or fuzzy option, such as `q:"like,unaccent"`.
+func isNormalized(tag string) bool {
+	_, unaccent := s.GetOption(tag, s.Unaccent)
+	_, fuzzy := s.GetOption(tag, s.Fuzzy)
+	return unaccent || fuzzy
+}
+
+// buildNormalized builds the accent insensitive or fuzzy condition of the string column.
+// Postgres uses unaccent, and the word similarity of pg_trgm for fuzzy, whose threshold is pg_trgm.word_similarity_threshold.
+// SQL Server uses the accent insensitive collation. The other drivers use like, because their default collations are usually accent insensitive, such as MySQL.
+func buildNormalized(driver string, column string, tag string, keyword string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	match := strings.TrimSpace(strings.Split(tag, ",")[0])
+	_, fuzzy := s.GetOption(tag, s.Fuzzy)
+	value := keyword
+	if match == "like" {
+		value = buildQ(keyword)
+	} else if match != "=" {
+		value = prefix(keyword)
+	}
+	param := buildParam(marker + 1)
+	values := []interface{}{value}
+	marker++
+	var condition string
+	switch driver {
+	case driverPostgres:
+		if match == "=" {
+			condition = fmt.Sprintf("lower(unaccent(%s)) = lower(unaccent(%s))", column, param)
+		} else {
+			condition = fmt.Sprintf("unaccent(%s) ilike unaccent(%s)", column, param)
+		}
+		if fuzzy {
+			param2 := buildParam(marker + 1)
+			condition = fmt.Sprintf("(%s or unaccent(%s) <%% unaccent(%s))", condition, param2, column)
+			values = append(values, keyword)
+			marker++
+		}
+	case driverMssql:
+		if match == "=" {
+			condition = fmt.Sprintf("%s collate Latin1_General_CI_AI = %s", column, param)
+		} else {
+			condition = fmt.Sprintf("%s collate Latin1_General_CI_AI %s %s", column, like, param)
+		}
+	default:
+		if match == "=" {
+			condition = fmt.Sprintf("%s = %s", column, param)
+		} else {
+			condition = fmt.Sprintf("%s %s %s", column, like, param)
+		}
+	}
+	return condition, values, marker
+}
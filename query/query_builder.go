@@ -9,6 +9,7 @@ import (
 	"time"
 
 	s "github.com/core-go/search"
+	"github.com/core-go/search/geo"
 )
 
 const (
@@ -55,6 +56,15 @@ func (b *Builder[T, F]) BuildQuery(filter F) (string, []interface{}) {
 	return Build(filter, b.TableName, b.ModelType, b.Driver, b.BuildParam)
 }
 
+// UseQueryWithError is UseQuery, which returns the error of the invalid filter, such as the invalid shape of GeoWithin.
+func UseQueryWithError[T any, F any](db *sql.DB, tableName string, options ...func(int) string) func(F) (string, []interface{}, error) {
+	b := NewBuilder[T, F](db, tableName, options...)
+	return b.BuildQueryWithError
+}
+func (b *Builder[T, F]) BuildQueryWithError(filter F) (string, []interface{}, error) {
+	return BuildWithError(filter, b.TableName, b.ModelType, b.Driver, b.BuildParam)
+}
+
 const (
 	like             = "like"
 	greaterEqualThan = ">="
@@ -92,17 +102,40 @@ func getColumnNameFromSqlBuilderTag(typeOfField reflect.StructField) *string {
 	}
 	return nil*/
 }
+
+// Build builds the query as BuildWithError. If the filter is invalid, it builds the query which returns no rows; use BuildWithError to get the error.
 func Build(filter interface{}, tableName string, modelType reflect.Type, driver string, buildParam func(int) string) (string, []interface{}) {
+	query, params, err := BuildWithError(filter, tableName, modelType, driver, buildParam)
+	if err != nil {
+		return "select * from " + tableName + " where 1 = 0", make([]interface{}, 0)
+	}
+	return query, params
+}
+
+// BuildWithError builds the query and the parameters of the filter, and returns the error of the invalid filter, such as the invalid shape of GeoWithin or GeoIntersects.
+func BuildWithError(filter interface{}, tableName string, modelType reflect.Type, driver string, buildParam func(int) string) (string, []interface{}, error) {
 	s1 := ""
 	rawConditions := make([]string, 0)
 	queryValues := make([]interface{}, 0)
 	qQueryValues := make([]string, 0)
 	qCols := make([]string, 0)
+	qMatches := make([]string, 0)
+	qTags := make([]string, 0)
+	normalizedCols := make([]string, 0)
+	normalizedTags := make([]string, 0)
 	rawJoin := make([]string, 0)
 	sortString := ""
+	sorts := ""
+	distance := ""
+	fulltextColumns := make([]fulltextColumn, 0)
+	searches := make([]fulltextSearch, 0)
 	fields := make([]string, 0)
 	var excluding []string
 	var keyword string
+	var terms [][]string
+	var vectorQuery *s.VectorQuery
+	var moreLikeThis *s.MoreLikeThis
+	vectorColumn := ""
 	value := reflect.Indirect(reflect.ValueOf(filter))
 	filterType := value.Type()
 	numField := value.NumField()
@@ -160,14 +193,26 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 			if len(keyword) > 0 {
 				qMatch, isQ := tf.Tag.Lookup("q")
 				if isQ {
-					if qMatch == "=" {
+					if language, ok := getFulltext(qMatch); ok {
+						fulltextColumns = append(fulltextColumns, fulltextColumn{Column: columnName, Language: language})
+						continue
+					}
+					if isNormalized(qMatch) {
+						normalizedCols = append(normalizedCols, columnName)
+						normalizedTags = append(normalizedTags, qMatch)
+						continue
+					}
+					match := strings.TrimSpace(strings.Split(qMatch, ",")[0])
+					if match == "=" {
 						qQueryValues = append(qQueryValues, keyword)
-					} else if qMatch == "like" {
+					} else if match == "like" {
 						qQueryValues = append(qQueryValues, buildQ(keyword))
 					} else {
 						qQueryValues = append(qQueryValues, prefix(keyword))
 					}
 					qCols = append(qCols, columnName)
+					qMatches = append(qMatches, match)
+					qTags = append(qTags, qMatch)
 				}
 			}
 			continue
@@ -184,10 +229,8 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 					}
 				}
 			}
-			if len(fields) > 0 {
-				s1 = `select ` + strings.Join(fields, ",") + ` from ` + tableName
-			}
 			if len(v.Sort) > 0 {
+				sorts = v.Sort
 				sortString = buildSort(v.Sort, modelType)
 			}
 			if v.Excluding != nil && len(v.Excluding) > 0 {
@@ -199,6 +242,7 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 			}
 			if len(v.Q) > 0 {
 				keyword = strings.TrimSpace(v.Q)
+				terms = v.Terms
 			}
 			continue
 		} else if len(psv) > 0 {
@@ -206,6 +250,23 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 			if !ok {
 				key, _ = tf.Tag.Lookup("q")
 			}
+			if language, ok := getFulltext(key); ok {
+				columns := []fulltextColumn{{Column: columnName, Language: language}}
+				condition, values, next := buildFulltext(driver, columns, psv, marker, buildParam)
+				rawConditions = append(rawConditions, condition)
+				queryValues = append(queryValues, values...)
+				marker = next
+				searches = append(searches, fulltextSearch{Columns: columns, Keyword: psv})
+				continue
+			}
+			if isNormalized(key) {
+				condition, values, next := buildNormalized(driver, columnName, key, psv, marker, buildParam)
+				rawConditions = append(rawConditions, condition)
+				queryValues = append(queryValues, values...)
+				marker = next
+				continue
+			}
+			key = strings.TrimSpace(strings.Split(key, ",")[0])
 			if key == "=" {
 				rawConditions = append(rawConditions, fmt.Sprintf("%s %s %s", columnName, "=", param))
 			} else {
@@ -306,6 +367,48 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 				queryValues = append(queryValues, dateRange.Max)
 				marker += 1
 			}
+		} else if geoDistance, ok := x.(s.GeoDistance); ok {
+			if geoDistance.Radius > 0 {
+				latitude, longitude, isSpatial := getGeoColumns(typeOfField, modelType)
+				rawConditions = append(rawConditions, BuildGeoDistance(driver, columnName, latitude, longitude, geoDistance, isSpatial))
+				distance = BuildDistance(driver, columnName, latitude, longitude, geoDistance.Latitude, geoDistance.Longitude, isSpatial)
+			}
+		} else if box, ok := x.(s.GeoBoundingBox); ok {
+			if !box.IsEmpty() {
+				latitude, longitude, isSpatial := getGeoColumns(typeOfField, modelType)
+				rawConditions = append(rawConditions, BuildGeoBoundingBox(driver, columnName, latitude, longitude, box, isSpatial))
+			}
+		} else if within, ok := x.(s.GeoWithin); ok {
+			if len(within.Type) > 0 {
+				wkt, err := toWKT(geo.Geometry(within))
+				if err != nil {
+					return "", nil, err
+				}
+				latitude, longitude, isSpatial := getGeoColumns(typeOfField, modelType)
+				rawConditions = append(rawConditions, BuildGeoShape(driver, columnName, latitude, longitude, "within", param, isSpatial))
+				queryValues = append(queryValues, wkt)
+				marker++
+			}
+		} else if intersects, ok := x.(s.GeoIntersects); ok {
+			if len(intersects.Type) > 0 {
+				wkt, err := toWKT(geo.Geometry(intersects))
+				if err != nil {
+					return "", nil, err
+				}
+				latitude, longitude, isSpatial := getGeoColumns(typeOfField, modelType)
+				rawConditions = append(rawConditions, BuildGeoShape(driver, columnName, latitude, longitude, "intersects", param, isSpatial))
+				queryValues = append(queryValues, wkt)
+				marker++
+			}
+		} else if mlt, ok := x.(s.MoreLikeThis); ok {
+			if len(mlt.Fields) > 0 && len(mlt.Like) > 0 {
+				moreLikeThis = &mlt
+			}
+		} else if vq, ok := x.(s.VectorQuery); ok {
+			if !vq.IsEmpty() {
+				vectorQuery = &vq
+				vectorColumn = columnName
+			}
 		} else if kind == reflect.Slice {
 			if field.Len() > 0 {
 				format := fmt.Sprintf("(%s)", buildParametersFrom(marker, field.Len(), buildParam))
@@ -330,19 +433,13 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 		rawConditions = append(rawConditions, fmt.Sprintf("%s NOT IN %s", idCol, format))
 		queryValues = extractArray(queryValues, excluding)
 	}
-	if len(s1) == 0 {
-		columns := getColumnsSelect(modelType)
-		if len(columns) > 0 {
-			s1 = `select  ` + strings.Join(columns, ",") + ` from ` + tableName
-		} else {
-			s1 = `select * from ` + tableName
-		}
-	}
-	if len(rawJoin) > 0 {
-		s1 = s1 + " " + strings.Join(rawJoin, " ")
-	}
-	if len(qCols) > 0 {
-		qConditions := make([]string, 0)
+	qConditions := make([]string, 0)
+	if len(terms) > 0 && (len(qCols) > 0 || len(normalizedCols) > 0) {
+		condition, values, next := buildTerms(driver, qCols, qMatches, normalizedCols, normalizedTags, terms, marker, buildParam)
+		qConditions = append(qConditions, condition)
+		queryValues = append(queryValues, values...)
+		marker = next
+	} else if len(qCols) > 0 || len(normalizedCols) > 0 {
 		if driver == driverPostgres { // "postgres"
 			for i, s := range qCols {
 				param := buildParam(marker + 1)
@@ -358,16 +455,135 @@ func Build(filter interface{}, tableName string, modelType reflect.Type, driver
 				marker++
 			}
 		}
-		if len(qConditions) > 0 {
-			rawConditions = append(rawConditions, " ("+strings.Join(qConditions, " or ")+") ")
+		for i, col := range normalizedCols {
+			condition, values, next := buildNormalized(driver, col, normalizedTags[i], keyword, marker, buildParam)
+			qConditions = append(qConditions, condition)
+			queryValues = append(queryValues, values...)
+			marker = next
 		}
 	}
+	if len(fulltextColumns) > 0 && len(keyword) > 0 {
+		condition, values, next := buildFulltext(driver, fulltextColumns, keyword, marker, buildParam)
+		qConditions = append(qConditions, condition)
+		queryValues = append(queryValues, values...)
+		marker = next
+		searches = append(searches, fulltextSearch{Columns: fulltextColumns, Keyword: keyword})
+	}
+	if len(qConditions) > 0 {
+		rawConditions = append(rawConditions, " ("+strings.Join(qConditions, " or ")+") ")
+	}
+	if moreLikeThis != nil {
+		condition, values, next := buildMoreLikeThis(*moreLikeThis, modelType, driver, marker, buildParam)
+		if len(condition) > 0 {
+			rawConditions = append(rawConditions, condition)
+			queryValues = append(queryValues, values...)
+			marker = next
+		} else {
+			moreLikeThis = nil
+		}
+	}
+	expressions := make(map[string]string)
+	if len(distance) > 0 {
+		expressions[s.Distance] = distance
+	}
+	selectScore := ""
+	vectorSort := ""
+	if vectorQuery != nil {
+		if driver != driverPostgres {
+			return "", nil, fmt.Errorf("VectorQuery is supported by postgres with pgvector only, not by %s", driver)
+		}
+		param := buildParam(marker + 1)
+		queryValues = append(queryValues, FormatVector(vectorQuery.Vector))
+		marker++
+		var err error
+		rawConditions, err = buildVector(*vectorQuery, vectorColumn, tableName, modelType, rawJoin, rawConditions, param)
+		if err != nil {
+			return "", nil, err
+		}
+		vectorDistance := BuildVectorDistance(vectorColumn, vectorQuery.GetMetric(), param)
+		vectorScore := BuildVectorScore(vectorQuery.GetMetric(), vectorDistance)
+		if len(sorts) == 0 || strings.TrimSpace(sorts) == "-"+s.Similarity {
+			sorts = "-" + s.Similarity
+			vectorSort = " order by " + vectorDistance + " " + asc
+		}
+		expressions[s.Similarity] = vectorScore
+		if column := getVectorScoreColumn(modelType); len(column) > 0 {
+			selectScore = fmt.Sprintf(", %s as %s", vectorScore, column)
+		}
+	}
+	if moreLikeThis != nil && (len(sorts) == 0 || hasSortField(sorts, s.Relevance)) {
+		rank, values, next := buildLikeRank(*moreLikeThis, modelType, driver, marker, buildParam)
+		if len(sorts) == 0 {
+			sorts = "-" + s.Relevance
+		}
+		expressions[s.Relevance] = rank
+		queryValues = append(queryValues, values...)
+		marker = next
+	}
+	scoreColumn := ""
+	if len(keyword) > 0 {
+		scoreColumn = getScoreColumn(modelType)
+	}
+	if len(keyword) > 0 && len(expressions[s.Relevance]) == 0 && (len(scoreColumn) > 0 || len(sorts) == 0 || hasSortField(sorts, s.Relevance)) {
+		relevance := ""
+		var values []interface{}
+		if len(searches) > 0 {
+			relevance, values, _ = buildRelevance(driver, tableName, searches, marker, buildParam)
+		}
+		if len(relevance) == 0 {
+			columns := make([]qColumn, 0)
+			for i, col := range qCols {
+				columns = append(columns, qColumn{Column: col, Tag: qTags[i]})
+			}
+			for i, col := range normalizedCols {
+				columns = append(columns, qColumn{Column: col, Tag: normalizedTags[i]})
+			}
+			if len(columns) > 0 {
+				relevance, values, _ = buildScore(driver, columns, keyword, marker, buildParam)
+			}
+		}
+		if len(relevance) > 0 {
+			if len(sorts) == 0 {
+				sorts = "-" + s.Relevance
+			}
+			if len(scoreColumn) > 0 {
+				selectScore = selectScore + fmt.Sprintf(", %s as %s", relevance, scoreColumn)
+				expressions[s.Relevance] = scoreColumn
+				if isPositional(buildParam) {
+					queryValues = append(values, queryValues...)
+				} else {
+					queryValues = append(queryValues, values...)
+				}
+			} else if hasSortField(sorts, s.Relevance) {
+				expressions[s.Relevance] = relevance
+				queryValues = append(queryValues, values...)
+			}
+		}
+	}
+	if len(vectorSort) > 0 {
+		sortString = vectorSort
+	} else if len(expressions) > 0 && len(sorts) > 0 {
+		sortString = buildSortWithExpressions(sorts, modelType, expressions)
+	}
+	if len(fields) > 0 {
+		s1 = `select ` + strings.Join(fields, ",") + selectScore + ` from ` + tableName
+	} else {
+		columns := getColumnsSelect(modelType)
+		if len(columns) > 0 {
+			s1 = `select  ` + strings.Join(columns, ",") + selectScore + ` from ` + tableName
+		} else {
+			s1 = `select *` + selectScore + ` from ` + tableName
+		}
+	}
+	if len(rawJoin) > 0 {
+		s1 = s1 + " " + strings.Join(rawJoin, " ")
+	}
 	if len(rawConditions) > 0 {
 		s2 := s1 + ` where ` + strings.Join(rawConditions, " and ") + sortString
-		return s2, queryValues
+		return s2, queryValues, nil
 	}
 	s3 := s1 + sortString
-	return s3, queryValues
+	return s3, queryValues, nil
 }
 func extractArray(values []interface{}, field interface{}) []interface{} {
 	s := reflect.Indirect(reflect.ValueOf(field))
@@ -477,6 +693,9 @@ func getColumnsSelect(modelType reflect.Type) []string {
 	columnNameKeys := make([]string, 0)
 	for i := 0; i < numField; i++ {
 		field := modelType.Field(i)
+		if isScore(field) || field.Tag.Get("vector") == "score" {
+			continue
+		}
 		ormTag := field.Tag.Get("gorm")
 		if has := strings.Contains(ormTag, "column"); has {
 			str1 := strings.Split(ormTag, ";")
@@ -499,6 +718,11 @@ func getColumnsSelect(modelType reflect.Type) []string {
 	return columnNameKeys
 }
 func buildSort(sortString string, modelType reflect.Type) string {
+	return buildSortWithExpressions(sortString, modelType, nil)
+}
+
+// buildSortWithExpressions builds the sort. The fields which are not in the model, such as "distance" and "relevance", are sorted by the expressions.
+func buildSortWithExpressions(sortString string, modelType reflect.Type, expressions map[string]string) string {
 	var sort = make([]string, 0)
 	sorts := strings.Split(sortString, ",")
 	for i := 0; i < len(sorts); i++ {
@@ -509,6 +733,9 @@ func buildSort(sortString string, modelType reflect.Type) string {
 			fieldName = sortField[1:]
 		}
 		columnName := getColumnNameForSearch(modelType, fieldName)
+		if len(columnName) == 0 {
+			columnName = expressions[fieldName]
+		}
 		if len(columnName) > 0 {
 			sortType := getSortType(c)
 			sort = append(sort, columnName+" "+sortType)
@@ -520,6 +747,16 @@ func buildSort(sortString string, modelType reflect.Type) string {
 		return ""
 	}
 }
+func hasSortField(sortString string, field string) bool {
+	sorts := strings.Split(sortString, ",")
+	for _, sortField := range sorts {
+		sortField = strings.TrimSpace(sortField)
+		if strings.TrimLeft(sortField, "+-") == field {
+			return true
+		}
+	}
+	return false
+}
 func getColumnNameForSearch(modelType reflect.Type, sortField string) string {
 	sortField = strings.TrimSpace(sortField)
 	i, _, column := getFieldByJson(modelType, sortField)
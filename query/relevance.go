@@ -0,0 +1,94 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	s "github.com/core-go/search"
+)
+
+// qColumn is a column which is searched by Q, with the q tag, such as `q:"like,weight=3"`.
+type qColumn struct {
+	Column string
+	Tag    string
+}
+
+// buildScore builds the portable CASE expression of the relevance of the keyword, the greater the better: 3 for the exact match, 2 for the prefix match,
+// 1 for the match anywhere, multiplied by the weight of the column, and summed for all the columns.
+func buildScore(driver string, columns []qColumn, keyword string, marker int, buildParam func(int) string) (string, []interface{}, int) {
+	values := make([]interface{}, 0)
+	scores := make([]string, 0, len(columns))
+	operator := like
+	if driver == driverPostgres {
+		operator = "ilike"
+	}
+	for _, c := range columns {
+		weight := getWeight(c.Tag)
+		col := c.Column
+		normalize := func(p string) string { return p }
+		if isNormalized(c.Tag) {
+			if driver == driverPostgres {
+				col = fmt.Sprintf("unaccent(%s)", c.Column)
+				normalize = func(p string) string { return fmt.Sprintf("unaccent(%s)", p) }
+			} else if driver == driverMssql {
+				col = fmt.Sprintf("%s collate Latin1_General_CI_AI", c.Column)
+			}
+		}
+		p1, p2, p3 := buildParam(marker+1), buildParam(marker+2), buildParam(marker+3)
+		equal := fmt.Sprintf("%s = %s", col, normalize(p1))
+		if driver == driverPostgres {
+			equal = fmt.Sprintf("%s ilike %s", col, normalize(p1))
+		}
+		scores = append(scores, fmt.Sprintf("case when %s then %s when %s %s %s then %s when %s %s %s then %s else 0 end",
+			equal, formatWeight(3*weight), col, operator, normalize(p2), formatWeight(2*weight), col, operator, normalize(p3), formatWeight(weight)))
+		values = append(values, keyword, prefix(keyword), buildQ(keyword))
+		marker += 3
+	}
+	if len(scores) == 1 {
+		return scores[0], values, marker
+	}
+	return "(" + strings.Join(scores, " + ") + ")", values, marker
+}
+
+// getScoreColumn gets the column of the field of the model which receives the relevance, such as `gorm:"column:score" sql_builder:"score"`.
+func getScoreColumn(modelType reflect.Type) string {
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		field := modelType.Field(i)
+		if isScore(field) {
+			if column, ok := getColumnName(modelType, field.Name); ok && len(column) > 0 {
+				return column
+			}
+			return strings.ToLower(field.Name)
+		}
+	}
+	return ""
+}
+func isScore(field reflect.StructField) bool {
+	for _, property := range strings.Split(field.Tag.Get("sql_builder"), ";") {
+		if strings.TrimSpace(property) == "score" {
+			return true
+		}
+	}
+	return false
+}
+func getWeight(tag string) float64 {
+	if v, ok := s.GetOption(tag, s.Weight); ok {
+		if w, err := strconv.ParseFloat(v, 64); err == nil && w > 0 {
+			return w
+		}
+	}
+	return 1
+}
+func formatWeight(w float64) string {
+	return strconv.FormatFloat(w, 'f', -1, 64)
+}
+
+// isPositional checks if the parameters are bound by the position, such as "?", or ":1" of Oracle, which binds by the position even if the parameters are numbered,
+// so the values of the select must be before the values of the where.
+func isPositional(buildParam func(int) string) bool {
+	p := buildParam(1)
+	return p == buildParam(2) || strings.HasPrefix(p, ":")
+}
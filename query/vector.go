@@ -0,0 +1,114 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	s "github.com/core-go/search"
+)
+
+// BuildVectorDistance builds the pgvector distance of the column and the vector: <=> for cosine, <-> for l2_norm and <#> for dot_product,
+// which is the negative inner product. The smaller the more similar, so the order by the distance uses the index of the column.
+func BuildVectorDistance(column string, metric string, param string) string {
+	operator := "<=>"
+	switch metric {
+	case s.L2Norm:
+		operator = "<->"
+	case s.DotProduct:
+		operator = "<#>"
+	}
+	return fmt.Sprintf("(%s %s cast(%s as vector))", column, operator, param)
+}
+
+// BuildVectorScore builds the score of the distance, the same as the score of VectorQuery.
+func BuildVectorScore(metric string, distance string) string {
+	switch metric {
+	case s.L2Norm:
+		return fmt.Sprintf("(1 / (1 + %s * %s))", distance, distance)
+	case s.DotProduct:
+		return fmt.Sprintf("((1 - %s) / 2)", distance)
+	default:
+		return fmt.Sprintf("(1 - %s / 2)", distance)
+	}
+}
+
+// getMaxDistance converts the minimum score to the maximum distance, so the condition is on the distance instead of the score.
+func getMaxDistance(metric string, minScore float64) float64 {
+	switch metric {
+	case s.L2Norm:
+		return math.Sqrt(1/minScore - 1)
+	case s.DotProduct:
+		return 1 - 2*minScore
+	default:
+		return 2 * (1 - minScore)
+	}
+}
+
+// FormatVector formats the vector as the text of pgvector, such as "[0.1,0.2,0.3]".
+func FormatVector(vector []float32) string {
+	values := make([]string, len(vector))
+	for i, v := range vector {
+		values[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+// buildVector builds the conditions of VectorQuery for Postgres: the maximum distance of the minimum score, and the K nearest rows,
+// which are selected by the key and the other conditions, so the conditions are the pre-filter of the nearest neighbors.
+// The model must have the key column if K is set.
+func buildVector(q s.VectorQuery, column string, tableName string, modelType reflect.Type, rawJoin []string, rawConditions []string, param string) ([]string, error) {
+	metric := q.GetMetric()
+	distance := BuildVectorDistance(column, metric, param)
+	if q.MinScore > 0 {
+		rawConditions = append(rawConditions, fmt.Sprintf("%s <= %s", distance, formatFloat(math.Round(getMaxDistance(metric, q.MinScore)*1e9)/1e9)))
+	}
+	if q.K > 0 {
+		key := getKeyColumn(modelType)
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%s must have the key column, whose bson name is '_id' or whose json name is 'id', to filter the K nearest neighbors", modelType.Name())
+		}
+		sub := fmt.Sprintf("select %s.%s from %s", tableName, key, tableName)
+		if len(rawJoin) > 0 {
+			sub = sub + " " + strings.Join(rawJoin, " ")
+		}
+		if len(rawConditions) > 0 {
+			sub = sub + " where " + strings.Join(rawConditions, " and ")
+		}
+		sub = fmt.Sprintf("%s order by %s limit %d", sub, distance, q.K)
+		rawConditions = append(rawConditions, fmt.Sprintf("%s.%s in (%s)", tableName, key, sub))
+	}
+	return rawConditions, nil
+}
+
+// getKeyColumn gets the column of the field of the model whose bson name is "_id", or whose json name is "id".
+func getKeyColumn(modelType reflect.Type) string {
+	if i, _, column := getFieldByBson(modelType, "_id"); i >= 0 {
+		if len(column) > 0 {
+			return column
+		}
+		return strings.ToLower(modelType.Field(i).Name)
+	}
+	if i, _, column := getFieldByJson(modelType, "id"); i >= 0 {
+		if len(column) > 0 {
+			return column
+		}
+		return "id"
+	}
+	return ""
+}
+
+// getVectorScoreColumn gets the column of the field of the model which receives the score of VectorQuery, such as `gorm:"column:score" vector:"score"`.
+func getVectorScoreColumn(modelType reflect.Type) string {
+	i := s.FindVectorScoreField(modelType)
+	if i < 0 {
+		return ""
+	}
+	field := modelType.Field(i)
+	if column, ok := getColumnName(modelType, field.Name); ok && len(column) > 0 {
+		return column
+	}
+	return strings.ToLower(field.Name)
+}
@@ -0,0 +1,59 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+
+	"github.com/core-go/search/geo"
+)
+
+// ClusterBuilder loads all rows of the filter and clusters them in memory by geo.Cluster, because SQL has no geo grid aggregation.
+// The filter should have a GeoBoundingBox of the viewport, so that the rows are limited.
+type ClusterBuilder[T any, F any] struct {
+	Database    *sql.DB
+	BuildQuery  func(F) (string, []interface{})
+	fieldsIndex map[string]int
+	Latitude    string
+	Longitude   string
+	ToArray     func(interface{}) interface {
+		driver.Valuer
+		sql.Scanner
+	}
+}
+
+// NewClusterBuilder creates the builder. The options are the names of the latitude and longitude fields of T, which are "Latitude" and "Longitude" by default.
+func NewClusterBuilder[T any, F any](db *sql.DB, buildQuery func(F) (string, []interface{}), opts ...string) (*ClusterBuilder[T, F], error) {
+	var t T
+	modelType := reflect.TypeOf(t)
+	if modelType.Kind() != reflect.Struct {
+		return nil, errors.New("T must be a struct")
+	}
+	fieldsIndex, err := GetColumnIndexes(modelType)
+	if err != nil {
+		return nil, err
+	}
+	latitude := "Latitude"
+	longitude := "Longitude"
+	if len(opts) > 0 && len(opts[0]) > 0 {
+		latitude = opts[0]
+	}
+	if len(opts) > 1 && len(opts[1]) > 0 {
+		longitude = opts[1]
+	}
+	return &ClusterBuilder[T, F]{Database: db, BuildQuery: buildQuery, fieldsIndex: fieldsIndex, Latitude: latitude, Longitude: longitude}, nil
+}
+
+func (b *ClusterBuilder[T, F]) Cluster(ctx context.Context, filter F, grid geo.Grid) ([]geo.Cell, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	query, params := b.BuildQuery(filter)
+	var objs []T
+	if err := QueryWithArray(ctx, b.Database, b.fieldsIndex, &objs, b.ToArray, query, params...); err != nil {
+		return nil, err
+	}
+	return geo.Cluster(objs, grid, b.Latitude, b.Longitude)
+}
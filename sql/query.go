@@ -6,6 +6,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -49,7 +50,7 @@ func BuildFromQuery(ctx context.Context, db *sql.DB, fieldsIndex map[string]int,
 			if er1 != nil {
 				return -1, er1
 			}
-			total, er2 := Count(ctx, db, queryCount, params...)
+			total, er2 := Count(ctx, db, queryCount, BuildCountParams(query, params)...)
 			if er2 != nil {
 				total = 0
 			}
@@ -102,11 +103,11 @@ func BuildCountQuery(sql string) string {
 	if i < 0 {
 		return sql
 	}
-	j := strings.Index(sql, " from ")
+	j := indexOutside(sql, " from ")
 	if j < 0 {
 		return sql
 	}
-	k := strings.Index(sql, " order by ")
+	k := indexOutside(sql, " order by ")
 	h := strings.Index(sql, " distinct ")
 	if h > 0 {
 		sql3 := `select count(*) as total from (` + sql[i:] + `) as main`
@@ -121,6 +122,66 @@ func BuildCountQuery(sql string) string {
 	}
 }
 
+// BuildCountParams removes the params of the select list and the order by, such as the params of the relevance, because they are removed by BuildCountQuery.
+// For the positional params, such as "?" and ":1" of Oracle, which binds by the position, the params of the select list are the first params, and the params of the order by are the last params.
+// For the numbered params, such as "$1", the params after the greatest number of the count query are removed.
+func BuildCountParams(sql string, params []interface{}) []interface{} {
+	i := strings.Index(sql, "select ")
+	j := indexOutside(sql, " from ")
+	if i < 0 || j < 0 || strings.Contains(sql, " distinct ") {
+		return params
+	}
+	k := indexOutside(sql, " order by ")
+	if k < 0 {
+		k = len(sql)
+	}
+	if !isPositional(sql) {
+		last := 0
+		for _, p := range paramRegex.FindAllString(sql[j:k], -1) {
+			if n, err := strconv.Atoi(strings.TrimLeft(p, "$@p:")); err == nil && n > last {
+				last = n
+			}
+		}
+		if last < len(params) {
+			return params[:last]
+		}
+		return params
+	}
+	selectParams := len(paramRegex.FindAllString(sql[i:j], -1))
+	sortParams := len(paramRegex.FindAllString(sql[k:], -1))
+	if selectParams+sortParams > len(params) {
+		return params
+	}
+	return params[selectParams : len(params)-sortParams]
+}
+
+var paramRegex = regexp.MustCompile(`\?|\$[0-9]+|@p[0-9]+|:[0-9]+`)
+
+func isPositional(sql string) bool {
+	p := paramRegex.FindString(sql)
+	return strings.HasPrefix(p, "?") || strings.HasPrefix(p, ":")
+}
+
+// indexOutside returns the index of the first keyword which is not in the parentheses or the quotes, such as the order by of a subquery.
+func indexOutside(sql string, keyword string) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(sql[i:], keyword):
+			return i
+		}
+	}
+	return -1
+}
+
 func GetSort(sortString string, modelType reflect.Type) string {
 	var sort = make([]string, 0)
 	sorts := strings.Split(sortString, ",")
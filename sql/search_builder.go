@@ -17,6 +17,11 @@ type SearchBuilder[T any, F any] struct {
 		driver.Valuer
 		sql.Scanner
 	}
+	// BuildPagingQuery and BuildCountQuery build the query of the page and the count query, such as by the template which has the paging node. If they are set, BuildQuery is not used.
+	BuildPagingQuery func(F, int64, int64) (string, []interface{})
+	BuildCountQuery  func(F) (string, []interface{})
+	// BuildQueryWithError builds the query, and returns the error of the invalid filter, which is returned by Search. If it is set, BuildQuery is not used.
+	BuildQueryWithError func(F) (string, []interface{}, error)
 }
 
 func NewSearchBuilder[T any, F any](db *sql.DB, buildQuery func(F) (string, []interface{}), opts ...func(*T)) (*SearchBuilder[T, F], error) {
@@ -43,10 +48,46 @@ func NewSearchBuilderWithArray[T any, F any](db *sql.DB, buildQuery func(F) (str
 	return builder, nil
 }
 
+// NewSearchBuilderWithPaging creates the search builder which pages and counts by the queries of buildPagingQuery and buildCountQuery, instead of changing the text of the query.
+func NewSearchBuilderWithPaging[T any, F any](db *sql.DB, buildPagingQuery func(F, int64, int64) (string, []interface{}), buildCountQuery func(F) (string, []interface{}), toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}, opts ...func(*T)) (*SearchBuilder[T, F], error) {
+	builder, err := NewSearchBuilderWithArray[T, F](db, nil, toArray, opts...)
+	if err != nil {
+		return nil, err
+	}
+	builder.BuildPagingQuery = buildPagingQuery
+	builder.BuildCountQuery = buildCountQuery
+	return builder, nil
+}
+
+// NewSearchBuilderWithError creates the search builder by buildQuery, which returns the error of the invalid filter, such as query.UseQueryWithError.
+func NewSearchBuilderWithError[T any, F any](db *sql.DB, buildQuery func(F) (string, []interface{}, error), opts ...func(*T)) (*SearchBuilder[T, F], error) {
+	builder, err := NewSearchBuilderWithArray[T, F](db, nil, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	builder.BuildQueryWithError = buildQuery
+	return builder, nil
+}
+
 func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64, offset int64) ([]T, int64, error) {
-	query, params := b.BuildQuery(filter)
 	var objs []T
-	total, er2 := BuildFromQuery(ctx, b.Database, b.fieldsIndex, &objs, query, params, limit, offset, b.ToArray)
+	var total int64
+	var er2 error
+	if b.BuildPagingQuery != nil && b.BuildCountQuery != nil {
+		total, er2 = b.searchPage(ctx, filter, &objs, limit, offset)
+	} else if b.BuildQueryWithError != nil {
+		query, params, er1 := b.BuildQueryWithError(filter)
+		if er1 != nil {
+			return objs, -1, er1
+		}
+		total, er2 = BuildFromQuery(ctx, b.Database, b.fieldsIndex, &objs, query, params, limit, offset, b.ToArray)
+	} else {
+		query, params := b.BuildQuery(filter)
+		total, er2 = BuildFromQuery(ctx, b.Database, b.fieldsIndex, &objs, query, params, limit, offset, b.ToArray)
+	}
 	if b.Map != nil {
 		l := len(objs)
 		for i := 0; i < l; i++ {
@@ -55,3 +96,18 @@ func (b *SearchBuilder[T, F]) Search(ctx context.Context, filter F, limit int64,
 	}
 	return objs, total, er2
 }
+func (b *SearchBuilder[T, F]) searchPage(ctx context.Context, filter F, objs *[]T, limit int64, offset int64) (int64, error) {
+	query, params := b.BuildPagingQuery(filter, limit, offset)
+	if er1 := QueryWithArray(ctx, b.Database, b.fieldsIndex, objs, b.ToArray, query, params...); er1 != nil {
+		return -1, er1
+	}
+	if limit <= 0 {
+		return int64(len(*objs)), nil
+	}
+	queryCount, countParams := b.BuildCountQuery(filter)
+	total, er2 := Count(ctx, b.Database, queryCount, countParams...)
+	if er2 != nil {
+		return -1, er2
+	}
+	return total, nil
+}
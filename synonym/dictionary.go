@@ -0,0 +1,115 @@
+package synonym
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Dictionary has the synonyms of the words and the phrases, which are in lower case, and the stop words.
+type Dictionary struct {
+	Synonyms  map[string][]string
+	StopWords map[string]bool
+	// MaxWords is the number of the words of the longest phrase of Synonyms.
+	MaxWords int
+}
+
+// NewDictionary builds the dictionary from the rules in the Solr format and the stop words.
+// "tv, television, telly" are equivalent, so each of them is expanded to all of them. "usa, united states => america" replaces the words on the left with the words on the right.
+func NewDictionary(rules []string, stopWords []string) (*Dictionary, error) {
+	d := &Dictionary{Synonyms: make(map[string][]string), StopWords: make(map[string]bool)}
+	for i, rule := range rules {
+		if err := d.AddRule(rule); err != nil {
+			return nil, fmt.Errorf("invalid synonym rule %d: %w", i+1, err)
+		}
+	}
+	for _, w := range stopWords {
+		w = normalize(w)
+		if len(w) > 0 {
+			d.StopWords[w] = true
+		}
+	}
+	return d, nil
+}
+
+// AddRule adds a rule in the Solr format, such as "tv, television" or "tv => television".
+func (d *Dictionary) AddRule(rule string) error {
+	rule = strings.TrimSpace(rule)
+	if len(rule) == 0 || strings.HasPrefix(rule, "#") {
+		return nil
+	}
+	var left, right []string
+	if parts := strings.Split(rule, "=>"); len(parts) == 2 {
+		left = split(parts[0])
+		right = split(parts[1])
+		if len(left) == 0 || len(right) == 0 {
+			return fmt.Errorf("%q has no words on one side of =>", rule)
+		}
+	} else if len(parts) == 1 {
+		left = split(rule)
+		right = left
+	} else {
+		return fmt.Errorf("%q has more than one =>", rule)
+	}
+	for _, w := range left {
+		d.Synonyms[w] = appendUnique(d.Synonyms[w], right...)
+		if n := len(strings.Fields(w)); n > d.MaxWords {
+			d.MaxWords = n
+		}
+	}
+	return nil
+}
+
+// Rules returns the synonyms in the Solr explicit format, such as "tv => tv, television", which can be used by the synonym filter of Elasticsearch.
+func (d *Dictionary) Rules() []string {
+	rules := make([]string, 0, len(d.Synonyms))
+	for w, synonyms := range d.Synonyms {
+		rules = append(rules, w+" => "+strings.Join(synonyms, ", "))
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// ReadLines reads the lines which are not empty and not comments, which start with "#".
+func ReadLines(r io.Reader) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func split(s string) []string {
+	words := make([]string, 0)
+	for _, w := range strings.Split(s, ",") {
+		w = normalize(w)
+		if len(w) > 0 {
+			words = appendUnique(words, w)
+		}
+	}
+	return words
+}
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+func appendUnique(words []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, w := range words {
+			if w == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			words = append(words, v)
+		}
+	}
+	return words
+}
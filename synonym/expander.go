@@ -0,0 +1,104 @@
+package synonym
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Expander expands the keyword by the dictionary of the loader. It implements search.Expander.
+// The dictionary is replaced by Reload, so it is changed without restarting, and the searches in progress keep the previous dictionary.
+type Expander struct {
+	Loader     Loader
+	mu         sync.RWMutex
+	dictionary *Dictionary
+}
+
+func NewExpander(ctx context.Context, loader Loader) (*Expander, error) {
+	e := &Expander{Loader: loader}
+	if err := e.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Reload loads the dictionary again. If it fails, the current dictionary is kept.
+func (e *Expander) Reload(ctx context.Context) error {
+	d, err := e.Loader.Load(ctx)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	e.dictionary = d
+	e.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the dictionary every interval, until the context is done. It is run by a goroutine, such as "go expander.Watch(ctx, time.Minute, logError)".
+func (e *Expander) Watch(ctx context.Context, interval time.Duration, logError func(context.Context, string, ...map[string]interface{})) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Reload(ctx); err != nil && logError != nil {
+				logError(ctx, "cannot reload the synonyms: "+err.Error())
+			}
+		}
+	}
+}
+
+// Dictionary returns the current dictionary.
+func (e *Expander) Dictionary() *Dictionary {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dictionary
+}
+
+// Expand splits the keyword into the words, and matches the longest phrase of the dictionary at each word.
+// A phrase with synonyms is a group of its synonyms, a stop word is removed, and any other word is a group of itself.
+// If all the words are stop words, such as "the who", they are kept.
+func (e *Expander) Expand(keyword string) [][]string {
+	return Expand(e.Dictionary(), keyword)
+}
+
+// Expand expands the keyword by the dictionary, as Expander.Expand.
+func Expand(d *Dictionary, keyword string) [][]string {
+	words := strings.Fields(keyword)
+	groups := make([][]string, 0, len(words))
+	if d == nil {
+		for _, w := range words {
+			groups = append(groups, []string{w})
+		}
+		return groups
+	}
+	for i := 0; i < len(words); {
+		n := d.MaxWords
+		if n > len(words)-i {
+			n = len(words) - i
+		}
+		for ; n > 0; n-- {
+			if synonyms, ok := d.Synonyms[normalize(strings.Join(words[i:i+n], " "))]; ok {
+				groups = append(groups, append([]string{}, synonyms...))
+				break
+			}
+		}
+		if n > 0 {
+			i += n
+			continue
+		}
+		if !d.StopWords[strings.ToLower(words[i])] {
+			groups = append(groups, []string{words[i]})
+		}
+		i++
+	}
+	if len(groups) == 0 {
+		for _, w := range words {
+			groups = append(groups, []string{w})
+		}
+	}
+	return groups
+}
@@ -0,0 +1,51 @@
+package synonym
+
+import (
+	"context"
+	"os"
+)
+
+// Loader loads the dictionary, such as from the files or the database. It is called again when the dictionary is reloaded.
+type Loader interface {
+	Load(ctx context.Context) (*Dictionary, error)
+}
+
+// LoaderFunc is the function which implements Loader.
+type LoaderFunc func(ctx context.Context) (*Dictionary, error)
+
+func (f LoaderFunc) Load(ctx context.Context) (*Dictionary, error) {
+	return f(ctx)
+}
+
+// FileLoader loads the synonyms in the Solr format and the stop words, one word per line. A file name can be empty.
+type FileLoader struct {
+	SynonymFile  string
+	StopWordFile string
+}
+
+func NewFileLoader(synonymFile string, stopWordFile string) *FileLoader {
+	return &FileLoader{SynonymFile: synonymFile, StopWordFile: stopWordFile}
+}
+func (l *FileLoader) Load(ctx context.Context) (*Dictionary, error) {
+	rules, err := readFile(l.SynonymFile)
+	if err != nil {
+		return nil, err
+	}
+	stopWords, err := readFile(l.StopWordFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewDictionary(rules, stopWords)
+}
+
+func readFile(filename string) ([]string, error) {
+	if len(filename) == 0 {
+		return nil, nil
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadLines(file)
+}
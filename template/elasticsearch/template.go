@@ -0,0 +1,107 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	set "github.com/core-go/search/template"
+)
+
+// Build renders the json template, such as {"status": {"match": {"status": #{status}}}}, into the query, which is the map of the clauses of elasticsearch.SearchBuilder.
+// The parameters are bound as the typed values.
+func Build(obj map[string]interface{}, template set.Template) (map[string]interface{}, error) {
+	return BuildWithSubstitution(obj, template, nil)
+}
+func BuildWithSubstitution(obj map[string]interface{}, template set.Template, substitution *set.Substitution) (map[string]interface{}, error) {
+	text, values := set.BuildJSON(obj, template, substitution)
+	if len(strings.TrimSpace(text)) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	var query map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	if err := decoder.Decode(&query); err != nil {
+		return nil, fmt.Errorf("template %s: %w", template.Id, err)
+	}
+	return set.BindJSON(query, values).(map[string]interface{}), nil
+}
+
+type QueryBuilder struct {
+	Template     set.Template
+	Id           string
+	Source       set.Source
+	ModelType    *reflect.Type
+	Map          func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
+	Q            func(string) string
+	Substitution *set.Substitution
+}
+
+// UseQuery creates the query builder of the template, and returns its BuildQuery for elasticsearch.NewSearchBuilder.
+func UseQuery[F any](id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (func(F) map[string]interface{}, error) {
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(f F) map[string]interface{} {
+		return b.BuildQuery(f)
+	}, nil
+}
+
+// UseQueryWithError creates the query builder of the template, and returns its BuildQueryWithError for elasticsearch.NewSearchBuilderWithError, so the search returns the error of the rendered json.
+func UseQueryWithError[F any](id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (func(F) (map[string]interface{}, error), error) {
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(f F) (map[string]interface{}, error) {
+		return b.BuildQueryWithError(f)
+	}, nil
+}
+func NewQueryBuilder(id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (*QueryBuilder, error) {
+	t, ok := templates[id]
+	if !ok || t == nil {
+		return nil, errors.New("cannot get the template with id " + id)
+	}
+	var q func(string) string
+	if len(opts) > 0 {
+		q = opts[0]
+	}
+	return &QueryBuilder{Template: *t, Id: id, ModelType: modelType, Map: mp, Q: q, Substitution: set.NewSubstitution(modelType, nil)}, nil
+}
+
+// NewQueryBuilderWithSource creates the query builder which builds the queries by the current template of the source, such as set.Loader, which can be reloaded.
+func NewQueryBuilderWithSource(id string, source set.Source, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (*QueryBuilder, error) {
+	var templates map[string]*set.Template
+	if t, ok := source.Get(id); ok {
+		templates = map[string]*set.Template{id: t}
+	}
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b.Source = source
+	return b, nil
+}
+
+// BuildQuery builds the query of the filter. If the rendered json is invalid, the query has the clause which matches no document; use BuildQueryWithError to get the error.
+func (b *QueryBuilder) BuildQuery(f interface{}) map[string]interface{} {
+	query, err := b.BuildQueryWithError(f)
+	if err != nil {
+		return map[string]interface{}{"_invalid": map[string]interface{}{"bool": map[string]interface{}{"must_not": map[string]interface{}{"match_all": map[string]interface{}{}}}}}
+	}
+	return query
+}
+
+// BuildQueryWithError builds the query of the filter, and returns the error of the rendered json.
+func (b *QueryBuilder) BuildQueryWithError(f interface{}) (map[string]interface{}, error) {
+	obj := b.Map(f, b.ModelType)
+	if b.Q != nil {
+		if q, ok := obj["q"].(string); ok {
+			obj["q"] = b.Q(q)
+		}
+	}
+	return BuildWithSubstitution(obj, set.GetTemplate(b.Source, b.Id, b.Template), b.Substitution)
+}
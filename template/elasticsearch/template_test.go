@@ -0,0 +1,50 @@
+package elasticsearch
+
+import (
+	"reflect"
+	"testing"
+
+	set "github.com/core-go/search/template"
+)
+
+const queries = `<mapper>
+<select id="user">
+  {
+    <if test="name != null">"name": {"match_phrase_prefix": {"name": "x#{name}"}},</if>
+    <if test="status != null">"status": {"match": {"status": #{status}}}</if>
+  }
+</select>
+<select id="invalid">
+  {"name": #{name</select>
+</mapper>`
+
+func TestBuildEscapedText(t *testing.T) {
+	templates, err := set.BuildTemplates(queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, err := Build(map[string]interface{}{"name": `a", "b": "\`}, *templates["user"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	clause := query["name"].(map[string]interface{})["match_phrase_prefix"].(map[string]interface{})
+	if clause["name"] != `xa", "b": "\` || len(clause) != 1 {
+		t.Errorf("unexpected clause %v", clause)
+	}
+}
+
+func TestBuildQueryWithError(t *testing.T) {
+	templates, err := set.BuildTemplates(queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewQueryBuilder("invalid", templates, nil, func(f interface{}, _ *reflect.Type, _ ...func(string, reflect.Type) string) map[string]interface{} {
+		return f.(map[string]interface{})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.BuildQueryWithError(map[string]interface{}{"name": "a"}); err == nil {
+		t.Error("expected the error of the invalid json")
+	}
+}
@@ -0,0 +1,562 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Expression is the parsed test of the conditions, such as "limit > 100", "status in ('A', 'B')", "len(ids) > 0 && includeArchived" or "!empty(q)".
+// The operators are ||, &&, !, ==, !=, >, >=, <, <=, in and not in, with the words or, and, not, eq, ne, gt, gte, lt and lte, which need not be escaped in XML.
+// The functions are len, empty and in. The properties are the dotted paths, which are resolved by valueOf.
+type Expression interface {
+	Eval(valueOf func(string) interface{}) interface{}
+}
+
+var expressions sync.Map
+
+// CompileExpression parses the expression, and caches it by the text, so the templates which are loaded from json or yaml can be evaluated by the test.
+func CompileExpression(test string) (Expression, error) {
+	if e, ok := expressions.Load(test); ok {
+		return e.(Expression), nil
+	}
+	e, err := ParseExpression(test)
+	if err != nil {
+		return nil, err
+	}
+	expressions.Store(test, e)
+	return e, nil
+}
+
+// Test evaluates the expression, and returns false if it cannot be parsed.
+func Test(test string, valueOf func(string) interface{}) bool {
+	e, err := CompileExpression(test)
+	if err != nil {
+		return false
+	}
+	return IsTrue(e.Eval(valueOf))
+}
+
+// ParseExpression parses the expression.
+func ParseExpression(s string) (Expression, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &expressionParser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.tokens[p.i].text, p.tokens[p.i].pos+1)
+	}
+	return e, nil
+}
+
+// IsTrue converts the value to bool: nil, false, 0, the empty string and the empty slice or map are false.
+func IsTrue(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	vo := reflect.Indirect(reflect.ValueOf(v))
+	switch vo.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Bool:
+		return vo.Bool()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return vo.Len() > 0
+	}
+	if f, ok := toFloat(vo); ok {
+		return f != 0
+	}
+	return true
+}
+
+// ExpressionProperties parses the expression, and returns the paths of its properties, such as ["ids", "includeArchived"] of "len(ids) > 0 && includeArchived".
+func ExpressionProperties(test string) ([]string, error) {
+	e, err := CompileExpression(test)
+	if err != nil {
+		return nil, err
+	}
+	return properties(e, make([]string, 0)), nil
+}
+func properties(e Expression, paths []string) []string {
+	switch x := e.(type) {
+	case property:
+		return append(paths, x.path)
+	case not:
+		return properties(x.e, paths)
+	case binary:
+		return properties(x.right, properties(x.left, paths))
+	case call:
+		for _, arg := range x.args {
+			paths = properties(arg, paths)
+		}
+	case list:
+		for _, item := range x.items {
+			paths = properties(item, paths)
+		}
+	}
+	return paths
+}
+
+const (
+	tokenOperator = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+var operators = []string{"||", "&&", "==", "!=", ">=", "<=", ">", "<", "!", "(", ")", ",", "-"}
+var words = map[string]string{"or": "||", "and": "&&", "not": "!", "eq": "==", "ne": "!=", "gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+
+func tokenize(s string) ([]token, error) {
+	tokens := make([]token, 0)
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		if r == '\'' || r == '"' {
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(s) && rune(s[j]) != r; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("unterminated string at %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: i})
+			i = j + 1
+			continue
+		}
+		if r >= '0' && r <= '9' {
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[i:j], pos: i})
+			i = j
+			continue
+		}
+		if unicode.IsLetter(r) || r == '_' {
+			j := i
+			for j < len(s) {
+				c, n := utf8.DecodeRuneInString(s[j:])
+				if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '.' {
+					break
+				}
+				j += n
+			}
+			text := s[i:j]
+			if op, ok := words[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+			} else {
+				tokens = append(tokens, token{kind: tokenIdent, text: text, pos: i})
+			}
+			i = j
+			continue
+		}
+		found := false
+		for _, op := range operators {
+			if strings.HasPrefix(s[i:], op) {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+				i += len(op)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unexpected %q at %d", r, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+type expressionParser struct {
+	tokens []token
+	i      int
+}
+
+func (p *expressionParser) peek(text string) bool {
+	return p.i < len(p.tokens) && p.tokens[p.i].kind == tokenOperator && p.tokens[p.i].text == text
+}
+func (p *expressionParser) peekIdent(text string) bool {
+	return p.i < len(p.tokens) && p.tokens[p.i].kind == tokenIdent && strings.EqualFold(p.tokens[p.i].text, text)
+}
+func (p *expressionParser) expect(text string) error {
+	if !p.peek(text) {
+		if p.i < len(p.tokens) {
+			return fmt.Errorf("expected %q at %d", text, p.tokens[p.i].pos+1)
+		}
+		return fmt.Errorf("expected %q at the end", text)
+	}
+	p.i++
+	return nil
+}
+func (p *expressionParser) parseOr() (Expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("||") {
+		p.i++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+func (p *expressionParser) parseAnd() (Expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek("&&") {
+		p.i++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+func (p *expressionParser) parseNot() (Expression, error) {
+	if p.peek("!") {
+		p.i++
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return not{e: e}, nil
+	}
+	return p.parseComparison()
+}
+func (p *expressionParser) parseComparison() (Expression, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if p.peek(op) {
+			p.i++
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return binary{op: op, left: left, right: right}, nil
+		}
+	}
+	negate := false
+	if p.peek("!") && p.i+1 < len(p.tokens) && p.tokens[p.i+1].kind == tokenIdent && strings.EqualFold(p.tokens[p.i+1].text, "in") {
+		negate = true
+		p.i++
+	}
+	if p.peekIdent("in") {
+		p.i++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		var e Expression = call{name: "in", args: []Expression{left, right}}
+		if negate {
+			e = not{e: e}
+		}
+		return e, nil
+	}
+	return left, nil
+}
+func (p *expressionParser) parsePrimary() (Expression, error) {
+	if p.i >= len(p.tokens) {
+		return nil, errors.New("unexpected end")
+	}
+	t := p.tokens[p.i]
+	p.i++
+	switch t.kind {
+	case tokenString:
+		return literal{value: t.text}, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos+1)
+		}
+		return literal{value: f}, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		case "null", "nil":
+			return literal{}, nil
+		}
+		if p.peek("(") {
+			name := strings.ToLower(t.text)
+			if name != "len" && name != "empty" && name != "in" {
+				return nil, fmt.Errorf("unknown function %q at %d", t.text, t.pos+1)
+			}
+			p.i++
+			args, err := p.parseList()
+			if err != nil {
+				return nil, err
+			}
+			if (name == "len" || name == "empty") && len(args) != 1 || name == "in" && len(args) < 2 {
+				return nil, fmt.Errorf("invalid number of arguments of %s at %d", name, t.pos+1)
+			}
+			if name == "in" {
+				args = []Expression{args[0], list{items: args[1:]}}
+			}
+			return call{name: name, args: args}, nil
+		}
+		return property{path: t.text}, nil
+	}
+	switch t.text {
+	case "(":
+		items, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 1 {
+			return items[0], nil
+		}
+		return list{items: items}, nil
+	case "-":
+		if p.i < len(p.tokens) && p.tokens[p.i].kind == tokenNumber {
+			e, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return literal{value: -e.(literal).value.(float64)}, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos+1)
+}
+
+// parseList parses the expressions separated by commas until the closing parenthesis.
+func (p *expressionParser) parseList() ([]Expression, error) {
+	items := make([]Expression, 0)
+	if p.peek(")") {
+		p.i++
+		return items, nil
+	}
+	for {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+		if p.peek(",") {
+			p.i++
+			continue
+		}
+		return items, p.expect(")")
+	}
+}
+
+type literal struct {
+	value interface{}
+}
+type property struct {
+	path string
+}
+type not struct {
+	e Expression
+}
+type binary struct {
+	op    string
+	left  Expression
+	right Expression
+}
+type call struct {
+	name string
+	args []Expression
+}
+type list struct {
+	items []Expression
+}
+
+func (e literal) Eval(valueOf func(string) interface{}) interface{} {
+	return e.value
+}
+func (e property) Eval(valueOf func(string) interface{}) interface{} {
+	return valueOf(e.path)
+}
+func (e not) Eval(valueOf func(string) interface{}) interface{} {
+	return !IsTrue(e.e.Eval(valueOf))
+}
+func (e list) Eval(valueOf func(string) interface{}) interface{} {
+	values := make([]interface{}, len(e.items))
+	for i, item := range e.items {
+		values[i] = item.Eval(valueOf)
+	}
+	return values
+}
+func (e binary) Eval(valueOf func(string) interface{}) interface{} {
+	switch e.op {
+	case "||":
+		return IsTrue(e.left.Eval(valueOf)) || IsTrue(e.right.Eval(valueOf))
+	case "&&":
+		return IsTrue(e.left.Eval(valueOf)) && IsTrue(e.right.Eval(valueOf))
+	}
+	a, b := e.left.Eval(valueOf), e.right.Eval(valueOf)
+	switch e.op {
+	case "==":
+		return equal(a, b)
+	case "!=":
+		return !equal(a, b)
+	}
+	c, ok := compare(a, b)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	default:
+		return c <= 0
+	}
+}
+func (e call) Eval(valueOf func(string) interface{}) interface{} {
+	v := e.args[0].Eval(valueOf)
+	switch e.name {
+	case "len":
+		return float64(length(v))
+	case "empty":
+		return isNil(v) || length(v) == 0
+	}
+	values := e.args[1].Eval(valueOf)
+	vo := reflect.Indirect(reflect.ValueOf(values))
+	if vo.Kind() != reflect.Slice && vo.Kind() != reflect.Array {
+		return equal(v, values)
+	}
+	for i := 0; i < vo.Len(); i++ {
+		if equal(v, vo.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	vo := reflect.ValueOf(v)
+	switch vo.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return vo.IsNil()
+	}
+	return false
+}
+func length(v interface{}) int {
+	vo := reflect.Indirect(reflect.ValueOf(v))
+	switch vo.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(vo.String())
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return vo.Len()
+	}
+	return 0
+}
+
+// equal compares the numbers by the values, the times by the instants and the others by the texts, so 1 equals 1.0 and '1'.
+func equal(a interface{}, b interface{}) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) && isNil(b)
+	}
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return fmt.Sprintf("%v", reflect.Indirect(reflect.ValueOf(a)).Interface()) == fmt.Sprintf("%v", reflect.Indirect(reflect.ValueOf(b)).Interface())
+}
+
+// compare compares the numbers, the times and the strings. The string is compared with the number as a number if it can be parsed,
+// and the strings are compared as the numbers if both can be parsed, such as the values of the query string, so '20' < '100'.
+func compare(a interface{}, b interface{}) (int, bool) {
+	if isNil(a) || isNil(b) {
+		return 0, false
+	}
+	va, vb := reflect.Indirect(reflect.ValueOf(a)), reflect.Indirect(reflect.ValueOf(b))
+	if ta, ok := va.Interface().(time.Time); ok {
+		if tb, ok := vb.Interface().(time.Time); ok {
+			return compareFloat(float64(ta.UnixNano()), float64(tb.UnixNano())), true
+		}
+		return 0, false
+	}
+	fa, okA := toFloat(va)
+	fb, okB := toFloat(vb)
+	if okA && okB {
+		return compareFloat(fa, fb), true
+	}
+	if !okA && va.Kind() == reflect.String {
+		fa, okA = parseNumber(va.String())
+	}
+	if !okB && vb.Kind() == reflect.String {
+		fb, okB = parseNumber(vb.String())
+	}
+	if okA && okB {
+		return compareFloat(fa, fb), true
+	}
+	if va.Kind() == reflect.String && vb.Kind() == reflect.String {
+		return strings.Compare(va.String(), vb.String()), true
+	}
+	return 0, false
+}
+
+// parseNumber parses the number of the string, such as "100" or "-1.5", but not "NaN" or "Inf".
+func parseNumber(s string) (float64, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+	return f, true
+}
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+func compareFloat(a float64, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+package template
+
+import (
+	"strings"
+	"testing"
+)
+
+func valuesOf(m map[string]interface{}) func(string) interface{} {
+	return func(path string) interface{} {
+		return m[path]
+	}
+}
+
+func TestEvalExpression(t *testing.T) {
+	var nilIds []int64
+	params := map[string]interface{}{
+		"t":        true,
+		"f":        false,
+		"limit":    20,
+		"max":      "20",
+		"price":    9.5,
+		"status":   "A",
+		"q":        "",
+		"name":     "tom",
+		"ids":      []int64{1, 2, 3},
+		"nilIds":   nilIds,
+		"statuses": []string{"A", "B"},
+		"tags":     map[string]string{},
+	}
+	tests := []struct {
+		test string
+		want bool
+	}{
+		// precedence
+		{"t || f && f", true},
+		{"(t || f) && f", false},
+		{"f && f || t", true},
+		{"!f && t", true},
+		{"!t || t", true},
+		{"!(t || f)", false},
+		{"not status == 'B'", true},
+		{"t or f and f", true},
+		{"limit > 10 && status == 'A' || f", true},
+		{"limit gt 10 and status eq 'B'", false},
+		// in and not in
+		{"status in ('A', 'B')", true},
+		{"status in ('C', 'D')", false},
+		{"status not in ('C', 'D')", true},
+		{"status !in ('A')", false},
+		{"status in ('A')", true},
+		{"status in statuses", true},
+		{"'C' in statuses", false},
+		{"2 in ids", true},
+		{"in(limit, 10, 20)", true},
+		{"in(limit, 10)", false},
+		// len and empty
+		{"len(ids) == 3", true},
+		{"len(ids) > 3", false},
+		{"len(name) == 3", true},
+		{"len(nilIds) == 0", true},
+		{"len(unknown) == 0", true},
+		{"empty(q)", true},
+		{"!empty(name)", true},
+		{"empty(ids)", false},
+		{"empty(nilIds)", true},
+		{"empty(tags)", true},
+		{"empty(unknown)", true},
+		{"EMPTY(q)", true},
+		// null
+		{"unknown == null", true},
+		{"unknown != nil", false},
+		{"status == null", false},
+		{"status != null", true},
+		{"nilIds == null", true},
+		{"null == null", true},
+		{"limit > null", false},
+		{"limit < null", false},
+		{"unknown > 0", false},
+		{"unknown <= 0", false},
+		{"unknown", false},
+		// numbers and strings
+		{"limit > 10", true},
+		{"limit > 100", false},
+		{"limit > '100'", false},
+		{"limit < '100'", true},
+		{"limit == '20'", true},
+		{"max > '100'", false},
+		{"max > 100", false},
+		{"max >= 20", true},
+		{"max == 20", true},
+		{"'9' < '10'", true},
+		{"'b' > 'a'", true},
+		{"'b' > 'ab'", true},
+		{"name == 'tom'", true},
+		{"name > 100", false},
+		{"name < 100", false},
+		{"'NaN' == 'nan'", false},
+		{"price > 9", true},
+		{"price <= -1", false},
+		{"limit >= 20 && limit lte 20", true},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpression(tt.test)
+		if err != nil {
+			t.Errorf("ParseExpression(%q) error: %v", tt.test, err)
+			continue
+		}
+		if got := IsTrue(e.Eval(valuesOf(params))); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.test, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpressionError(t *testing.T) {
+	tests := []struct {
+		test string
+		want string
+	}{
+		{"status == 'A", "unterminated string at 11"},
+		{"limit # 10", "unexpected '#' at 7"},
+		{"limit > 10 10", "unexpected \"10\" at 12"},
+		{"limit > 1.2.3", "invalid number \"1.2.3\" at 9"},
+		{"size(ids) > 0", "unknown function \"size\" at 1"},
+		{"len(a, b)", "invalid number of arguments of len at 1"},
+		{"in(a)", "invalid number of arguments of in at 1"},
+		{"(a || b", "expected \")\" at the end"},
+		{"status in ('A' 'B')", "expected \")\" at 16"},
+		{"limit >", "unexpected end"},
+		{"a && )", "unexpected \")\" at 6"},
+	}
+	for _, tt := range tests {
+		_, err := ParseExpression(tt.test)
+		if err == nil {
+			t.Errorf("ParseExpression(%q) error = nil, want %q", tt.test, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseExpression(%q) error = %q, want %q", tt.test, err.Error(), tt.want)
+		}
+	}
+}
+
+func TestTestInvalidExpression(t *testing.T) {
+	if Test("limit >", valuesOf(map[string]interface{}{"limit": 1})) {
+		t.Errorf("Test of the invalid expression = true, want false")
+	}
+}
+
+func TestExpressionProperties(t *testing.T) {
+	paths, err := ExpressionProperties("len(ids) > 0 && (status in ('A', q) || !empty(user.name))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"ids": true, "status": true, "q": true, "user.name": true}
+	if len(paths) != len(want) {
+		t.Fatalf("ExpressionProperties = %v, want %d properties", paths, len(want))
+	}
+	for _, p := range paths {
+		if !want[p] {
+			t.Errorf("ExpressionProperties = %v, unexpected %q", paths, p)
+		}
+	}
+}
@@ -25,6 +25,11 @@ const (
 )
 
 func Merge(obj map[string]interface{}, format set.StringFormat, skipArray bool, separator string, prefix string, suffix string) string {
+	return MergeWithSubstitution(obj, format, skipArray, separator, prefix, suffix, nil)
+}
+
+// MergeWithSubstitution merges the format, and writes the text parameters by the substitution, which checks the identifiers, the enums and the sorts.
+func MergeWithSubstitution(obj map[string]interface{}, format set.StringFormat, skipArray bool, separator string, prefix string, suffix string, substitution *set.Substitution) string {
 	results := make([]string, 0)
 	parameters := format.Parameters
 	if len(separator) > 0 && len(parameters) == 1 {
@@ -35,7 +40,7 @@ func Merge(obj map[string]interface{}, format set.StringFormat, skipArray bool,
 			if l > 0 {
 				strs := make([]string, 0)
 				for i := 0; i < l; i++ {
-					ts := Merge(obj, format, true, "", "", "")
+					ts := MergeWithSubstitution(obj, format, true, "", "", "", substitution)
 					strs = append(strs, ts)
 				}
 				results = append(results, strings.Join(strs, separator))
@@ -49,8 +54,10 @@ func Merge(obj map[string]interface{}, format set.StringFormat, skipArray bool,
 		results = append(results, texts[i])
 		p := set.ValueOf(obj, parameters[i].Name)
 		if p != nil {
-			if parameters[i].Type == set.ParamText {
-				results = append(results, fmt.Sprintf("%v", p))
+			if parameters[i].Type != "param" {
+				if text, ok := substitution.Text(parameters[i], p); ok {
+					results = append(results, text)
+				}
 			} else {
 				vo := reflect.Indirect(reflect.ValueOf(p))
 				if vo.Kind() == reflect.Slice {
@@ -82,11 +89,14 @@ func Merge(obj map[string]interface{}, format set.StringFormat, skipArray bool,
 	return prefix + strings.Join(results, "") + suffix
 }
 func Build(obj map[string]interface{}, template set.Template) string {
+	return BuildWithSubstitution(obj, template, nil)
+}
+func BuildWithSubstitution(obj map[string]interface{}, template set.Template, substitution *set.Substitution) string {
 	results := make([]string, 0)
 	renderNodes := set.RenderTemplateNodes(obj, template.Templates)
 	for _, sub := range renderNodes {
 		skipArray := sub.Array == "skip"
-		s := Merge(obj, sub.Format, skipArray, sub.Separator, sub.Prefix, sub.Suffix)
+		s := MergeWithSubstitution(obj, sub.Format, skipArray, sub.Separator, sub.Prefix, sub.Suffix, substitution)
 		if len(s) > 0 {
 			results = append(results, s)
 		}
@@ -95,11 +105,14 @@ func Build(obj map[string]interface{}, template set.Template) string {
 }
 
 type QueryBuilder struct {
-	Template  set.Template
-	ModelType *reflect.Type
-	Map       func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
-	BuildSort func(string, reflect.Type) string
-	Q         func(string) string
+	Template     set.Template
+	Id           string
+	Source       set.Source
+	ModelType    *reflect.Type
+	Map          func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
+	BuildSort    func(string, reflect.Type) string
+	Q            func(string) string
+	Substitution *set.Substitution
 }
 type Builder interface {
 	BuildQuery(f interface{}) string
@@ -142,7 +155,7 @@ func (b *QueryBuilder) BuildQuery(f interface{}) string {
 			}
 		}
 	}
-	return Build(m, b.Template)
+	return BuildWithSubstitution(m, set.GetTemplate(b.Source, b.Id, b.Template), b.Substitution)
 }
 func NewQueryBuilder(id string, m map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, buildSort func(string, reflect.Type) string, opts ...func(string) string) (Builder, error) {
 	t, ok := m[id]
@@ -155,7 +168,7 @@ func NewQueryBuilder(id string, m map[string]*set.Template, modelType *reflect.T
 	} else {
 		q = set.Q
 	}
-	return &QueryBuilder{Template: *t, ModelType: modelType, Map: mp, BuildSort: buildSort, Q: q}, nil
+	return &QueryBuilder{Template: *t, Id: id, ModelType: modelType, Map: mp, BuildSort: buildSort, Q: q, Substitution: set.NewSubstitution(modelType, buildSort, func(s *set.Substitution) { s.Quote = set.QuoteBacktick })}, nil
 }
 
 func join(strs ...string) string {
@@ -345,3 +358,18 @@ func RoundRat(rat big.Rat, scale int8) string {
 	res := strconv.Itoa(intNum) + "." + strconv.Itoa(e)
 	return res
 }
+
+// NewQueryBuilderWithSource creates the query builder which builds the queries by the current template of the source, such as set.Loader, which can be reloaded.
+func NewQueryBuilderWithSource(id string, source set.Source, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, buildSort func(string, reflect.Type) string, opts ...func(string) string) (Builder, error) {
+	var templates map[string]*set.Template
+	if t, ok := source.Get(id); ok {
+		templates = map[string]*set.Template{id: t}
+	}
+	b, err := NewQueryBuilder(id, templates, modelType, mp, buildSort, opts...)
+	if err != nil {
+		return nil, err
+	}
+	qb := b.(*QueryBuilder)
+	qb.Source = source
+	return qb, nil
+}
@@ -0,0 +1,121 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResolveIncludes replaces the includes of the templates by the nodes of the sql fragments, such as <include refid="columns"/>.
+// The properties of include replace ${name} of the fragment. The fragments can include the other fragments, but not cyclically.
+// A text parameter which has no type and is not replaced by the properties, such as ${name}, is an error, because it is never written.
+func ResolveIncludes(templates map[string]*Template, fragments map[string]*Template) error {
+	ids := make([]string, 0, len(templates))
+	for id := range templates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		t := templates[id]
+		if t == nil {
+			continue
+		}
+		if hasInclude(t.Templates) {
+			nodes, err := resolveNodes(t.Templates, fragments, nil, nil)
+			if err != nil {
+				return fmt.Errorf("template %s: %w", id, err)
+			}
+			t.Templates = nodes
+			t.Text = strings.Join(collectTexts(nodes), " ")
+		}
+		if err := checkTextParameters(t.Templates); err != nil {
+			return fmt.Errorf("template %s: %w", id, err)
+		}
+	}
+	return nil
+}
+func resolveNodes(nodes []TemplateNode, fragments map[string]*Template, stack []string, properties map[string]string) ([]TemplateNode, error) {
+	results := make([]TemplateNode, 0, len(nodes))
+	for _, n := range nodes {
+		n = replaceProperties(n, properties)
+		if n.Type == TypeInclude {
+			for _, id := range stack {
+				if id == n.RefId {
+					return nil, fmt.Errorf("cyclic include %s -> %s", strings.Join(stack, " -> "), n.RefId)
+				}
+			}
+			f, ok := fragments[n.RefId]
+			if !ok || f == nil {
+				return nil, fmt.Errorf("cannot find the sql fragment %q", n.RefId)
+			}
+			merged := make(map[string]string)
+			for k, v := range properties {
+				merged[k] = v
+			}
+			for k, v := range n.Properties {
+				merged[k] = v
+			}
+			path := append(append(make([]string, 0, len(stack)+1), stack...), n.RefId)
+			sub, err := resolveNodes(f.Templates, fragments, path, merged)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, sub...)
+			continue
+		}
+		if n.Children != nil {
+			children, err := resolveNodes(n.Children, fragments, stack, properties)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = children
+		}
+		results = append(results, n)
+	}
+	return results, nil
+}
+
+// replaceProperties replaces ${name} of the text and the attributes of the node by the properties of include.
+func replaceProperties(n TemplateNode, properties map[string]string) TemplateNode {
+	if len(properties) == 0 {
+		return n
+	}
+	pairs := make([]string, 0, len(properties)*2)
+	for k, v := range properties {
+		pairs = append(pairs, "${"+k+"}", v)
+	}
+	r := strings.NewReplacer(pairs...)
+	if text := r.Replace(n.Text); text != n.Text {
+		n.Text = text
+		n.Format = buildFormat(text)
+	}
+	for _, s := range []*string{&n.Property, &n.Value, &n.Array, &n.Separator, &n.Prefix, &n.Suffix, &n.Test, &n.PrefixOverrides, &n.SuffixOverrides, &n.Collection, &n.Item, &n.Index, &n.Open, &n.Close, &n.RefId} {
+		*s = r.Replace(*s)
+	}
+	if len(n.Properties) > 0 {
+		values := make(map[string]string, len(n.Properties))
+		for k, v := range n.Properties {
+			values[k] = r.Replace(v)
+		}
+		n.Properties = values
+	}
+	return n
+}
+func hasInclude(nodes []TemplateNode) bool {
+	for _, n := range nodes {
+		if n.Type == TypeInclude || hasInclude(n.Children) {
+			return true
+		}
+	}
+	return false
+}
+func collectTexts(nodes []TemplateNode) []string {
+	texts := make([]string, 0)
+	for _, n := range nodes {
+		if len(n.Text) > 0 {
+			texts = append(texts, n.Text)
+		}
+		texts = append(texts, collectTexts(n.Children)...)
+	}
+	return texts
+}
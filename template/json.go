@@ -0,0 +1,187 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// marker is the character which encloses the index of the parameter in the json text, such as "0". It is in the private use area, so it is not in the templates.
+const marker = '\uE000'
+
+// BuildJSON renders the json template, such as {"status": #{status}}, into the json text, in which the parameters are the markers, and returns the values of the parameters.
+// After the json is decoded, the markers are replaced by the values by BindJSON, so the values are typed, such as the numbers, the times and the arrays, but never spliced into the text.
+// The text parameters, such as "${field:identifier}", are written by the substitution. The commas which are left by the conditions, such as the trailing commas, are removed.
+func BuildJSON(obj map[string]interface{}, template Template, substitution *Substitution) (string, []interface{}) {
+	var sb strings.Builder
+	values := make([]interface{}, 0)
+	for _, n := range RenderTemplateNodes(obj, template.Templates) {
+		texts, parameters := n.Format.Texts, n.Format.Parameters
+		if len(texts) != len(parameters)+1 {
+			continue
+		}
+		sb.WriteString(n.Prefix)
+		for i, p := range parameters {
+			sb.WriteString(texts[i])
+			v := ValueOf(obj, p.Name)
+			if p.Type == "param" {
+				sb.WriteRune(marker)
+				sb.WriteString(strconv.Itoa(len(values)))
+				sb.WriteRune(marker)
+				values = append(values, v)
+			} else if v != nil {
+				if text, ok := substitution.Text(p, v); ok {
+					sb.WriteString(text)
+				}
+			}
+		}
+		sb.WriteString(texts[len(texts)-1])
+		sb.WriteString(n.Suffix)
+	}
+	return cleanJSON(sb.String(), values), values
+}
+
+// cleanJSON quotes the markers which are not in the strings, so they are the strings which are replaced by the values,
+// writes the JSON-escaped text of the values of the markers in the strings, such as {"$regex": "^#{name}"},
+// and removes the commas which are not between the values, such as {"a": 1,} or [, 1].
+func cleanJSON(s string, values []interface{}) string {
+	out := make([]rune, 0, len(s))
+	inString, escaped, inMarker, inText := false, false, false, false
+	index := make([]rune, 0)
+	for _, c := range s {
+		if inString && c == marker {
+			if inText {
+				out = append(out, []rune(escapeJSON(textOf(string(index), values)))...)
+				index = index[:0]
+			}
+			inText = !inText
+			continue
+		}
+		if inText {
+			index = append(index, c)
+			continue
+		}
+		if inString {
+			out = append(out, c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case marker:
+			if !inMarker {
+				out = append(out, '"')
+			}
+			out = append(out, c)
+			if inMarker {
+				out = append(out, '"')
+			}
+			inMarker = !inMarker
+			continue
+		case ',':
+			if last := lastRune(out); last == 0 || last == '{' || last == '[' || last == ',' || last == ':' {
+				continue
+			}
+		case '}', ']':
+			if i := lastIndex(out); i >= 0 && out[i] == ',' {
+				out = append(out[:i], out[i+1:]...)
+			}
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
+func lastIndex(s []rune) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] != ' ' && s[i] != '\t' && s[i] != '\r' && s[i] != '\n' {
+			return i
+		}
+	}
+	return -1
+}
+func lastRune(s []rune) rune {
+	if i := lastIndex(s); i >= 0 {
+		return s[i]
+	}
+	return 0
+}
+
+// BindJSON replaces the markers of the decoded json, which are the maps, the slices and the strings, by the values of the parameters.
+func BindJSON(v interface{}, values []interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, sub := range x {
+			m[BindText(k, values)] = BindJSON(sub, values)
+		}
+		return m
+	case []interface{}:
+		for i, sub := range x {
+			x[i] = BindJSON(sub, values)
+		}
+		return x
+	case string:
+		return BindString(x, values)
+	}
+	return v
+}
+
+// BindString returns the value if the string is a marker, or the string in which the markers are replaced by the text of the values, such as {"$regex": "^#{name}"}.
+func BindString(s string, values []interface{}) interface{} {
+	m := string(marker)
+	if len(s) > 2*len(m) && strings.HasPrefix(s, m) && strings.HasSuffix(s, m) && strings.Count(s, m) == 2 {
+		if i, err := strconv.Atoi(s[len(m) : len(s)-len(m)]); err == nil && i >= 0 && i < len(values) {
+			return values[i]
+		}
+	}
+	return BindText(s, values)
+}
+
+// BindText replaces the markers in the string by the text of the values.
+// BuildJSON writes the JSON-escaped text of the markers in the strings, so the decoded strings have no markers.
+func BindText(s string, values []interface{}) string {
+	if !strings.ContainsRune(s, marker) {
+		return s
+	}
+	parts := strings.Split(s, string(marker))
+	var sb strings.Builder
+	for i, part := range parts {
+		if i%2 == 0 {
+			sb.WriteString(part)
+			continue
+		}
+		sb.WriteString(textOf(part, values))
+	}
+	return sb.String()
+}
+func textOf(index string, values []interface{}) string {
+	if k, err := strconv.Atoi(index); err == nil && k >= 0 && k < len(values) && values[k] != nil {
+		return toText(values[k])
+	}
+	return ""
+}
+
+// escapeJSON escapes the text, so it is the content of a json string.
+func escapeJSON(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b[1 : len(b)-1])
+}
+func toText(v interface{}) string {
+	vo := reflect.ValueOf(v)
+	if vo.Kind() == reflect.Ptr {
+		if vo.IsNil() {
+			return ""
+		}
+		vo = vo.Elem()
+	}
+	return fmt.Sprintf("%v", vo.Interface())
+}
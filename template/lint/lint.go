@@ -0,0 +1,193 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	t "github.com/core-go/search/template"
+)
+
+// Issue is a problem of the template, such as an unknown property or an array which is not in parentheses.
+type Issue struct {
+	Template string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return i.Template + ": " + i.Message
+}
+
+// Linter checks the properties and the parameters of the templates against the schema of the filter.
+// If Unused is true, the fields of the filter which are not used by the template are reported.
+// If Render is true, the template is rendered by the sample filters, to check the sql, such as an empty "in ()" or a dangling "where".
+type Linter struct {
+	Schema Schema
+	Unused bool
+	Render bool
+	Param  func(int) string
+}
+
+func NewLinter(schema Schema, options ...func(*Linter)) *Linter {
+	l := &Linter{Schema: schema, Param: func(i int) string { return fmt.Sprintf("$%d", i) }}
+	for _, opt := range options {
+		opt(l)
+	}
+	return l
+}
+
+// Lint checks the templates of the ids, or all the templates by the sorted ids if there is no id.
+func Lint(templates map[string]*t.Template, schema Schema, ids ...string) []Issue {
+	return NewLinter(schema).LintTemplates(templates, ids...)
+}
+func (l *Linter) LintTemplates(templates map[string]*t.Template, ids ...string) []Issue {
+	if len(ids) == 0 {
+		for id := range templates {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	issues := make([]Issue, 0)
+	for _, id := range ids {
+		template, ok := templates[id]
+		if !ok || template == nil {
+			issues = append(issues, Issue{Template: id, Message: "cannot find the template"})
+			continue
+		}
+		issues = append(issues, l.Lint(id, *template)...)
+	}
+	return issues
+}
+func (l *Linter) Lint(id string, template t.Template) []Issue {
+	c := &checker{schema: l.Schema, used: make(map[string]bool), messages: make([]string, 0)}
+	c.nodes(template.Templates, make(map[string]bool))
+	if l.Unused {
+		names := make([]string, 0, len(l.Schema))
+		for name, f := range l.Schema {
+			if !f.Filter && !c.used[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			c.add("the field %q of the filter is not used", name)
+		}
+	}
+	if l.Render {
+		for _, m := range Render(template, l.Schema, l.Param) {
+			c.add("%s", m)
+		}
+	}
+	issues := make([]Issue, len(c.messages))
+	for i, m := range c.messages {
+		issues[i] = Issue{Template: id, Message: m}
+	}
+	return issues
+}
+
+type checker struct {
+	schema   Schema
+	used     map[string]bool
+	messages []string
+}
+
+func (c *checker) add(format string, args ...interface{}) {
+	m := fmt.Sprintf(format, args...)
+	for _, s := range c.messages {
+		if s == m {
+			return
+		}
+	}
+	c.messages = append(c.messages, m)
+}
+
+// ref checks the path of the property, such as "status" or "createdDate.min". The item and the index of foreach are not checked.
+func (c *checker) ref(path string, aliases map[string]bool) (Field, string, bool) {
+	name, sub := path, ""
+	if i := strings.Index(path, "."); i >= 0 {
+		name, sub = path[:i], path[i+1:]
+	}
+	if aliases[name] {
+		return Field{}, "", false
+	}
+	f, ok := c.schema[name]
+	if !ok {
+		c.add("unknown property %q", path)
+		return f, sub, false
+	}
+	c.used[name] = true
+	if f.Kind == KindRange && len(sub) > 0 && !isBound(sub) {
+		c.add("unknown property %q of the range %q, which has %s", sub, name, strings.Join(bounds, ", "))
+		return f, sub, false
+	}
+	return f, sub, true
+}
+func isBound(s string) bool {
+	for _, b := range bounds {
+		if s == b {
+			return true
+		}
+	}
+	return false
+}
+func (c *checker) nodes(nodes []t.TemplateNode, aliases map[string]bool) {
+	for _, n := range nodes {
+		if len(n.Property) > 0 {
+			c.ref(n.Property, aliases)
+		}
+		if n.Type == t.TypeIf {
+			paths, err := t.ExpressionProperties(n.Test)
+			if err != nil {
+				c.add("invalid test %q: %s", n.Test, err.Error())
+			}
+			for _, p := range paths {
+				c.ref(p, aliases)
+			}
+		}
+		children := aliases
+		if n.Type == t.TypeForeach {
+			if f, sub, ok := c.ref(n.Collection, aliases); ok && len(sub) == 0 && f.Kind != KindArray && !strings.HasPrefix(f.Type, "map[") {
+				c.add("the collection %q of foreach is not an array", n.Collection)
+			}
+			children = make(map[string]bool, len(aliases)+2)
+			for k := range aliases {
+				children[k] = true
+			}
+			for _, k := range []string{n.Item, n.Index} {
+				if len(k) > 0 {
+					children[k] = true
+				}
+			}
+		}
+		c.parameters(n, aliases)
+		c.nodes(n.Children, children)
+	}
+}
+
+// parameters checks the parameters of the text: a range must be used by its bounds, such as min, max or top, and an array must be in parentheses,
+// such as "id in (#{ids})", or be merged by the separator or array="skip". A text parameter must have a type, because ${name} is never written.
+func (c *checker) parameters(n t.TemplateNode, aliases map[string]bool) {
+	for i, p := range n.Format.Parameters {
+		if p.Type == t.ParamText {
+			c.add("the text parameter %q has no type, such as ${%s:identifier}, ${%s:sort} or ${%s:unsafe}, so it is not written", p.Name, p.Name, p.Name, p.Name)
+		}
+		f, sub, ok := c.ref(p.Name, aliases)
+		if !ok || len(sub) > 0 {
+			continue
+		}
+		switch f.Kind {
+		case KindRange:
+			c.add("the range %q must be used by its bounds, such as #{%s.min}", p.Name, p.Name)
+		case KindArray:
+			if p.Type != "param" {
+				c.add("the array %q is written as the text, use #{%s} instead of ${%s}", p.Name, p.Name, p.Name)
+			} else if len(n.Separator) == 0 && n.Array != "skip" && (i >= len(n.Format.Texts) || !strings.HasSuffix(strings.TrimSpace(n.Format.Texts[i]), "(")) {
+				c.add("the array %q must be in parentheses, such as in (#{%s}), or be used by foreach", p.Name, p.Name)
+			}
+		default:
+			if len(n.Separator) > 0 && len(n.Format.Parameters) == 1 {
+				c.add("the separator needs an array, but %q is not an array", p.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	t "github.com/core-go/search/template"
+	"github.com/core-go/search/template/sql"
+)
+
+var (
+	dangling  = regexp.MustCompile(`(?i)(=|<>|!=|<=|>=|<|>|\blike|\bin|\band|\bor|\bwhere|\bset|,)\s*($|\)|\b(and|or|order|group|limit)\b)`)
+	emptyIn   = regexp.MustCompile(`(?i)\bin\s*\(\s*\)`)
+	whereAnd  = regexp.MustCompile(`(?i)\b(where|set)\s+(and|or|,)`)
+	listParam = regexp.MustCompile(`\$\d+\s*,\s*\$\d+`)
+)
+
+// Sample builds the sample filters of the schema: the map which has all the properties, and the empty map, so both the conditions which are true and false are rendered.
+func Sample(schema Schema) map[string]map[string]interface{} {
+	all := make(map[string]interface{}, len(schema))
+	for name, f := range schema {
+		switch f.Kind {
+		case KindArray:
+			if strings.Contains(f.Type, "int") || strings.Contains(f.Type, "float") {
+				all[name] = []int64{1, 2}
+			} else {
+				all[name] = []string{"a", "b"}
+			}
+		case KindRange:
+			r := make(map[string]interface{}, len(bounds))
+			for _, b := range bounds {
+				r[b] = sampleValue(f.Type)
+			}
+			all[name] = r
+		case KindStruct:
+			all[name] = map[string]interface{}{}
+		default:
+			all[name] = sampleValue(f.Type)
+		}
+	}
+	return map[string]map[string]interface{}{"all the properties": all, "no properties": {}}
+}
+func sampleValue(typeName string) interface{} {
+	typeName = strings.TrimPrefix(typeName, "*")
+	switch {
+	case strings.Contains(typeName, "Time") || strings.Contains(typeName, "Date"):
+		return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	case strings.HasPrefix(typeName, "int") || strings.HasPrefix(typeName, "uint") || strings.Contains(typeName, "Int"):
+		return int64(1)
+	case strings.HasPrefix(typeName, "float") || strings.Contains(typeName, "Number"):
+		return 1.5
+	case typeName == "bool":
+		return true
+	}
+	return "a"
+}
+
+// Render renders the template by the sample filters of the schema, and returns the problems of the sql, such as the parameters which are not replaced,
+// the placeholders which do not match the parameters, an empty "in ()" or a dangling "where".
+func Render(template t.Template, schema Schema, param func(int) string) (messages []string) {
+	messages = make([]string, 0)
+	defer func() {
+		if r := recover(); r != nil {
+			messages = append(messages, fmt.Sprintf("cannot render the template: %v", r))
+		}
+	}()
+	samples := Sample(schema)
+	for _, name := range []string{"all the properties", "no properties"} {
+		query, params := sql.Build(samples[name], template, param)
+		for _, m := range checkQuery(query, params, param) {
+			messages = append(messages, fmt.Sprintf("rendered with %s: %s in %q", name, m, strings.TrimSpace(query)))
+		}
+	}
+	return messages
+}
+func checkQuery(query string, params []interface{}, param func(int) string) []string {
+	messages := make([]string, 0)
+	if strings.Contains(query, "#{") || strings.Contains(query, "${") {
+		messages = append(messages, "the parameter is not replaced")
+	}
+	if (len(params) > 0 && !strings.Contains(query, param(len(params)))) || strings.Contains(query, param(len(params)+1)) {
+		messages = append(messages, fmt.Sprintf("the placeholders do not match %d parameters", len(params)))
+	}
+	if depth := parentheses(query, len(query)); depth != 0 {
+		messages = append(messages, "the parentheses are not balanced")
+	}
+	if emptyIn.MatchString(query) {
+		messages = append(messages, "empty in ()")
+	}
+	if m := whereAnd.FindString(query); len(m) > 0 {
+		messages = append(messages, fmt.Sprintf("dangling %q", m))
+	} else if m := dangling.FindStringSubmatch(query); len(m) > 0 {
+		messages = append(messages, fmt.Sprintf("missing the value after %q", m[1]))
+	}
+	for _, loc := range listParam.FindAllStringIndex(query, -1) {
+		if parentheses(query, loc[0]) == 0 {
+			messages = append(messages, "the placeholders of the array are not in parentheses")
+			break
+		}
+	}
+	return messages
+}
+func parentheses(s string, end int) int {
+	depth := 0
+	for i := 0; i < end; i++ {
+		if s[i] == '(' {
+			depth++
+		} else if s[i] == ')' {
+			depth--
+		}
+	}
+	return depth
+}
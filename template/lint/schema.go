@@ -0,0 +1,227 @@
+package lint
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/core-go/search"
+)
+
+const (
+	KindValue  = "value"
+	KindArray  = "array"
+	KindRange  = "range"
+	KindStruct = "struct"
+)
+
+// Field is a key of the map which convert.ToMap builds from the filter. Type is the Go type, such as "int64" or "time.Time".
+// The keys of search.Filter, such as "q" and "sort", are not reported when they are not used.
+type Field struct {
+	Name   string
+	Kind   string
+	Type   string
+	Filter bool
+}
+
+// Schema is the fields of the filter by the json names.
+type Schema map[string]Field
+
+var ranges = map[string]bool{"TimeRange": true, "DateRange": true, "NumberRange": true, "Int64Range": true, "IntRange": true, "Int32Range": true}
+
+// bounds are the keys of the ranges. The top of DateRange is the start of the day after max, if top is nil.
+var bounds = []string{"min", "max", "bottom", "top", "floor", "ceiling", "lower", "upper"}
+var filterKeys = []string{"fields", "sort", "excluding", "q", "terms"}
+
+func (s Schema) addFilter() {
+	for _, k := range filterKeys {
+		kind, typeName := KindValue, "string"
+		if k == "excluding" {
+			kind = KindArray
+		} else if k == "terms" {
+			kind, typeName = KindArray, "[][]string"
+		}
+		s[k] = Field{Name: k, Kind: kind, Type: typeName, Filter: true}
+	}
+}
+
+// NewSchema builds the schema of the filter type by reflection, as convert.ToMap: the names are the json names, or the field names if there is no json tag.
+// The fields of the embedded structs are the fields of the filter.
+func NewSchema(filterType reflect.Type) Schema {
+	schema := make(Schema)
+	for filterType.Kind() == reflect.Ptr {
+		filterType = filterType.Elem()
+	}
+	if filterType.Kind() != reflect.Struct {
+		return schema
+	}
+	filter := reflect.TypeOf(search.Filter{})
+	numField := filterType.NumField()
+	for i := 0; i < numField; i++ {
+		field := filterType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == filter {
+			schema.addFilter()
+			continue
+		}
+		if field.Anonymous && t.Kind() == reflect.Struct && t.PkgPath() != filter.PkgPath() {
+			for k, f := range NewSchema(t) {
+				schema[k] = f
+			}
+			continue
+		}
+		name := getJsonName(field.Name, string(field.Tag))
+		if name == "-" {
+			continue
+		}
+		kind := KindValue
+		if t.PkgPath() == filter.PkgPath() && ranges[t.Name()] {
+			kind = KindRange
+		} else if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+			kind = KindArray
+		} else if t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+			kind = KindStruct
+		}
+		schema[name] = Field{Name: name, Kind: kind, Type: t.String()}
+	}
+	return schema
+}
+
+// ParseSchema builds the schema of the filter type by the source files of the package directory, so the filter types can be checked without building them.
+func ParseSchema(dir string, typeName string) (Schema, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range pkgs {
+		specs := typeSpecs(pkg)
+		ts, ok := specs[typeName]
+		if !ok {
+			continue
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a struct", typeName)
+		}
+		return parseFields(st, specs), nil
+	}
+	return nil, fmt.Errorf("cannot find the type %s in %s", typeName, dir)
+}
+
+// typeSpecs gets the types which are declared in all the files of the package by the names, so the fields can have the types of the other files.
+func typeSpecs(pkg *ast.Package) map[string]*ast.TypeSpec {
+	specs := make(map[string]*ast.TypeSpec)
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				specs[ts.Name.Name] = ts
+			}
+		}
+	}
+	return specs
+}
+func parseFields(st *ast.StructType, specs map[string]*ast.TypeSpec) Schema {
+	schema := make(Schema)
+	for _, field := range st.Fields.List {
+		t := field.Type
+		if star, ok := t.(*ast.StarExpr); ok {
+			t = star.X
+		}
+		kind, typeName := KindValue, types(t)
+		switch x := t.(type) {
+		case *ast.ArrayType:
+			if typeName != "[]byte" {
+				kind = KindArray
+			}
+		case *ast.SelectorExpr:
+			if x.Sel.Name == "Filter" {
+				schema.addFilter()
+				continue
+			}
+			if ranges[x.Sel.Name] {
+				kind = KindRange
+			} else if typeName != "time.Time" {
+				kind = KindStruct
+			}
+		case *ast.StructType:
+			kind = KindStruct
+		case *ast.Ident:
+			if ts, ok := specs[x.Name]; ok {
+				switch sub := ts.Type.(type) {
+				case *ast.StructType:
+					if len(field.Names) == 0 {
+						for k, f := range parseFields(sub, specs) {
+							schema[k] = f
+						}
+						continue
+					}
+					kind = KindStruct
+				case *ast.ArrayType:
+					if types(sub) != "[]byte" {
+						kind = KindArray
+					}
+				}
+			}
+		}
+		tag := ""
+		if field.Tag != nil {
+			tag, _ = strconv.Unquote(field.Tag.Value)
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(strings.TrimPrefix(typeName[strings.LastIndex(typeName, ".")+1:], "*"))}
+		}
+		for _, n := range names {
+			if !ast.IsExported(n.Name) {
+				continue
+			}
+			name := getJsonName(n.Name, tag)
+			if name == "-" {
+				continue
+			}
+			schema[name] = Field{Name: name, Kind: kind, Type: typeName}
+		}
+	}
+	return schema
+}
+func types(t ast.Expr) string {
+	switch x := t.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.StarExpr:
+		return "*" + types(x.X)
+	case *ast.ArrayType:
+		return "[]" + types(x.Elt)
+	case *ast.SelectorExpr:
+		return types(x.X) + "." + x.Sel.Name
+	case *ast.MapType:
+		return "map[" + types(x.Key) + "]" + types(x.Value)
+	}
+	return "interface{}"
+}
+func getJsonName(name string, tag string) string {
+	if json := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]; len(json) > 0 {
+		return json
+	}
+	return name
+}
@@ -0,0 +1,49 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSchemaOfTypesInOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"filter.go": `package user
+
+import "github.com/core-go/search"
+
+type UserFilter struct {
+	*search.Filter
+	Audit
+	Ids     Ids     ` + "`json:\"ids\"`" + `
+	Address Address ` + "`json:\"address\"`" + `
+}
+`,
+		"types.go": `package user
+
+type Audit struct {
+	CreatedBy string ` + "`json:\"createdBy\"`" + `
+}
+type Ids []string
+type Address struct {
+	City string ` + "`json:\"city\"`" + `
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	schema, err := ParseSchema(dir, "UserFilter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"createdBy": KindValue, "ids": KindArray, "address": KindStruct, "q": KindValue, "terms": KindArray}
+	for name, kind := range expected {
+		if f, ok := schema[name]; !ok || f.Kind != kind {
+			t.Errorf("the field %s is %+v, but the kind must be %s", name, f, kind)
+		}
+	}
+}
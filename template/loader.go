@@ -0,0 +1,151 @@
+package template
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Source gets the template by the id. The query builders which use a Source build the queries by the current template, after the templates are reloaded.
+type Source interface {
+	Get(id string) (*Template, bool)
+}
+
+// Loader loads the templates of the patterns, such as "configs/*.xml", from the file system, or from the OS if it is nil.
+// The templates are replaced atomically by Reload, so the queries in progress keep the previous templates.
+type Loader struct {
+	FS        fs.FS
+	Patterns  []string
+	Trim      func(string) string
+	mu        sync.Mutex
+	templates atomic.Value
+	versions  map[string]version
+}
+type version struct {
+	modTime time.Time
+	size    int64
+}
+
+func NewLoader(fsys fs.FS, trim func(string) string, patterns ...string) (*Loader, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"configs/query.xml"}
+	}
+	l := &Loader{FS: fsys, Patterns: patterns, Trim: trim}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Templates returns the current templates, which must not be changed.
+func (l *Loader) Templates() map[string]*Template {
+	templates, _ := l.templates.Load().(map[string]*Template)
+	return templates
+}
+
+// Get gets the current template of the id.
+func (l *Loader) Get(id string) (*Template, bool) {
+	t, ok := l.Templates()[id]
+	return t, ok && t != nil
+}
+
+// Reload parses all the files again, and resolves the includes. If it fails, such as a broken edit, the current templates are kept.
+func (l *Loader) Reload() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files, err := Glob(l.FS, l.Patterns...)
+	if err != nil {
+		return err
+	}
+	versions, err := l.stat(files)
+	if err != nil {
+		return err
+	}
+	l.versions = versions
+	templates, err := loadTemplates(l.FS, l.Trim, files...)
+	if err != nil {
+		return err
+	}
+	l.templates.Store(templates)
+	return nil
+}
+
+// Watch checks the files every interval, and reloads the templates if a file is changed, added or removed, until the context is done.
+// The error of a broken edit is logged once, and the current templates are kept until the files are fixed. It is run by a goroutine,
+// such as "go loader.Watch(ctx, 5*time.Second, logError)".
+func (l *Loader) Watch(ctx context.Context, interval time.Duration, logError func(context.Context, string, ...map[string]interface{})) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := l.changed()
+			if err == nil && changed {
+				err = l.Reload()
+			}
+			if err != nil && logError != nil {
+				logError(ctx, "cannot reload the templates: "+err.Error())
+			}
+		}
+	}
+}
+
+// changed checks if the files are different from the files of the last reload. The error is returned once, until the files are changed again.
+func (l *Loader) changed() (bool, error) {
+	files, err := Glob(l.FS, l.Patterns...)
+	if err == nil {
+		var versions map[string]version
+		if versions, err = l.stat(files); err == nil {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if len(versions) != len(l.versions) {
+				return true, nil
+			}
+			for name, v := range versions {
+				if old, ok := l.versions[name]; !ok || !old.modTime.Equal(v.modTime) || old.size != v.size {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.versions == nil {
+		return false, nil
+	}
+	l.versions = nil
+	return false, err
+}
+func (l *Loader) stat(files []string) (map[string]version, error) {
+	versions := make(map[string]version, len(files))
+	for _, name := range files {
+		var info fs.FileInfo
+		var err error
+		if l.FS == nil {
+			info, err = os.Stat(name)
+		} else {
+			info, err = fs.Stat(l.FS, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions[name] = version{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
+
+// GetTemplate gets the current template of the id from the source, or returns the template if the source is nil or the template is removed.
+func GetTemplate(source Source, id string, t Template) Template {
+	if source != nil {
+		if current, ok := source.Get(id); ok {
+			return *current
+		}
+	}
+	return t
+}
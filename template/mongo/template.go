@@ -0,0 +1,135 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/core-go/search"
+	m "github.com/core-go/search/mongo"
+	set "github.com/core-go/search/template"
+)
+
+// Build renders the json template, such as {"status": #{status}, "age": {"$gte": #{age.min}}}, into the query.
+// The parameters are bound as the typed values, and the template can use the extended json, such as {"$oid": "..."}.
+func Build(obj map[string]interface{}, template set.Template) (bson.D, error) {
+	return BuildWithSubstitution(obj, template, nil)
+}
+func BuildWithSubstitution(obj map[string]interface{}, template set.Template, substitution *set.Substitution) (bson.D, error) {
+	text, values := set.BuildJSON(obj, template, substitution)
+	if len(strings.TrimSpace(text)) == 0 {
+		return bson.D{}, nil
+	}
+	var query bson.D
+	if err := bson.UnmarshalExtJSON([]byte(text), false, &query); err != nil {
+		return nil, fmt.Errorf("template %s: %w", template.Id, err)
+	}
+	return bind(query, values).(bson.D), nil
+}
+func bind(v interface{}, values []interface{}) interface{} {
+	switch x := v.(type) {
+	case bson.D:
+		d := make(bson.D, len(x))
+		for i, e := range x {
+			d[i] = bson.E{Key: set.BindText(e.Key, values), Value: bind(e.Value, values)}
+		}
+		return d
+	case bson.A:
+		a := make(bson.A, len(x))
+		for i, e := range x {
+			a[i] = bind(e, values)
+		}
+		return a
+	case string:
+		return set.BindString(x, values)
+	}
+	return v
+}
+
+type QueryBuilder struct {
+	Template     set.Template
+	Id           string
+	Source       set.Source
+	ModelType    *reflect.Type
+	Map          func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
+	Q            func(string) string
+	Substitution *set.Substitution
+}
+
+// UseQuery creates the query builder of the template, and returns its BuildQuery for mongo.NewSearchBuilder.
+func UseQuery[F any](id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (func(F) (bson.D, bson.M), error) {
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(f F) (bson.D, bson.M) {
+		return b.BuildQuery(f)
+	}, nil
+}
+
+// UseQueryWithError creates the query builder of the template, and returns its BuildQueryWithError for mongo.NewSearchBuilderWithError, so the search returns the error of the rendered json.
+func UseQueryWithError[F any](id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (func(F) (bson.D, bson.M, error), error) {
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return func(f F) (bson.D, bson.M, error) {
+		return b.BuildQueryWithError(f)
+	}, nil
+}
+func NewQueryBuilder(id string, templates map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (*QueryBuilder, error) {
+	t, ok := templates[id]
+	if !ok || t == nil {
+		return nil, errors.New("cannot get the template with id " + id)
+	}
+	var q func(string) string
+	if len(opts) > 0 {
+		q = opts[0]
+	}
+	return &QueryBuilder{Template: *t, Id: id, ModelType: modelType, Map: mp, Q: q, Substitution: set.NewSubstitution(modelType, nil)}, nil
+}
+
+// NewQueryBuilderWithSource creates the query builder which builds the queries by the current template of the source, such as set.Loader, which can be reloaded.
+func NewQueryBuilderWithSource(id string, source set.Source, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, opts ...func(string) string) (*QueryBuilder, error) {
+	var templates map[string]*set.Template
+	if t, ok := source.Get(id); ok {
+		templates = map[string]*set.Template{id: t}
+	}
+	b, err := NewQueryBuilder(id, templates, modelType, mp, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b.Source = source
+	return b, nil
+}
+
+// BuildQuery builds the query and the projection of the fields of the filter. If the rendered json is invalid, the query is nil, so the search fails; use BuildQueryWithError to get the error.
+func (b *QueryBuilder) BuildQuery(f interface{}) (bson.D, bson.M) {
+	query, fields, err := b.BuildQueryWithError(f)
+	if err != nil {
+		return nil, nil
+	}
+	return query, fields
+}
+
+// BuildQueryWithError builds the query and the projection of the fields of the filter, and returns the error of the rendered json.
+func (b *QueryBuilder) BuildQueryWithError(f interface{}) (bson.D, bson.M, error) {
+	obj := b.Map(f, b.ModelType)
+	if b.Q != nil {
+		if q, ok := obj["q"].(string); ok {
+			obj["q"] = b.Q(q)
+		}
+	}
+	query, err := BuildWithSubstitution(obj, set.GetTemplate(b.Source, b.Id, b.Template), b.Substitution)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fields bson.M
+	if b.ModelType != nil {
+		fields = m.GetFields(search.GetFields(f), *b.ModelType)
+	}
+	return query, fields, nil
+}
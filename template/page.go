@@ -0,0 +1,116 @@
+package template
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Page is the sort and the paging of the query, which are rendered by the orderBy and the paging nodes, such as:
+//
+//	<select id="user">
+//	  select * from users
+//	  <where>...</where>
+//	  <orderBy default="-createdDate" allowed="id,username,createdDate=created_date"/>
+//	  <paging/>
+//	</select>
+type Page struct {
+	// Sort is the sort of the filter, such as "-createdDate,id". The fields which are not allowed are skipped.
+	Sort      string
+	ModelType reflect.Type
+	// Paging keeps the paging node, so the dialect renders the limit and the offset at its place.
+	Paging bool
+	// Count renders neither the orderBy nor the paging, so the query can be counted.
+	Count bool
+}
+
+// RenderPageNodes renders the nodes as RenderTemplateNodes, and renders the orderBy and the paging nodes by the page.
+func RenderPageNodes(obj map[string]interface{}, templateNodes []TemplateNode, page Page) []TemplateNode {
+	return renderNodes(obj, templateNodes, scope{page: &page})
+}
+
+// HasPaging checks if the template has the paging node.
+func HasPaging(nodes []TemplateNode) bool {
+	for _, n := range nodes {
+		if n.Type == TypePaging || HasPaging(n.Children) {
+			return true
+		}
+	}
+	return false
+}
+func (p *Page) orderBy(n TemplateNode) []TemplateNode {
+	var s string
+	if p == nil {
+		s = OrderBy(n, "", nil)
+	} else if !p.Count {
+		s = OrderBy(n, p.Sort, p.ModelType)
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	return []TemplateNode{textNode(" order by " + s + " ")}
+}
+func (p *Page) paging() []TemplateNode {
+	if p == nil || p.Count || !p.Paging {
+		return nil
+	}
+	return []TemplateNode{{Type: TypePaging}}
+}
+
+// OrderBy builds the columns of the orderBy node by the sort, such as "created_date desc,id asc".
+// The fields of the sort must be allowed, or be the fields of the model type if the node has no allowed fields. The columns are mapped by the allowed fields, such as "createdDate=u.created_date", or by the model type. If none of the fields can be sorted, the default sort is used.
+func OrderBy(n TemplateNode, sort string, modelType reflect.Type) string {
+	allowed := make(map[string]string)
+	for _, s := range strings.Split(n.Allowed, ",") {
+		if s = strings.TrimSpace(s); len(s) == 0 {
+			continue
+		}
+		if kv := strings.SplitN(s, "=", 2); len(kv) == 2 {
+			allowed[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		} else {
+			allowed[s] = ""
+		}
+	}
+	column := func(field string, trusted bool) (string, bool) {
+		c, ok := allowed[field]
+		if ok && len(c) > 0 {
+			return c, true
+		}
+		if (ok || len(allowed) == 0) && modelType != nil {
+			if c, ok := ColumnOf(modelType, field); ok {
+				return c, true
+			}
+		}
+		return field, (ok || trusted) && identifierPattern.MatchString(field)
+	}
+	if s := buildOrderBy(sort, func(field string) (string, bool) { return column(field, false) }); len(s) > 0 {
+		return s
+	}
+	return buildOrderBy(n.Default, func(field string) (string, bool) { return column(field, true) })
+}
+
+// buildOrderBy builds the columns of the sort, such as "-createdDate,id" or "createdDate desc,id", and skips the fields which have no column.
+func buildOrderBy(sort string, column func(string) (string, bool)) string {
+	columns := make([]string, 0)
+	for _, s := range strings.Split(sort, ",") {
+		words := strings.Fields(s)
+		if len(words) == 0 || len(words) > 2 {
+			continue
+		}
+		field, direction := words[0], "asc"
+		if strings.HasPrefix(field, "-") {
+			field, direction = field[1:], "desc"
+		} else if strings.HasPrefix(field, "+") {
+			field = field[1:]
+		}
+		if len(words) == 2 {
+			direction = strings.ToLower(words[1])
+			if direction != "asc" && direction != "desc" {
+				continue
+			}
+		}
+		if c, ok := column(field); ok && len(field) > 0 {
+			columns = append(columns, c+" "+direction)
+		}
+	}
+	return strings.Join(columns, ",")
+}
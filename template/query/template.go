@@ -2,7 +2,6 @@ package template
 
 import (
 	"errors"
-	"fmt"
 	"reflect"
 	"strings"
 
@@ -10,6 +9,11 @@ import (
 )
 
 func Merge(obj map[string]interface{}, format set.StringFormat, param func(int) string, j int, skipArray bool, separator string, prefix string, suffix string) set.TStatement {
+	return MergeWithSubstitution(obj, format, param, j, skipArray, separator, prefix, suffix, nil)
+}
+
+// MergeWithSubstitution merges the format, and writes the text parameters by the substitution, which checks the identifiers, the enums and the sorts.
+func MergeWithSubstitution(obj map[string]interface{}, format set.StringFormat, param func(int) string, j int, skipArray bool, separator string, prefix string, suffix string, substitution *set.Substitution) set.TStatement {
 	results := make([]string, 0)
 	parameters := format.Parameters
 	k := j
@@ -22,7 +26,7 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 			if l > 0 {
 				strs := make([]string, 0)
 				for i := 0; i < l; i++ {
-					ts := Merge(obj, format, param, k, true, "", "", "")
+					ts := MergeWithSubstitution(obj, format, param, k, true, "", "", "", substitution)
 					strs = append(strs, ts.Query)
 					model := vo.Index(i).Addr()
 					params = append(params, model.Interface())
@@ -39,8 +43,10 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 		results = append(results, texts[i])
 		p := set.ValueOf(obj, parameters[i].Name)
 		if p != nil {
-			if parameters[i].Type == set.ParamText {
-				results = append(results, fmt.Sprintf("%v", p))
+			if parameters[i].Type != "param" {
+				if text, ok := substitution.Text(parameters[i], p); ok {
+					results = append(results, text)
+				}
 			} else {
 				vo := reflect.Indirect(reflect.ValueOf(p))
 				if vo.Kind() == reflect.Slice {
@@ -75,13 +81,16 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 	return set.TStatement{Query: prefix + strings.Join(results, "") + suffix, Params: params, Index: k}
 }
 func Build(obj map[string]interface{}, template set.Template, param func(int) string) (string, []interface{}) {
+	return BuildWithSubstitution(obj, template, param, nil)
+}
+func BuildWithSubstitution(obj map[string]interface{}, template set.Template, param func(int) string, substitution *set.Substitution) (string, []interface{}) {
 	results := make([]string, 0)
 	params := make([]interface{}, 0)
 	i := 1
 	renderNodes := set.RenderTemplateNodes(obj, template.Templates)
 	for _, sub := range renderNodes {
 		skipArray := sub.Array == "skip"
-		s := Merge(obj, sub.Format, param, i, skipArray, sub.Separator, sub.Prefix, sub.Suffix)
+		s := MergeWithSubstitution(obj, sub.Format, param, i, skipArray, sub.Separator, sub.Prefix, sub.Suffix, substitution)
 		i = s.Index
 		if len(s.Query) > 0 {
 			results = append(results, s.Query)
@@ -96,12 +105,15 @@ func Build(obj map[string]interface{}, template set.Template, param func(int) st
 }
 
 type QueryBuilder struct {
-	Template  set.Template
-	ModelType *reflect.Type
-	Map       func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
-	Param     func(int) string
-	BuildSort func(string, reflect.Type) string
-	Q         func(string) string
+	Template     set.Template
+	Id           string
+	Source       set.Source
+	ModelType    *reflect.Type
+	Map          func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
+	Param        func(int) string
+	BuildSort    func(string, reflect.Type) string
+	Q            func(string) string
+	Substitution *set.Substitution
 }
 type Builder interface {
 	BuildQuery(f interface{}) (string, []interface{})
@@ -144,7 +156,7 @@ func NewQueryBuilder(id string, m map[string]*set.Template, modelType *reflect.T
 	} else {
 		q = set.Q
 	}
-	return &QueryBuilder{Template: *t, ModelType: modelType, Map: mp, Param: param, BuildSort: buildSort, Q: q}, nil
+	return &QueryBuilder{Template: *t, Id: id, ModelType: modelType, Map: mp, Param: param, BuildSort: buildSort, Q: q, Substitution: set.NewSubstitution(modelType, buildSort, func(s *set.Substitution) { s.Quote = set.QuoteByParam(param) })}, nil
 }
 func (b *QueryBuilder) BuildQuery(f interface{}) (string, []interface{}) {
 	m := b.Map(f, b.ModelType, b.BuildSort)
@@ -157,5 +169,19 @@ func (b *QueryBuilder) BuildQuery(f interface{}) (string, []interface{}) {
 			}
 		}
 	}
-	return Build(m, b.Template, b.Param)
+	return BuildWithSubstitution(m, set.GetTemplate(b.Source, b.Id, b.Template), b.Param, b.Substitution)
+}
+
+// NewQueryBuilderWithSource creates the query builder which builds the queries by the current template of the source, such as set.Loader, which can be reloaded.
+func NewQueryBuilderWithSource(id string, source set.Source, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, param func(i int) string, buildSort func(string, reflect.Type) string, opts ...func(string) string) (*QueryBuilder, error) {
+	var templates map[string]*set.Template
+	if t, ok := source.Get(id); ok {
+		templates = map[string]*set.Template{id: t}
+	}
+	b, err := NewQueryBuilder(id, templates, modelType, mp, param, buildSort, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b.Source = source
+	return b, nil
 }
@@ -0,0 +1,311 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scope binds the item and the index of foreach: the item is the path of the item in the collection, such as "ids.0", and the index is the value.
+type scope struct {
+	paths  map[string]string
+	values map[string]interface{}
+	page   *Page
+}
+
+func (s scope) with(item string, path string, index string, key interface{}) scope {
+	sub := scope{paths: make(map[string]string), values: make(map[string]interface{}), page: s.page}
+	for k, v := range s.paths {
+		sub.paths[k] = v
+	}
+	for k, v := range s.values {
+		sub.values[k] = v
+	}
+	if len(item) > 0 {
+		sub.paths[item] = path
+		delete(sub.values, item)
+	}
+	if len(index) > 0 {
+		sub.values[index] = key
+		delete(sub.paths, index)
+	}
+	return sub
+}
+
+// resolve returns the path of the name in the scope, or the value if the name is the index of foreach.
+func (s scope) resolve(name string) (string, interface{}, bool) {
+	if len(s.paths) == 0 && len(s.values) == 0 {
+		return name, nil, false
+	}
+	first, rest := name, ""
+	if i := strings.Index(name, "."); i >= 0 {
+		first, rest = name[:i], name[i:]
+	}
+	if v, ok := s.values[first]; ok && len(rest) == 0 {
+		return "", v, true
+	}
+	if p, ok := s.paths[first]; ok {
+		return p + rest, nil, false
+	}
+	return name, nil, false
+}
+func (s scope) valueOf(obj map[string]interface{}, name string) interface{} {
+	path, v, ok := s.resolve(name)
+	if ok {
+		return v
+	}
+	return ValueOf(obj, path)
+}
+
+// test checks the condition of the node: the expression of the type if, or the simple conditions, such as isNotNull.
+func (s scope) test(obj map[string]interface{}, n TemplateNode) bool {
+	if n.Type == TypeIf {
+		return Test(n.Test, func(name string) interface{} {
+			return s.valueOf(obj, name)
+		})
+	}
+	return isValidNode(n.Type) && isTrue(n.Type, s.valueOf(obj, n.Property), n.Value)
+}
+
+// bind replaces the parameters of the node by their paths in the scope. The index of foreach is written as the text.
+func (s scope) bind(n TemplateNode) TemplateNode {
+	if (len(s.paths) == 0 && len(s.values) == 0) || len(n.Format.Texts) != len(n.Format.Parameters)+1 {
+		return n
+	}
+	texts := []string{n.Format.Texts[0]}
+	parameters := make([]Parameter, 0, len(n.Format.Parameters))
+	for i, p := range n.Format.Parameters {
+		path, v, ok := s.resolve(p.Name)
+		if ok {
+			texts[len(texts)-1] += fmt.Sprintf("%v", v) + n.Format.Texts[i+1]
+		} else {
+			p.Name = path
+			parameters = append(parameters, p)
+			texts = append(texts, n.Format.Texts[i+1])
+		}
+	}
+	n.Format = StringFormat{Texts: texts, Parameters: parameters}
+	if len(n.Property) > 0 {
+		if path, _, ok := s.resolve(n.Property); !ok {
+			n.Property = path
+		}
+	}
+	return n
+}
+
+// renderNodes renders the nested nodes into the flat list of the text nodes and the conditions which are true, so they can be merged one by one.
+func renderNodes(obj map[string]interface{}, templateNodes []TemplateNode, s scope) []TemplateNode {
+	nodes := make([]TemplateNode, 0)
+	for _, sub := range templateNodes {
+		switch sub.Type {
+		case TypeText:
+			nodes = append(nodes, s.bind(sub))
+		case TypeWhere:
+			nodes = append(nodes, trimNodes(renderNodes(obj, sub.Children, s), "where", "", "and|or", "")...)
+		case TypeSet:
+			nodes = append(nodes, trimNodes(renderNodes(obj, sub.Children, s), "set", "", "", ",")...)
+		case TypeTrim:
+			nodes = append(nodes, trimNodes(renderNodes(obj, sub.Children, s), sub.Prefix, sub.Suffix, sub.PrefixOverrides, sub.SuffixOverrides)...)
+		case TypeForeach:
+			nodes = append(nodes, renderForeach(obj, sub, s)...)
+		case TypeChoose:
+			for _, when := range sub.Children {
+				if when.Type == TypeOtherwise {
+					nodes = append(nodes, renderNodes(obj, when.Children, s)...)
+					break
+				}
+				if s.test(obj, when) {
+					nodes = append(nodes, renderCondition(obj, when, s)...)
+					break
+				}
+			}
+		case TypeOtherwise:
+			nodes = append(nodes, renderNodes(obj, sub.Children, s)...)
+		case TypeOrderBy:
+			nodes = append(nodes, s.page.orderBy(sub)...)
+		case TypePaging:
+			nodes = append(nodes, s.page.paging()...)
+		default:
+			if s.test(obj, sub) {
+				nodes = append(nodes, renderCondition(obj, sub, s)...)
+			}
+		}
+	}
+	return nodes
+}
+
+// renderCondition renders the condition which is true: the node itself if it has only the text, or its children between the prefix and the suffix.
+func renderCondition(obj map[string]interface{}, n TemplateNode, s scope) []TemplateNode {
+	if n.Children == nil {
+		return []TemplateNode{s.bind(n)}
+	}
+	children := renderNodes(obj, n.Children, s)
+	if !hasContent(children) {
+		return nil
+	}
+	nodes := make([]TemplateNode, 0, len(children)+2)
+	if len(n.Prefix) > 0 {
+		nodes = append(nodes, textNode(n.Prefix))
+	}
+	nodes = append(nodes, children...)
+	if len(n.Suffix) > 0 {
+		nodes = append(nodes, textNode(n.Suffix))
+	}
+	return nodes
+}
+
+// renderForeach renders the children for each item of the collection, between open and close. For a map, the index is the key and the item is the value.
+func renderForeach(obj map[string]interface{}, n TemplateNode, s scope) []TemplateNode {
+	path, _, ok := s.resolve(n.Collection)
+	if ok || len(path) == 0 {
+		return nil
+	}
+	vo := reflect.Indirect(reflect.ValueOf(ValueOf(obj, path)))
+	var keys []interface{}
+	switch vo.Kind() {
+	case reflect.Slice, reflect.Array:
+		keys = make([]interface{}, vo.Len())
+		for i := range keys {
+			keys[i] = i
+		}
+	case reflect.Map:
+		if vo.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		names := make([]string, 0, vo.Len())
+		for _, k := range vo.MapKeys() {
+			names = append(names, k.String())
+		}
+		sort.Strings(names)
+		keys = make([]interface{}, len(names))
+		for i, k := range names {
+			keys[i] = k
+		}
+	default:
+		return nil
+	}
+	items := make([]TemplateNode, 0)
+	for _, key := range keys {
+		var itemPath string
+		if i, ok := key.(int); ok {
+			itemPath = path + "." + strconv.Itoa(i)
+		} else {
+			itemPath = path + "." + key.(string)
+		}
+		children := renderNodes(obj, n.Children, s.with(n.Item, itemPath, n.Index, key))
+		if !hasContent(children) {
+			continue
+		}
+		if len(items) > 0 && len(n.Separator) > 0 {
+			items = append(items, textNode(n.Separator))
+		}
+		items = append(items, children...)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	nodes := make([]TemplateNode, 0, len(items)+2)
+	if len(n.Open) > 0 {
+		nodes = append(nodes, textNode(n.Open))
+	}
+	nodes = append(nodes, items...)
+	if len(n.Close) > 0 {
+		nodes = append(nodes, textNode(n.Close))
+	}
+	return nodes
+}
+
+// trimNodes removes the prefix overrides from the start and the suffix overrides from the end of the content, then adds the prefix and the suffix.
+// If there is no content, nothing is rendered, so the where clause has no "where" if none of its conditions is true.
+func trimNodes(nodes []TemplateNode, prefix string, suffix string, prefixOverrides string, suffixOverrides string) []TemplateNode {
+	if !hasContent(nodes) {
+		return nil
+	}
+	if overrides := splitOverrides(prefixOverrides); len(overrides) > 0 {
+		for i := range nodes {
+			if !hasContent(nodes[i : i+1]) {
+				continue
+			}
+			if len(strings.TrimSpace(nodes[i].Prefix)) > 0 {
+				nodes[i].Prefix = trimStart(nodes[i].Prefix, overrides)
+			} else {
+				texts := append([]string{}, nodes[i].Format.Texts...)
+				texts[0] = trimStart(texts[0], overrides)
+				nodes[i].Format.Texts = texts
+			}
+			break
+		}
+	}
+	if overrides := splitOverrides(suffixOverrides); len(overrides) > 0 {
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if !hasContent(nodes[i : i+1]) {
+				continue
+			}
+			if len(strings.TrimSpace(nodes[i].Suffix)) > 0 {
+				nodes[i].Suffix = trimEnd(nodes[i].Suffix, overrides)
+			} else {
+				texts := append([]string{}, nodes[i].Format.Texts...)
+				texts[len(texts)-1] = trimEnd(texts[len(texts)-1], overrides)
+				nodes[i].Format.Texts = texts
+			}
+			break
+		}
+	}
+	results := make([]TemplateNode, 0, len(nodes)+2)
+	if len(prefix) > 0 {
+		results = append(results, textNode(" "+prefix+" "))
+	}
+	results = append(results, nodes...)
+	if len(suffix) > 0 {
+		results = append(results, textNode(" "+suffix+" "))
+	}
+	return results
+}
+func hasContent(nodes []TemplateNode) bool {
+	for _, n := range nodes {
+		if len(n.Format.Parameters) > 0 || len(strings.TrimSpace(n.Prefix+n.Suffix)) > 0 {
+			return true
+		}
+		for _, t := range n.Format.Texts {
+			if len(strings.TrimSpace(t)) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+func splitOverrides(s string) []string {
+	overrides := make([]string, 0)
+	for _, o := range strings.Split(s, "|") {
+		if o = strings.TrimSpace(o); len(o) > 0 {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides
+}
+
+// trimStart removes the first override at the start of the text, such as "and" of " and status = ?", but not of "android".
+func trimStart(text string, overrides []string) string {
+	s := strings.TrimLeft(text, " \t\r\n")
+	for _, o := range overrides {
+		if len(s) >= len(o) && strings.EqualFold(s[:len(o)], o) && (len(s) == len(o) || !isWord(o[len(o)-1]) || !isWord(s[len(o)])) {
+			return " " + s[len(o):]
+		}
+	}
+	return text
+}
+func trimEnd(text string, overrides []string) string {
+	s := strings.TrimRight(text, " \t\r\n")
+	for _, o := range overrides {
+		l := len(s) - len(o)
+		if l >= 0 && strings.EqualFold(s[l:], o) && (l == 0 || !isWord(o[0]) || !isWord(s[l-1])) {
+			return s[:l] + " "
+		}
+	}
+	return text
+}
+func isWord(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
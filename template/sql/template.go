@@ -8,12 +8,22 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/core-go/search"
+	sq "github.com/core-go/search/sql"
 	set "github.com/core-go/search/template"
 )
 
 func Merge(obj map[string]interface{}, format set.StringFormat, param func(int) string, j int, skipArray bool, separator string, prefix string, suffix string, toArray func(interface{}) interface {
 	driver.Valuer
 	sql.Scanner
+}) set.TStatement {
+	return MergeWithSubstitution(obj, format, param, j, skipArray, separator, prefix, suffix, nil, toArray)
+}
+
+// MergeWithSubstitution merges the format, and writes the text parameters by the substitution, which checks the identifiers, the enums and the sorts.
+func MergeWithSubstitution(obj map[string]interface{}, format set.StringFormat, param func(int) string, j int, skipArray bool, separator string, prefix string, suffix string, substitution *set.Substitution, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
 }) set.TStatement {
 	results := make([]string, 0)
 	parameters := format.Parameters
@@ -27,7 +37,7 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 			if l > 0 {
 				strs := make([]string, 0)
 				for i := 0; i < l; i++ {
-					ts := Merge(obj, format, param, k, true, "", "", "", toArray)
+					ts := MergeWithSubstitution(obj, format, param, k, true, "", "", "", substitution, toArray)
 					strs = append(strs, ts.Query)
 					model := vo.Index(i).Addr()
 					params = append(params, model.Interface())
@@ -44,8 +54,10 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 		results = append(results, texts[i])
 		p := set.ValueOf(obj, parameters[i].Name)
 		if p != nil {
-			if parameters[i].Type == set.ParamText {
-				results = append(results, fmt.Sprintf("%v", p))
+			if parameters[i].Type != "param" {
+				if text, ok := substitution.Text(parameters[i], p); ok {
+					results = append(results, text)
+				}
 			} else {
 				vo := reflect.Indirect(reflect.ValueOf(p))
 				if vo.Kind() == reflect.Slice {
@@ -86,6 +98,29 @@ func Merge(obj map[string]interface{}, format set.StringFormat, param func(int)
 func Build(obj map[string]interface{}, template set.Template, param func(int) string, opts ...func(interface{}) interface {
 	driver.Valuer
 	sql.Scanner
+}) (string, []interface{}) {
+	return BuildWithSubstitution(obj, template, param, nil, opts...)
+}
+func BuildWithSubstitution(obj map[string]interface{}, template set.Template, param func(int) string, substitution *set.Substitution, opts ...func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}) (string, []interface{}) {
+	var toArray func(interface{}) interface {
+		driver.Valuer
+		sql.Scanner
+	}
+	if len(opts) > 0 {
+		toArray = opts[0]
+	}
+	query, params, _ := mergeNodes(obj, set.RenderTemplateNodes(obj, template.Templates), param, 1, substitution, toArray)
+	return query, params
+}
+
+// BuildPagingQuery renders the query of the page: the orderBy node is rendered by the sort of the page, and the limit and the offset are rendered at the paging node,
+// or at the end if the template has no paging node, by the driver, such as "offset 20 rows fetch next 10 rows only" of Oracle and SQL Server.
+func BuildPagingQuery(obj map[string]interface{}, template set.Template, param func(int) string, page set.Page, limit int64, offset int64, driverName string, substitution *set.Substitution, opts ...func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
 }) (string, []interface{}) {
 	var toArray func(interface{}) interface {
 		driver.Valuer
@@ -94,13 +129,52 @@ func Build(obj map[string]interface{}, template set.Template, param func(int) st
 	if len(opts) > 0 {
 		toArray = opts[0]
 	}
+	page.Paging, page.Count = true, false
+	nodes := set.RenderPageNodes(obj, template.Templates, page)
+	l := len(nodes)
+	for i, n := range nodes {
+		if n.Type == set.TypePaging {
+			l = i
+			break
+		}
+	}
+	query, params, k := mergeNodes(obj, nodes[:l], param, 1, substitution, toArray)
+	query = query + PagingText(query, limit, offset, driverName)
+	if l < len(nodes) {
+		rest, restParams, _ := mergeNodes(obj, nodes[l+1:], param, k, substitution, toArray)
+		query = query + rest
+		params = append(params, restParams...)
+	}
+	return query, params
+}
+
+// BuildCountQuery renders the template without the orderBy and the paging nodes, and counts its rows, so the queries with the CTEs or the unions are counted correctly.
+func BuildCountQuery(obj map[string]interface{}, template set.Template, param func(int) string, substitution *set.Substitution, opts ...func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}) (string, []interface{}) {
+	var toArray func(interface{}) interface {
+		driver.Valuer
+		sql.Scanner
+	}
+	if len(opts) > 0 {
+		toArray = opts[0]
+	}
+	query, params, _ := mergeNodes(obj, set.RenderPageNodes(obj, template.Templates, set.Page{Count: true}), param, 1, substitution, toArray)
+	return CountQuery(query), params
+}
+func mergeNodes(obj map[string]interface{}, nodes []set.TemplateNode, param func(int) string, i int, substitution *set.Substitution, toArray func(interface{}) interface {
+	driver.Valuer
+	sql.Scanner
+}) (string, []interface{}, int) {
 	results := make([]string, 0)
 	params := make([]interface{}, 0)
-	i := 1
-	renderNodes := set.RenderTemplateNodes(obj, template.Templates)
-	for _, sub := range renderNodes {
+	for _, sub := range nodes {
+		if sub.Type == set.TypePaging {
+			continue
+		}
 		skipArray := sub.Array == "skip"
-		s := Merge(obj, sub.Format, param, i, skipArray, sub.Separator, sub.Prefix, sub.Suffix, toArray)
+		s := MergeWithSubstitution(obj, sub.Format, param, i, skipArray, sub.Separator, sub.Prefix, sub.Suffix, substitution, toArray)
 		i = s.Index
 		if len(s.Query) > 0 {
 			results = append(results, s.Query)
@@ -111,16 +185,119 @@ func Build(obj map[string]interface{}, template set.Template, param func(int) st
 			}
 		}
 	}
-	return strings.Join(results, ""), params
+	return strings.Join(results, ""), params, i
+}
+
+// QuoteByDriver returns the quote of the identifiers of the driver, or nil if the driver is not supported.
+func QuoteByDriver(driver string) func(string) string {
+	switch driver {
+	case sq.DriverPostgres, sq.DriverSqlite3:
+		return set.QuoteDouble
+	case sq.DriverOracle:
+		return set.QuoteUpper
+	case sq.DriverMssql:
+		return set.QuoteBracket
+	case sq.DriverMysql:
+		return set.QuoteBacktick
+	}
+	return nil
+}
+
+// PagingText returns the limit and the offset of the driver. SQL Server needs the order by, so "order by (select null)" is added if the query has no order by.
+func PagingText(query string, limit int64, offset int64, driver string) string {
+	if limit <= 0 {
+		return ""
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	switch driver {
+	case sq.DriverOracle:
+		return fmt.Sprintf(" offset %d rows fetch next %d rows only ", offset, limit)
+	case sq.DriverMssql:
+		if !hasOrderBy(query) {
+			return fmt.Sprintf(" order by (select null) offset %d rows fetch next %d rows only ", offset, limit)
+		}
+		return fmt.Sprintf(" offset %d rows fetch next %d rows only ", offset, limit)
+	default:
+		return fmt.Sprintf(" limit %d offset %d ", limit, offset)
+	}
+}
+
+// CountQuery wraps the query to count its rows. The common table expressions, such as "with t as (...)", are kept before the count,
+// because SQL Server does not allow them in the subquery.
+func CountQuery(query string) string {
+	query = strings.TrimSpace(query)
+	prefix := ""
+	if len(query) > 4 && strings.EqualFold(query[:4], "with") && !isWordByte(query[4]) {
+		if i := indexOutside(query, "select"); i > 0 {
+			prefix, query = query[:i], query[i:]
+		}
+	}
+	return prefix + "select count(*) as total from (" + query + ") t"
+}
+
+// hasOrderBy checks if the query has the order by at the end, which is not in the parentheses of the subqueries or the quotes.
+func hasOrderBy(query string) bool {
+	orders := []bool{false}
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			orders = append(orders, false)
+		case c == ')':
+			if len(orders) > 1 {
+				orders = orders[:len(orders)-1]
+			}
+		case isKeyword(query, i, "order"):
+			orders[len(orders)-1] = true
+		}
+	}
+	return orders[len(orders)-1]
+}
+
+// indexOutside returns the index of the first keyword which is not in the parentheses or the quotes.
+func indexOutside(query string, keyword string) int {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && isKeyword(query, i, keyword):
+			return i
+		}
+	}
+	return -1
+}
+func isKeyword(s string, i int, keyword string) bool {
+	j := i + len(keyword)
+	return j <= len(s) && strings.EqualFold(s[i:j], keyword) && (i == 0 || !isWordByte(s[i-1])) && (j == len(s) || !isWordByte(s[j]))
+}
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
 }
 type QueryBuilder struct {
-	Template  set.Template
-	ModelType *reflect.Type
-	Map       func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
-	Param     func(int) string
-	BuildSort func(string, reflect.Type) string
-	Q         func(string) string
-	ToArray   func(interface{}) interface {
+	Template     set.Template
+	Id           string
+	Source       set.Source
+	ModelType    *reflect.Type
+	Map          func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}
+	Param        func(int) string
+	BuildSort    func(string, reflect.Type) string
+	Q            func(string) string
+	Substitution *set.Substitution
+	// Driver is the driver of the database, such as "postgres" or "oracle", which renders the paging. The identifiers are quoted by the placeholders of Param, or by QuoteByDriver.
+	Driver  string
+	ToArray func(interface{}) interface {
 		driver.Valuer
 		sql.Scanner
 	}
@@ -201,9 +378,27 @@ func NewQueryBuilder(id string, m map[string]*set.Template, modelType *reflect.T
 	} else {
 		q = set.Q
 	}
-	return &QueryBuilder{Template: *t, ModelType: modelType, Map: mp, Param: param, BuildSort: buildSort, Q: q}, nil
+	return &QueryBuilder{Template: *t, Id: id, ModelType: modelType, Map: mp, Param: param, BuildSort: buildSort, Q: q, Substitution: set.NewSubstitution(modelType, buildSort, func(s *set.Substitution) { s.Quote = set.QuoteByParam(param) })}, nil
 }
 func (b *QueryBuilder) BuildQuery(f interface{}) (string, []interface{}) {
+	m := b.buildMap(f)
+	t := set.GetTemplate(b.Source, b.Id, b.Template)
+	query, params, _ := mergeNodes(m, set.RenderPageNodes(m, t.Templates, b.page(f)), b.Param, 1, b.Substitution, b.ToArray)
+	return query, params
+}
+
+// BuildPagingQuery builds the query of the page of the filter, which is sorted by the orderBy node and paged at the paging node of the template.
+func (b *QueryBuilder) BuildPagingQuery(f interface{}, limit int64, offset int64) (string, []interface{}) {
+	m := b.buildMap(f)
+	return BuildPagingQuery(m, set.GetTemplate(b.Source, b.Id, b.Template), b.Param, b.page(f), limit, offset, b.Driver, b.Substitution, b.ToArray)
+}
+
+// BuildCountQuery builds the query which counts the rows of the filter.
+func (b *QueryBuilder) BuildCountQuery(f interface{}) (string, []interface{}) {
+	m := b.buildMap(f)
+	return BuildCountQuery(m, set.GetTemplate(b.Source, b.Id, b.Template), b.Param, b.Substitution, b.ToArray)
+}
+func (b *QueryBuilder) buildMap(f interface{}) map[string]interface{} {
 	m := b.Map(f, b.ModelType, b.BuildSort)
 	if b.Q != nil {
 		q, ok := m["q"]
@@ -214,5 +409,46 @@ func (b *QueryBuilder) BuildQuery(f interface{}) (string, []interface{}) {
 			}
 		}
 	}
-	return Build(m, b.Template, b.Param, b.ToArray)
+	return m
+}
+func (b *QueryBuilder) page(f interface{}) set.Page {
+	page := set.Page{}
+	if reflect.Indirect(reflect.ValueOf(f)).Kind() == reflect.Struct {
+		page.Sort = search.GetSort(f)
+	}
+	if b.ModelType != nil {
+		page.ModelType = *b.ModelType
+	}
+	return page
+}
+
+// UsePaging creates the query builder of the driver, and returns its BuildPagingQuery and BuildCountQuery for sql.NewSearchBuilderWithPaging.
+func UsePaging[F any](id string, m map[string]*set.Template, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, param func(i int) string, buildSort func(string, reflect.Type) string, driver string, opts ...func(string) string) (func(F, int64, int64) (string, []interface{}), func(F) (string, []interface{}), error) {
+	b, err := NewQueryBuilder(id, m, modelType, mp, param, buildSort, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Driver = driver
+	if quote := QuoteByDriver(driver); quote != nil {
+		b.Substitution.Quote = quote
+	}
+	return func(f F, limit int64, offset int64) (string, []interface{}) {
+			return b.BuildPagingQuery(f, limit, offset)
+		}, func(f F) (string, []interface{}) {
+			return b.BuildCountQuery(f)
+		}, nil
+}
+
+// NewQueryBuilderWithSource creates the query builder which builds the queries by the current template of the source, such as set.Loader, which can be reloaded.
+func NewQueryBuilderWithSource(id string, source set.Source, modelType *reflect.Type, mp func(interface{}, *reflect.Type, ...func(string, reflect.Type) string) map[string]interface{}, param func(i int) string, buildSort func(string, reflect.Type) string, opts ...func(string) string) (*QueryBuilder, error) {
+	var templates map[string]*set.Template
+	if t, ok := source.Get(id); ok {
+		templates = map[string]*set.Template{id: t}
+	}
+	b, err := NewQueryBuilder(id, templates, modelType, mp, param, buildSort, opts...)
+	if err != nil {
+		return nil, err
+	}
+	b.Source = source
+	return b, nil
 }
@@ -0,0 +1,131 @@
+package sql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	sq "github.com/core-go/search/sql"
+	set "github.com/core-go/search/template"
+)
+
+const nested = `<mapper>
+<select id="user">
+  select * from users
+  <where>
+    <if test="status != null">and status = #{status}</if>
+    <foreach collection="ids" item="id" open="and id in (" separator="," close=")">#{id}</foreach>
+    <if test="big">and big = 1</if>
+    <choose>
+      <when test="name != null">and name = 'x'</when>
+      <otherwise>and name is null</otherwise>
+    </choose>
+  </where>
+</select>
+</mapper>`
+
+func TestBuildNestedNodes(t *testing.T) {
+	templates, err := set.BuildTemplates(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		obj    map[string]interface{}
+		query  string
+		params int
+	}{
+		{map[string]interface{}{"status": "A", "ids": []string{"1", "2"}, "big": true, "name": "x"}, "select * from users where status = $1 and id in ($2,$3) and big = 1 and name = 'x'", 3},
+		{map[string]interface{}{"ids": []string{"1"}}, "select * from users where id in ($1) and name is null", 1},
+		{map[string]interface{}{}, "select * from users where name is null", 0},
+	}
+	for _, tt := range tests {
+		query, params := Build(tt.obj, *templates["user"], sq.BuildDollarParam)
+		if q := strings.Join(strings.Fields(query), " "); q != tt.query {
+			t.Errorf("query = %q, want %q", q, tt.query)
+		}
+		if len(params) != tt.params {
+			t.Errorf("params = %v, want %d", params, tt.params)
+		}
+	}
+}
+
+func TestBuildTextParameters(t *testing.T) {
+	if _, err := set.BuildTemplates(`<mapper><select id="user">select * from users where a = ${a}</select></mapper>`); err == nil {
+		t.Error("expected the error of the text parameter which has no type")
+	}
+	templates, err := set.BuildTemplates(`<mapper><select id="user">select * from users where a = 1 order by ${column:identifier(name|email)} ${dir:enum(asc|desc)}</select></mapper>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewQueryBuilder("user", templates, nil, func(f interface{}, _ *reflect.Type, _ ...func(string, reflect.Type) string) map[string]interface{} {
+		return f.(map[string]interface{})
+	}, sq.BuildDollarParam, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _ := b.BuildQuery(map[string]interface{}{"column": "name", "dir": "desc"})
+	if want := `select * from users where a = 1 order by "name" desc`; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	b.Param = sq.BuildParam
+	b.Substitution.Quote = set.QuoteByParam(b.Param)
+	query, _ = b.BuildQuery(map[string]interface{}{"column": "email; drop table users", "dir": "up"})
+	if want := `select * from users where a = 1 order by  `; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+}
+
+func TestPagingText(t *testing.T) {
+	tests := []struct {
+		query  string
+		limit  int64
+		offset int64
+		driver string
+		want   string
+	}{
+		{"select * from users", 10, 20, sq.DriverPostgres, " limit 10 offset 20 "},
+		{"select * from users", 10, -1, sq.DriverMysql, " limit 10 offset 0 "},
+		{"select * from users", 0, 20, sq.DriverMssql, ""},
+		{"select * from users", 10, 20, sq.DriverMssql, " order by (select null) offset 20 rows fetch next 10 rows only "},
+		{"select * from users order by id", 10, 20, sq.DriverMssql, " offset 20 rows fetch next 10 rows only "},
+		{"select * from users ORDER BY id desc", 10, 0, sq.DriverMssql, " offset 0 rows fetch next 10 rows only "},
+		{"select * from (select top 5 * from users order by id) u", 10, 0, sq.DriverMssql, " order by (select null) offset 0 rows fetch next 10 rows only "},
+		{"select * from users where name = 'order by'", 10, 0, sq.DriverMssql, " order by (select null) offset 0 rows fetch next 10 rows only "},
+		{"select * from users where sort_order = 1", 10, 0, sq.DriverMssql, " order by (select null) offset 0 rows fetch next 10 rows only "},
+		{"select * from users", 10, 20, sq.DriverOracle, " offset 20 rows fetch next 10 rows only "},
+		{"select * from users order by id", 10, -5, sq.DriverOracle, " offset 0 rows fetch next 10 rows only "},
+		{"select * from users", -1, 0, sq.DriverOracle, ""},
+	}
+	for _, tt := range tests {
+		if got := PagingText(tt.query, tt.limit, tt.offset, tt.driver); got != tt.want {
+			t.Errorf("PagingText(%q, %d, %d, %s) = %q, want %q", tt.query, tt.limit, tt.offset, tt.driver, got, tt.want)
+		}
+	}
+}
+
+func TestCountQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"select * from users", "select count(*) as total from (select * from users) t"},
+		{
+			"with t as (select id, name from users where status = 'A') select * from t",
+			"with t as (select id, name from users where status = 'A') select count(*) as total from (select * from t) t",
+		},
+		{
+			" WITH a AS (select 1 as x), b AS (select x from a) select * from b ",
+			"WITH a AS (select 1 as x), b AS (select x from a) select count(*) as total from (select * from b) t",
+		},
+		{
+			"with t as (select 'select' as s from users) select s from t",
+			"with t as (select 'select' as s from users) select count(*) as total from (select s from t) t",
+		},
+		{"withdrawals", "select count(*) as total from (withdrawals) t"},
+	}
+	for _, tt := range tests {
+		if got := CountQuery(tt.query); got != tt.want {
+			t.Errorf("CountQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	ParamIdentifier = "identifier"
+	ParamEnum       = "enum"
+	ParamSort       = "sort"
+	ParamUnsafe     = "unsafe"
+)
+
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+	sortPattern       = regexp.MustCompile(`(?i)^\s*(order\s+by\s+)?[A-Za-z_][A-Za-z0-9_.]*(\s+(asc|desc))?(\s*,\s*[A-Za-z_][A-Za-z0-9_.]*(\s+(asc|desc))?)*\s*$`)
+	sortRequest       = regexp.MustCompile(`^[+-]?[A-Za-z_][A-Za-z0-9_.]*(,[+-]?[A-Za-z_][A-Za-z0-9_.]*)*$`)
+)
+
+// textTypes are the types of the text parameters, such as ${column:identifier}, ${status:enum(A|I)}, ${sort:sort} and ${where:unsafe}.
+var textTypes = map[string]bool{ParamIdentifier: true, ParamEnum: true, ParamSort: true, ParamUnsafe: true}
+
+// Substitution writes the text parameters into the query, because they cannot be the parameters of the statement, such as the columns of order by:
+//   - ${column:identifier} must be a column of the model, or one of the identifiers, such as ${column:identifier(name|email)}, and is quoted by the dialect
+//   - ${status:enum(A|I)} must be one of the declared values
+//   - ${sort:sort} is built by BuildSort, such as "-name,id", or must be the columns with asc or desc
+//   - ${where:unsafe} is written as is, so it must never be the input of the users
+//   - ${name}, which has no type, is never written, so the values such as "1 or true" cannot be injected; BuildTemplates returns its error
+//
+// If the value is not valid, nothing is written. Quote is the quote of the identifiers of the dialect, such as QuoteDouble.
+type Substitution struct {
+	ModelType   reflect.Type
+	Identifiers []string
+	Quote       func(string) string
+	BuildSort   func(string, reflect.Type) string
+}
+
+func NewSubstitution(modelType *reflect.Type, buildSort func(string, reflect.Type) string, options ...func(*Substitution)) *Substitution {
+	s := &Substitution{BuildSort: buildSort}
+	if modelType != nil {
+		s.ModelType = *modelType
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+	return s
+}
+
+// Text returns the text of the parameter, and false if the value is not valid for the type of the parameter.
+func (s *Substitution) Text(p Parameter, v interface{}) (string, bool) {
+	if s == nil {
+		s = &Substitution{}
+	}
+	if vo := reflect.ValueOf(v); vo.Kind() == reflect.Ptr {
+		if vo.IsNil() {
+			return "", false
+		}
+		v = vo.Elem().Interface()
+	}
+	text := fmt.Sprintf("%v", v)
+	switch p.Type {
+	case ParamUnsafe:
+		return text, true
+	case ParamEnum:
+		return text, contains(p.Values, text)
+	case ParamIdentifier:
+		column, ok := s.column(p, text)
+		if !ok {
+			return "", false
+		}
+		if s.Quote != nil {
+			return s.Quote(column), true
+		}
+		return column, true
+	case ParamSort:
+		if s.BuildSort != nil && s.ModelType != nil && sortRequest.MatchString(text) {
+			text = s.BuildSort(text, s.ModelType)
+		}
+		return text, len(strings.TrimSpace(text)) == 0 || sortPattern.MatchString(text)
+	default:
+		return "", false
+	}
+}
+func (s *Substitution) column(p Parameter, name string) (string, bool) {
+	if len(p.Values) > 0 {
+		return name, contains(p.Values, name)
+	}
+	if len(s.Identifiers) > 0 {
+		return name, contains(s.Identifiers, name)
+	}
+	if s.ModelType != nil {
+		return ColumnOf(s.ModelType, name)
+	}
+	return name, identifierPattern.MatchString(name)
+}
+
+// ColumnOf gets the column of the field by the json name, the field name or the column name of the gorm tag.
+func ColumnOf(modelType reflect.Type, name string) (string, bool) {
+	for modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return "", false
+	}
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		field := modelType.Field(i)
+		column := field.Name
+		if tag, ok := field.Tag.Lookup("gorm"); ok {
+			for _, s := range strings.Split(tag, ";") {
+				if kv := strings.SplitN(s, ":", 2); len(kv) == 2 && kv[0] == "column" {
+					column = kv[1]
+				}
+			}
+		}
+		json := strings.Split(field.Tag.Get("json"), ",")[0]
+		if json == name || field.Name == name || column == name {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// QuoteDouble quotes the identifier for PostgreSQL, Oracle, SQLite and Cassandra, such as "name".
+func QuoteDouble(s string) string {
+	return quote(s, `"`, `"`)
+}
+
+// QuoteBacktick quotes the identifier for MySQL and Hive, such as `name`.
+func QuoteBacktick(s string) string {
+	return quote(s, "`", "`")
+}
+
+// QuoteBracket quotes the identifier for SQL Server, such as [name].
+func QuoteBracket(s string) string {
+	return quote(s, "[", "]")
+}
+
+// QuoteByParam returns the quote of the dialect of the placeholders: "name" for $1 of PostgreSQL, "NAME" for :1 of Oracle, which stores the unquoted identifiers in upper case,
+// [name] for @p1 of SQL Server, and `name` for ? of MySQL and SQLite, which both accept the backticks.
+func QuoteByParam(param func(int) string) func(string) string {
+	if param == nil {
+		return QuoteDouble
+	}
+	p := param(1)
+	switch {
+	case strings.HasPrefix(p, "$"):
+		return QuoteDouble
+	case strings.HasPrefix(p, ":"):
+		return QuoteUpper
+	case strings.HasPrefix(p, "@"):
+		return QuoteBracket
+	case p == "?":
+		return QuoteBacktick
+	}
+	return QuoteDouble
+}
+
+// QuoteUpper quotes the identifier in upper case for Oracle, such as "NAME".
+func QuoteUpper(s string) string {
+	return QuoteDouble(strings.ToUpper(s))
+}
+func quote(s string, open string, close string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = open + strings.Replace(p, close, close+close, -1) + close
+	}
+	return strings.Join(parts, ".")
+}
+
+// checkTextParameters returns the error of the first text parameter which has no type, such as ${name}.
+func checkTextParameters(nodes []TemplateNode) error {
+	for _, n := range nodes {
+		for _, p := range n.Format.Parameters {
+			if p.Type == ParamText {
+				return fmt.Errorf("the text parameter %q has no type, such as ${%s:identifier}, ${%s:sort} or ${%s:unsafe}", p.Name, p.Name, p.Name, p.Name)
+			}
+		}
+		if err := checkTextParameters(n.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTextType parses the type of the text parameter, such as "identifier" or "enum(A|I)".
+func parseTextType(s string) (string, []string, bool) {
+	name, values := s, []string(nil)
+	if i := strings.Index(s, "("); i > 0 && strings.HasSuffix(s, ")") {
+		name = s[:i]
+		for _, v := range strings.Split(s[i+1:len(s)-1], "|") {
+			if v = strings.TrimSpace(v); len(v) > 0 {
+				values = append(values, v)
+			}
+		}
+	}
+	if !textTypes[name] || (name == ParamEnum && len(values) == 0) {
+		return "", nil, false
+	}
+	return name, values, true
+}
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"encoding/xml"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -17,6 +22,17 @@ const (
 	TypeIsNotEqual = "isNotEqual"
 	TypeIsNull     = "isNull"
 	TypeIsNotNull  = "isNotNull"
+	TypeWhere      = "where"
+	TypeSet        = "set"
+	TypeTrim       = "trim"
+	TypeForeach    = "foreach"
+	TypeChoose     = "choose"
+	TypeOtherwise  = "otherwise"
+	TypeIf         = "if"
+	TypeInclude    = "include"
+	TypeProperty   = "property"
+	TypeOrderBy    = "orderBy"
+	TypePaging     = "paging"
 	ParamText      = "text"
 )
 
@@ -38,6 +54,8 @@ type StringFormat struct {
 type Parameter struct {
 	Name string `yaml:"" mapstructure:"name" json:"name,omitempty" gorm:"column:name" bson:"name,omitempty" dynamodbav:"name,omitempty" firestore:"name,omitempty"`
 	Type string `yaml:"" mapstructure:"type" json:"type,omitempty" gorm:"column:type" bson:"type,omitempty" dynamodbav:"type,omitempty" firestore:"type,omitempty"`
+	// Values are the declared values of the text parameter, such as ["A", "I"] of ${status:enum(A|I)}.
+	Values []string `yaml:"" mapstructure:"values" json:"values,omitempty" gorm:"column:values" bson:"values,omitempty" dynamodbav:"values,omitempty" firestore:"values,omitempty"`
 }
 type TemplateNode struct {
 	Type      string       `yaml:"type" mapstructure:"type" json:"type,omitempty" gorm:"column:type" bson:"type,omitempty" dynamodbav:"type,omitempty" firestore:"type,omitempty"`
@@ -49,6 +67,25 @@ type TemplateNode struct {
 	Prefix    string       `yaml:"prefix" mapstructure:"prefix" json:"prefix,omitempty" gorm:"column:prefix" bson:"prefix,omitempty" dynamodbav:"prefix,omitempty" firestore:"prefix,omitempty"`
 	Suffix    string       `yaml:"suffix" mapstructure:"suffix" json:"suffix,omitempty" gorm:"column:suffix" bson:"suffix,omitempty" dynamodbav:"suffix,omitempty" firestore:"suffix,omitempty"`
 	Format    StringFormat `yaml:"format" mapstructure:"format" json:"format,omitempty" gorm:"column:format" bson:"format,omitempty" dynamodbav:"format,omitempty" firestore:"format,omitempty"`
+	// Test is the expression of the condition of the type if, such as "len(ids) > 0 && includeArchived".
+	Test string `yaml:"test" mapstructure:"test" json:"test,omitempty" gorm:"column:test" bson:"test,omitempty" dynamodbav:"test,omitempty" firestore:"test,omitempty"`
+	// PrefixOverrides and SuffixOverrides are the words separated by "|", which are removed from the start and the end of the content of trim, such as "and|or".
+	PrefixOverrides string `yaml:"prefix_overrides" mapstructure:"prefix_overrides" json:"prefixOverrides,omitempty" gorm:"column:prefixoverrides" bson:"prefixOverrides,omitempty" dynamodbav:"prefixOverrides,omitempty" firestore:"prefixOverrides,omitempty"`
+	SuffixOverrides string `yaml:"suffix_overrides" mapstructure:"suffix_overrides" json:"suffixOverrides,omitempty" gorm:"column:suffixoverrides" bson:"suffixOverrides,omitempty" dynamodbav:"suffixOverrides,omitempty" firestore:"suffixOverrides,omitempty"`
+	// Collection, Item, Index, Open and Close are the attributes of foreach, which renders the children for each item of the collection.
+	Collection string `yaml:"collection" mapstructure:"collection" json:"collection,omitempty" gorm:"column:collection" bson:"collection,omitempty" dynamodbav:"collection,omitempty" firestore:"collection,omitempty"`
+	Item       string `yaml:"item" mapstructure:"item" json:"item,omitempty" gorm:"column:item" bson:"item,omitempty" dynamodbav:"item,omitempty" firestore:"item,omitempty"`
+	Index      string `yaml:"index" mapstructure:"index" json:"index,omitempty" gorm:"column:index" bson:"index,omitempty" dynamodbav:"index,omitempty" firestore:"index,omitempty"`
+	Open       string `yaml:"open" mapstructure:"open" json:"open,omitempty" gorm:"column:open" bson:"open,omitempty" dynamodbav:"open,omitempty" firestore:"open,omitempty"`
+	Close      string `yaml:"close" mapstructure:"close" json:"close,omitempty" gorm:"column:close" bson:"close,omitempty" dynamodbav:"close,omitempty" firestore:"close,omitempty"`
+	// RefId is the id of the sql fragment of include, and Properties are the values which replace ${name} of the fragment.
+	RefId      string            `yaml:"ref_id" mapstructure:"ref_id" json:"refId,omitempty" gorm:"column:refid" bson:"refId,omitempty" dynamodbav:"refId,omitempty" firestore:"refId,omitempty"`
+	Properties map[string]string `yaml:"properties" mapstructure:"properties" json:"properties,omitempty" gorm:"column:properties" bson:"properties,omitempty" dynamodbav:"properties,omitempty" firestore:"properties,omitempty"`
+	// Default and Allowed are the attributes of orderBy: the default sort, such as "-createdDate", and the fields which can be sorted, such as "name,createdDate=u.created_date".
+	Default string `yaml:"default" mapstructure:"default" json:"default,omitempty" gorm:"column:default" bson:"default,omitempty" dynamodbav:"default,omitempty" firestore:"default,omitempty"`
+	Allowed string `yaml:"allowed" mapstructure:"allowed" json:"allowed,omitempty" gorm:"column:allowed" bson:"allowed,omitempty" dynamodbav:"allowed,omitempty" firestore:"allowed,omitempty"`
+	// Children are the nested nodes of where, set, trim, foreach, choose, otherwise, and of the conditions which have nested elements.
+	Children []TemplateNode `yaml:"children" mapstructure:"children" json:"children,omitempty" gorm:"column:children" bson:"children,omitempty" dynamodbav:"children,omitempty" firestore:"children,omitempty"`
 }
 type Template struct {
 	Id        string         `yaml:"id" mapstructure:"id" json:"id,omitempty" gorm:"column:id" bson:"id,omitempty" dynamodbav:"id,omitempty" firestore:"id,omitempty"`
@@ -61,137 +98,183 @@ type TStatement struct {
 	Index  int           `yaml:"index" mapstructure:"index" json:"index,omitempty" gorm:"column:index" bson:"index,omitempty" dynamodbav:"index,omitempty" firestore:"index,omitempty"`
 }
 
+// LoadTemplates loads the templates of the files, which can be the glob patterns, such as "configs/*.xml", or the directories. The default file is "configs/query.xml".
+// The files are parsed by their extensions: ".yaml", ".yml" and ".json" are the TemplateFile, and the others are xml, which are corrected by trim.
+// The sql fragments are shared by the templates of all the files.
 func LoadTemplates(trim func(string) string, files ...string) (map[string]*Template, error) {
-	if len(files) == 0 {
-		return loadTemplates(trim, "configs/query.xml")
-	}
-	return loadTemplates(trim, files...)
+	return LoadTemplatesFS(nil, trim, files...)
 }
-func loadTemplates(trim func(string) string, files ...string) (map[string]*Template, error) {
-	l := len(files)
-	f0, er0 := ReadFile(files[0])
-	if er0 != nil {
-		return nil, er0
+
+// LoadTemplatesFS loads the templates of the files in the file system, such as the files embedded by go:embed. If the file system is nil, the files are read from the OS.
+func LoadTemplatesFS(fsys fs.FS, trim func(string) string, patterns ...string) (map[string]*Template, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"configs/query.xml"}
 	}
-	if trim != nil {
-		f0 = trim(f0)
+	files, err := Glob(fsys, patterns...)
+	if err != nil {
+		return nil, err
 	}
-	templates, er0 := BuildTemplates(f0)
-	if er0 != nil {
-		return nil, er0
+	return loadTemplates(fsys, trim, files...)
+}
+func loadTemplates(fsys fs.FS, trim func(string) string, files ...string) (map[string]*Template, error) {
+	templates := make(map[string]*Template)
+	fragments := make(map[string]*Template)
+	for _, filename := range files {
+		var file string
+		var err error
+		if fsys == nil {
+			file, err = ReadFile(filename)
+		} else {
+			var content []byte
+			content, err = fs.ReadFile(fsys, filename)
+			file = string(content)
+		}
+		if err != nil {
+			return nil, err
+		}
+		var sub, subFragments map[string]*Template
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".yaml", ".yml":
+			sub, subFragments, err = BuildYAMLTemplatesAndFragments(file)
+		case ".json":
+			sub, subFragments, err = BuildJSONTemplatesAndFragments(file)
+		default:
+			if trim != nil {
+				file = trim(file)
+			}
+			sub, subFragments, err = BuildTemplatesAndFragments(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		for key, element := range sub {
+			templates[key] = element
+		}
+		for key, element := range subFragments {
+			fragments[key] = element
+		}
+	}
+	if err := ResolveIncludes(templates, fragments); err != nil {
+		return nil, err
 	}
-	if l >= 2 {
-		for i := 1; i < l; i++ {
-			file, err := ReadFile(files[i])
+	return templates, nil
+}
+
+// Glob returns the files of the patterns, in the order of the patterns and the names. The files of a directory are the xml, yaml and json files in it and its sub directories.
+func Glob(fsys fs.FS, patterns ...string) ([]string, error) {
+	files := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		var matches []string
+		var err error
+		if fsys == nil {
+			matches, err = filepath.Glob(pattern)
+		} else {
+			matches, err = fs.Glob(fsys, pattern)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no template file matches %s", pattern)
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			var info fs.FileInfo
+			if fsys == nil {
+				info, err = os.Stat(match)
+			} else {
+				info, err = fs.Stat(fsys, match)
+			}
 			if err != nil {
-				return templates, err
+				return nil, err
 			}
-			sub, er := BuildTemplates(file)
-			if er0 != nil {
-				return templates, er
+			names := []string{match}
+			if info.IsDir() {
+				if names, err = walkDir(fsys, match); err != nil {
+					return nil, err
+				}
 			}
-			for key, element := range sub {
-				templates[key] = element
+			for _, name := range names {
+				if !seen[name] {
+					seen[name] = true
+					files = append(files, name)
+				}
 			}
 		}
 	}
-	return templates, nil
+	return files, nil
+}
+
+var templateExts = map[string]bool{".xml": true, ".yaml": true, ".yml": true, ".json": true}
+
+func walkDir(fsys fs.FS, dir string) ([]string, error) {
+	names := make([]string, 0)
+	walk := func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && templateExts[strings.ToLower(filepath.Ext(path))] {
+			names = append(names, path)
+		}
+		return nil
+	}
+	var err error
+	if fsys == nil {
+		err = filepath.WalkDir(dir, walk)
+	} else {
+		err = fs.WalkDir(fsys, dir, walk)
+	}
+	return names, err
 }
 func BuildTemplates(stream string) (map[string]*Template, error) {
+	ts, fragments, err := BuildTemplatesAndFragments(stream)
+	if err != nil {
+		return nil, err
+	}
+	if err = ResolveIncludes(ts, fragments); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// BuildTemplatesAndFragments builds the templates of select, insert, update and delete, and the fragments of sql, without resolving the includes,
+// so the fragments can be shared by the templates of the other files.
+func BuildTemplatesAndFragments(stream string) (map[string]*Template, map[string]*Template, error) {
 	data := []byte(stream)
 	buf := bytes.NewBuffer(data)
 	dec := xml.NewDecoder(buf)
-	ns := make([]TemplateNode, 0)
 	ts := make(map[string]*Template)
-	texts := make([]string, 0)
-	start := false
-	id := ""
+	fragments := make(map[string]*Template)
 	for {
 		token, er0 := dec.Token()
 		if token == nil {
 			break
 		}
 		if er0 != nil {
-			return nil, er0
+			return nil, nil, er0
 		}
-		switch element := token.(type) {
-		case xml.CharData:
-			if start == true {
-				s := string([]byte(element))
-				if !isEmptyNode(s) {
-					n := TemplateNode{Type: "text", Text: s}
-					texts = append(texts, s)
-					n.Format = buildFormat(n.Text)
-					ns = append(ns, n)
-				}
-			}
-		case xml.EndElement:
+		if element, ok := token.(xml.StartElement); ok {
 			n := element.Name.Local
-			if n == "select" || n == "insert" || n == "update" || n == "delete" {
+			if n == "select" || n == "insert" || n == "update" || n == "delete" || n == "sql" {
+				id := getValue(element.Attr, "id")
+				p := &parser{dec: dec, data: data, id: id}
+				ns, texts, er1 := p.parseNodes()
+				if er1 != nil {
+					return nil, nil, er1
+				}
 				t := Template{Id: id}
 				t.Text = strings.Join(texts, " ")
 				t.Templates = ns
-				ts[id] = &t
-				ns = make([]TemplateNode, 0)
-				start = false
-			}
-		case xml.StartElement:
-			n := element.Name.Local
-			if n == "select" || n == "insert" || n == "update" || n == "delete" {
-				id = getValue(element.Attr, "id")
-				texts = make([]string, 0)
-				start = true
-			} else {
-				if element.Name.Local == "if" {
-					test := getValue(element.Attr, "test")
-					if len(test) > 0 {
-						n := buildIf(test)
-						if n != nil {
-							n.Array = getValue(element.Attr, "array")
-							n.Prefix = getValue(element.Attr, "prefix")
-							n.Suffix = getValue(element.Attr, "suffix")
-							n.Separator = getValue(element.Attr, "separator")
-							sub, er1 := dec.Token()
-							if er1 != nil {
-								return nil, er1
-							}
-							switch inner := sub.(type) {
-							case xml.CharData:
-								s2 := string([]byte(inner))
-								n.Text = s2
-								n.Format = buildFormat(n.Text)
-								texts = append(texts, s2)
-							}
-							ns = append(ns, *n)
-						}
-					}
+				if n == "sql" {
+					fragments[id] = &t
 				} else {
-					if isEmptyNode(element.Name.Local) {
-						property := getValue(element.Attr, "property")
-						v := getValue(element.Attr, "value")
-						array := getValue(element.Attr, "array")
-						prefix := getValue(element.Attr, "prefix")
-						suffix := getValue(element.Attr, "suffix")
-						separator := getValue(element.Attr, "separator")
-						n := TemplateNode{Type: element.Name.Local, Property: property, Value: v, Array: array, Prefix: prefix, Suffix: suffix, Separator: separator}
-						sub, er1 := dec.Token()
-						if er1 != nil {
-							return nil, er1
-						}
-						switch inner := sub.(type) {
-						case xml.CharData:
-							s2 := string([]byte(inner))
-							n.Text = s2
-							n.Format = buildFormat(n.Text)
-							texts = append(texts, s2)
-						}
-						ns = append(ns, n)
-					}
+					ts[id] = &t
 				}
 			}
 		}
 	}
-	return ts, nil
+	return ts, fragments, nil
 }
 func isEmptyNode(s string) bool {
 	v := strings.Replace(s, "\n", " ", -1)
@@ -204,78 +287,159 @@ func BuildTemplate(stream string) (*Template, error) {
 	data := []byte(stream)
 	buf := bytes.NewBuffer(data)
 	dec := xml.NewDecoder(buf)
+	p := &parser{dec: dec, data: data}
+	ns, texts, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	t := Template{}
+	t.Text = strings.Join(texts, " ")
+	t.Templates = ns
+	return &t, nil
+}
+
+// parser parses the nodes of the xml templates. The whitespace between the elements is kept as a space, so the rendered parts are not joined,
+// such as "status = #{status} and id in (...)".
+type parser struct {
+	dec  *xml.Decoder
+	data []byte
+	id   string
+}
+
+// parseNodes parses the nodes until the end of the current element, and returns the nodes and all the texts, including the texts of the nested nodes.
+func (p *parser) parseNodes() ([]TemplateNode, []string, error) {
 	ns := make([]TemplateNode, 0)
 	texts := make([]string, 0)
 	for {
-		token, er0 := dec.Token()
+		token, er0 := p.dec.Token()
 		if token == nil {
 			break
 		}
 		if er0 != nil {
-			return nil, er0
+			return nil, nil, er0
 		}
 		switch element := token.(type) {
 		case xml.CharData:
 			s := string([]byte(element))
-			if s != "\n" {
-				n := TemplateNode{Type: "text", Text: s}
+			if isEmptyNode(s) {
+				if len(s) > 0 {
+					ns = append(ns, textNode(" "))
+				}
+			} else {
+				ns = append(ns, textNode(s))
 				texts = append(texts, s)
-				n.Format = buildFormat(n.Text)
-				ns = append(ns, n)
 			}
+		case xml.EndElement:
+			return ns, texts, nil
 		case xml.StartElement:
-			if element.Name.Local == "if" {
-				test := getValue(element.Attr, "test")
-				if len(test) > 0 {
-					n := buildIf(test)
-					if n != nil {
-						n.Array = getValue(element.Attr, "array")
-						n.Prefix = getValue(element.Attr, "prefix")
-						n.Suffix = getValue(element.Attr, "suffix")
-						n.Separator = getValue(element.Attr, "separator")
-						sub, er1 := dec.Token()
-						if er1 != nil {
-							return nil, er1
-						}
-						switch inner := sub.(type) {
-						case xml.CharData:
-							s2 := string([]byte(inner))
-							n.Text = s2
-							n.Format = buildFormat(n.Text)
-							texts = append(texts, s2)
-						}
-						ns = append(ns, *n)
-					}
+			sub, subTexts, er1 := p.parseNode(element)
+			if er1 != nil {
+				return nil, nil, er1
+			}
+			ns = append(ns, sub...)
+			texts = append(texts, subTexts...)
+		}
+	}
+	return ns, texts, nil
+}
+func (p *parser) parseNode(element xml.StartElement) ([]TemplateNode, []string, error) {
+	line := 1 + bytes.Count(p.data[:p.dec.InputOffset()], []byte("\n"))
+	children, texts, err := p.parseNodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs := element.Attr
+	name := element.Name.Local
+	switch name {
+	case TypeWhere, TypeSet, TypeOtherwise:
+		return []TemplateNode{{Type: name, Children: children}}, texts, nil
+	case TypeTrim:
+		n := TemplateNode{Type: name, Prefix: getValue(attrs, "prefix"), Suffix: getValue(attrs, "suffix"), Children: children}
+		n.PrefixOverrides = getValue(attrs, "prefixOverrides")
+		n.SuffixOverrides = getValue(attrs, "suffixOverrides")
+		return []TemplateNode{n}, texts, nil
+	case TypeForeach:
+		n := TemplateNode{Type: name, Collection: getValue(attrs, "collection"), Item: getValue(attrs, "item"), Index: getValue(attrs, "index"), Children: children}
+		n.Separator = getValue(attrs, "separator")
+		n.Open = getValue(attrs, "open")
+		n.Close = getValue(attrs, "close")
+		return []TemplateNode{n}, texts, nil
+	case TypeInclude:
+		n := TemplateNode{Type: name, RefId: getValue(attrs, "refid")}
+		for _, c := range children {
+			if c.Type == TypeProperty {
+				if n.Properties == nil {
+					n.Properties = make(map[string]string)
 				}
-			} else {
-				if isEmptyNode(element.Name.Local) {
-					property := getValue(element.Attr, "property")
-					v := getValue(element.Attr, "value")
-					array := getValue(element.Attr, "array")
-					prefix := getValue(element.Attr, "prefix")
-					suffix := getValue(element.Attr, "suffix")
-					separator := getValue(element.Attr, "separator")
-					n := TemplateNode{Type: element.Name.Local, Property: property, Value: v, Array: array, Prefix: prefix, Suffix: suffix, Separator: separator}
-					sub, er1 := dec.Token()
-					if er1 != nil {
-						return nil, er1
-					}
-					switch inner := sub.(type) {
-					case xml.CharData:
-						s2 := string([]byte(inner))
-						n.Text = s2
-						n.Format = buildFormat(n.Text)
-						texts = append(texts, s2)
-					}
-					ns = append(ns, n)
+				n.Properties[c.Property] = c.Value
+			}
+		}
+		return []TemplateNode{n}, texts, nil
+	case TypeProperty:
+		return []TemplateNode{{Type: name, Property: getValue(attrs, "name"), Value: getValue(attrs, "value")}}, texts, nil
+	case TypeOrderBy:
+		return []TemplateNode{{Type: name, Default: getValue(attrs, "default"), Allowed: getValue(attrs, "allowed")}}, texts, nil
+	case TypePaging:
+		return []TemplateNode{{Type: name}}, texts, nil
+	case TypeChoose:
+		n := TemplateNode{Type: name, Children: make([]TemplateNode, 0)}
+		for _, c := range children {
+			if c.Type != TypeText {
+				n.Children = append(n.Children, c)
+			}
+		}
+		return []TemplateNode{n}, texts, nil
+	case "if", "when":
+		test := getValue(attrs, "test")
+		if len(test) == 0 {
+			return nil, texts, nil
+		}
+		n := buildIf(test)
+		if n == nil {
+			if _, er1 := CompileExpression(test); er1 != nil {
+				if len(p.id) > 0 {
+					return nil, nil, fmt.Errorf("template %s, line %d: invalid test %q: %w", p.id, line, test, er1)
 				}
+				return nil, nil, fmt.Errorf("line %d: invalid test %q: %w", line, test, er1)
 			}
+			n = &TemplateNode{Type: TypeIf, Test: test}
 		}
+		n.Array = getValue(attrs, "array")
+		n.Prefix = getValue(attrs, "prefix")
+		n.Suffix = getValue(attrs, "suffix")
+		n.Separator = getValue(attrs, "separator")
+		setContent(n, children)
+		return []TemplateNode{*n}, texts, nil
 	}
-	t := Template{}
-	t.Text = strings.Join(texts, " ")
-	t.Templates = ns
-	return &t, nil
+	if isValidNode(name) {
+		property := getValue(attrs, "property")
+		v := getValue(attrs, "value")
+		array := getValue(attrs, "array")
+		prefix := getValue(attrs, "prefix")
+		suffix := getValue(attrs, "suffix")
+		separator := getValue(attrs, "separator")
+		n := TemplateNode{Type: name, Property: property, Value: v, Array: array, Prefix: prefix, Suffix: suffix, Separator: separator}
+		setContent(&n, children)
+		return []TemplateNode{n}, texts, nil
+	}
+	return children, texts, nil
+}
+
+// setContent sets the text of the condition if it has only the texts, or the children if it has the nested elements.
+func setContent(n *TemplateNode, children []TemplateNode) {
+	texts := make([]string, 0, len(children))
+	for _, c := range children {
+		if c.Type != TypeText {
+			n.Children = children
+			return
+		}
+		texts = append(texts, c.Text)
+	}
+	n.Text = strings.Join(texts, "")
+	n.Format = buildFormat(n.Text)
+}
+func textNode(s string) TemplateNode {
+	return TemplateNode{Type: TypeText, Text: s, Format: buildFormat(s)}
 }
 func getValue(attrs []xml.Attr, name string) string {
 	if len(attrs) <= 0 {
@@ -302,9 +466,8 @@ func buildFormat(str string) StringFormat {
 			j = strings.Index(str3, "}")
 			if j >= 0 {
 				pro := str2b[i+1 : i+j+1]
-				if isValidProperty(pro) {
-					p := Parameter{}
-					p.Name = pro
+				p, valid := buildParameter(pro, i >= 1 && str2b[i-1] == '$')
+				if valid {
 					if i >= 1 {
 						var chr = string(str2b[i-1])
 						if chr == "#" {
@@ -312,7 +475,9 @@ func buildFormat(str string) StringFormat {
 							p.Type = "param"
 						} else if chr == "$" {
 							texts = append(texts, str2[:from+i-1])
-							p.Type = "text"
+							if len(p.Type) == 0 {
+								p.Type = "text"
+							}
 						} else {
 							texts = append(texts, str2[:from+i])
 							p.Type = "text"
@@ -327,11 +492,11 @@ func buildFormat(str string) StringFormat {
 					str2b = str2
 					from = 0
 				} else {
-					from = i + 1
-					str2b = str2[i+1:]
+					from = from + i + 1
+					str2b = str2[from:]
 				}
 			} else {
-				from = i + 1
+				from = from + i + 1
 				str2b = str2[from:]
 			}
 		} else {
@@ -344,75 +509,46 @@ func buildFormat(str string) StringFormat {
 	f.Parameters = parameters
 	return f
 }
+
+// buildParameter builds the parameter of the name, and the type of the text parameter, such as ${column:identifier} or ${status:enum(A|I)}.
+func buildParameter(s string, text bool) (Parameter, bool) {
+	if i := strings.Index(s, ":"); i > 0 && text {
+		t, values, ok := parseTextType(s[i+1:])
+		return Parameter{Name: s[:i], Type: t, Values: values}, ok && isValidProperty(s[:i])
+	}
+	return Parameter{Name: s}, len(s) > 0 && isValidProperty(s)
+}
+
+// RenderTemplateNodes renders the nodes. The orderBy node is rendered by its default sort, and the paging node is not rendered.
 func RenderTemplateNodes(obj map[string]interface{}, templateNodes []TemplateNode) []TemplateNode {
-	nodes := make([]TemplateNode, 0)
-	for _, sub := range templateNodes {
-		t := sub.Type
-		if t == TypeText {
-			nodes = append(nodes, sub)
-		} else {
-			attr := ValueOf(obj, sub.Property)
-			if t == TypeIsNotNull {
-				if attr != nil {
-					vo := reflect.Indirect(reflect.ValueOf(attr))
-					if vo.Kind() == reflect.Slice {
-						if vo.Len() > 0 {
-							nodes = append(nodes, sub)
-						}
-					} else {
-						nodes = append(nodes, sub)
-					}
-				} else {
-					vo := reflect.Indirect(reflect.ValueOf(attr))
-					if vo.Kind() == reflect.Slice {
-						if vo.Len() > 0 {
-							nodes = append(nodes, sub)
-						}
-					}
-				}
-			} else if t == TypeIsNull {
-				if attr == nil {
-					nodes = append(nodes, sub)
-				} else {
-					vo := reflect.Indirect(reflect.ValueOf(attr))
-					if vo.Kind() == reflect.Slice {
-						if vo.Len() == 0 {
-							nodes = append(nodes, sub)
-						}
-					}
-				}
-			} else if t == TypeIsEqual {
-				if attr != nil {
-					s := fmt.Sprintf("%v", attr)
-					if sub.Value == s {
-						nodes = append(nodes, sub)
-					}
-				}
-			} else if t == TypeIsNotEqual {
-				if attr != nil {
-					s := fmt.Sprintf("%v", attr)
-					if sub.Value != s {
-						nodes = append(nodes, sub)
-					}
-				}
-			} else if t == TypeIsEmpty {
-				if attr != nil {
-					s := fmt.Sprintf("%v", attr)
-					if len(s) == 0 {
-						nodes = append(nodes, sub)
-					}
-				}
-			} else if t == TypeIsNotEmpty {
-				if attr != nil {
-					s := fmt.Sprintf("%v", attr)
-					if len(s) > 0 {
-						nodes = append(nodes, sub)
-					}
-				}
+	return renderNodes(obj, templateNodes, scope{})
+}
+func isTrue(t string, attr interface{}, value string) bool {
+	if t == TypeIsNotNull {
+		if attr != nil {
+			vo := reflect.Indirect(reflect.ValueOf(attr))
+			if vo.Kind() == reflect.Slice {
+				return vo.Len() > 0
 			}
+			return true
 		}
+		return false
+	} else if t == TypeIsNull {
+		if attr == nil {
+			return true
+		}
+		vo := reflect.Indirect(reflect.ValueOf(attr))
+		return vo.Kind() == reflect.Slice && vo.Len() == 0
+	} else if t == TypeIsEqual {
+		return attr != nil && value == fmt.Sprintf("%v", attr)
+	} else if t == TypeIsNotEqual {
+		return attr != nil && value != fmt.Sprintf("%v", attr)
+	} else if t == TypeIsEmpty {
+		return attr != nil && len(fmt.Sprintf("%v", attr)) == 0
+	} else if t == TypeIsNotEmpty {
+		return attr != nil && len(fmt.Sprintf("%v", attr)) > 0
 	}
-	return nodes
+	return false
 }
 func isValidProperty(v string) bool {
 	var len = len(v) - 1
@@ -424,27 +560,64 @@ func isValidProperty(v string) bool {
 	}
 	return true
 }
+
+// ValueOf gets the value of the path, such as "user.name" or "ids.0". The path goes through the maps, the slices by the index and the structs by the json name.
 func ValueOf(m interface{}, path string) interface{} {
 	arr := strings.Split(path, ".")
-	i := 0
 	var c interface{}
 	c = m
 	l1 := len(arr) - 1
-	for i < len(arr) {
-		key := arr[i]
-		m2, ok := c.(map[string]interface{})
-		if ok {
+	for i, key := range arr {
+		if m2, ok := c.(map[string]interface{}); ok {
 			c = m2[key]
+		} else if v, ok := indexOf(c, key); ok {
+			c = v
+		} else {
+			return c
 		}
-		if !ok || i >= l1 {
+		if i >= l1 {
 			return c
 		}
-		i++
 	}
 	return c
 }
+func indexOf(c interface{}, key string) (interface{}, bool) {
+	vo := reflect.Indirect(reflect.ValueOf(c))
+	switch vo.Kind() {
+	case reflect.Slice, reflect.Array:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= vo.Len() {
+			return nil, true
+		}
+		return vo.Index(i).Interface(), true
+	case reflect.Map:
+		if vo.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		v := vo.MapIndex(reflect.ValueOf(key).Convert(vo.Type().Key()))
+		if !v.IsValid() {
+			return nil, true
+		}
+		return v.Interface(), true
+	case reflect.Struct:
+		t := vo.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() && (f.Name == key || strings.Split(f.Tag.Get("json"), ",")[0] == key) {
+				return vo.Field(i).Interface(), true
+			}
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+// buildIf builds the simple tests, such as "status != null" or "status == 'A'", as the conditions. The other tests are the expressions.
 func buildIf(t string) *TemplateNode {
 	i := strings.Index(t, "!=")
+	if i > 0 && !isSimpleTest(t[0:i], t[i+2:]) {
+		return nil
+	}
 	if i > 0 {
 		s1 := strings.TrimSpace(t[0:i])
 		s2 := strings.TrimSpace(t[i+2:])
@@ -457,7 +630,7 @@ func buildIf(t string) *TemplateNode {
 		}
 	} else {
 		i = strings.Index(t, "==")
-		if i > 0 {
+		if i > 0 && isSimpleTest(t[0:i], t[i+2:]) {
 			s1 := strings.TrimSpace(t[0:i])
 			s2 := strings.TrimSpace(t[i+2:])
 			if len(s1) > 0 {
@@ -471,6 +644,17 @@ func buildIf(t string) *TemplateNode {
 	}
 	return nil
 }
+func isSimpleTest(property string, value string) bool {
+	property = strings.TrimSpace(property)
+	value = strings.TrimSpace(value)
+	if len(property) == 0 || len(value) == 0 || !isValidProperty(property) {
+		return false
+	}
+	if l := len(value) - 1; l > 0 && (value[0] == '\'' || value[0] == '"') {
+		return value[l] == value[0] && !strings.ContainsAny(value[1:l], `'"`)
+	}
+	return isValidProperty(value)
+}
 func trimQ(s string) string {
 	if strings.HasPrefix(s, "'") {
 		s = s[1:]
@@ -478,9 +662,9 @@ func trimQ(s string) string {
 		s = s[1:]
 	}
 	if strings.HasSuffix(s, "'") {
-		s = s[len(s)-1:]
+		s = s[:len(s)-1]
 	} else if strings.HasSuffix(s, `"`) {
-		s = s[len(s)-1:]
+		s = s[:len(s)-1]
 	}
 	return s
 }
@@ -0,0 +1,191 @@
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateFile is the yaml or json file of the templates and the sql fragments, which are the same structures as the xml templates, such as:
+//
+//	templates:
+//	  - id: user
+//	    templates:
+//	      - text: select * from users
+//	      - type: where
+//	        children:
+//	          - test: len(ids) > 0
+//	            text: "and id in (#{ids})"
+//	          - type: isNotNull
+//	            property: age.min
+//	            text: "and age >= #{age.min}"
+//
+// The type of the node is "if" if it has the test, or "text". The texts are separated by the spaces, as the lines of the xml templates,
+// and they are not escaped, so they can have the operators such as "<", ">" and "->>". In yaml, the texts which have " #{" must be quoted,
+// or be the block scalars, because " #" starts a comment.
+type TemplateFile struct {
+	Templates []Template `yaml:"templates" mapstructure:"templates" json:"templates,omitempty" gorm:"column:templates" bson:"templates,omitempty" dynamodbav:"templates,omitempty" firestore:"templates,omitempty"`
+	Fragments []Template `yaml:"fragments" mapstructure:"fragments" json:"fragments,omitempty" gorm:"column:fragments" bson:"fragments,omitempty" dynamodbav:"fragments,omitempty" firestore:"fragments,omitempty"`
+}
+
+func BuildYAMLTemplates(stream string) (map[string]*Template, error) {
+	ts, fragments, err := BuildYAMLTemplatesAndFragments(stream)
+	if err != nil {
+		return nil, err
+	}
+	if err = ResolveIncludes(ts, fragments); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+func BuildJSONTemplates(stream string) (map[string]*Template, error) {
+	ts, fragments, err := BuildJSONTemplatesAndFragments(stream)
+	if err != nil {
+		return nil, err
+	}
+	if err = ResolveIncludes(ts, fragments); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// BuildYAMLTemplatesAndFragments builds the templates and the fragments of the yaml file, without resolving the includes. The unknown fields are the errors.
+func BuildYAMLTemplatesAndFragments(stream string) (map[string]*Template, map[string]*Template, error) {
+	var file TemplateFile
+	dec := yaml.NewDecoder(strings.NewReader(stream))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, err
+	}
+	return buildTemplateFile(file)
+}
+
+// BuildJSONTemplatesAndFragments builds the templates and the fragments of the json file, without resolving the includes. The unknown fields are the errors.
+func BuildJSONTemplatesAndFragments(stream string) (map[string]*Template, map[string]*Template, error) {
+	var file TemplateFile
+	dec := json.NewDecoder(bytes.NewReader([]byte(stream)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, nil, err
+	}
+	return buildTemplateFile(file)
+}
+func buildTemplateFile(file TemplateFile) (map[string]*Template, map[string]*Template, error) {
+	ts := make(map[string]*Template)
+	fragments := make(map[string]*Template)
+	for _, group := range []struct {
+		templates []Template
+		m         map[string]*Template
+	}{{file.Templates, ts}, {file.Fragments, fragments}} {
+		for i := range group.templates {
+			t := group.templates[i]
+			if len(t.Id) == 0 {
+				return nil, nil, fmt.Errorf("the id of the template %d is required", i+1)
+			}
+			if _, ok := group.m[t.Id]; ok {
+				return nil, nil, fmt.Errorf("duplicate template %s", t.Id)
+			}
+			if len(t.Templates) == 0 && len(t.Text) > 0 {
+				t.Templates = []TemplateNode{{Text: t.Text}}
+			}
+			nodes, err := normalizeNodes(t.Templates)
+			if err != nil {
+				return nil, nil, fmt.Errorf("template %s: %w", t.Id, err)
+			}
+			t.Templates = nodes
+			t.Text = strings.Join(collectTexts(nodes), " ")
+			group.m[t.Id] = &t
+		}
+	}
+	return ts, fragments, nil
+}
+
+// normalizeNodes sets the types, the formats of the texts and the conditions of the nodes, as the xml parser does.
+func normalizeNodes(nodes []TemplateNode) ([]TemplateNode, error) {
+	results := make([]TemplateNode, 0, len(nodes))
+	for _, n := range nodes {
+		if len(n.Type) == 0 {
+			if len(n.Test) > 0 {
+				n.Type = TypeIf
+			} else {
+				n.Type = TypeText
+			}
+		}
+		switch n.Type {
+		case TypeText:
+			results = append(results, textNode(spaced(n.Text)))
+			continue
+		case TypeIf, "when":
+			if len(n.Test) == 0 {
+				return nil, fmt.Errorf("the test of %s is required", n.Type)
+			}
+			c := buildIf(n.Test)
+			if c == nil {
+				if _, err := CompileExpression(n.Test); err != nil {
+					return nil, fmt.Errorf("invalid test %q: %w", n.Test, err)
+				}
+				c = &TemplateNode{Type: TypeIf, Test: n.Test}
+			}
+			c.Array, c.Prefix, c.Suffix, c.Separator, c.Text, c.Children = n.Array, n.Prefix, n.Suffix, n.Separator, n.Text, n.Children
+			n = *c
+		case TypeInclude:
+			if len(n.RefId) == 0 {
+				return nil, errors.New("the refid of include is required")
+			}
+			results = append(results, n)
+			continue
+		case TypeOrderBy, TypePaging:
+			results = append(results, TemplateNode{Type: n.Type, Default: n.Default, Allowed: n.Allowed})
+			continue
+		case TypeChoose:
+			for _, c := range n.Children {
+				if c.Type != TypeOtherwise && c.Type != TypeIf && c.Type != "when" && len(c.Test) == 0 {
+					return nil, fmt.Errorf("choose can have only when and otherwise, but not %q", c.Type)
+				}
+			}
+		case TypeWhere, TypeSet, TypeTrim, TypeForeach, TypeOtherwise:
+		default:
+			if !isValidNode(n.Type) {
+				return nil, fmt.Errorf("unknown node type %q", n.Type)
+			}
+			if len(n.Property) == 0 {
+				return nil, fmt.Errorf("the property of %s is required", n.Type)
+			}
+		}
+		if err := setNodeContent(&n); err != nil {
+			return nil, err
+		}
+		results = append(results, n)
+	}
+	return results, nil
+}
+
+// setNodeContent sets the format of the text of the node, or normalizes the children. The text is the first child if the node has both.
+func setNodeContent(n *TemplateNode) error {
+	if len(n.Children) == 0 {
+		if len(n.Text) > 0 {
+			n.Text = spaced(n.Text)
+			n.Format = buildFormat(n.Text)
+		}
+		return nil
+	}
+	children := n.Children
+	if len(n.Text) > 0 {
+		children = append([]TemplateNode{{Type: TypeText, Text: n.Text}}, children...)
+		n.Text = ""
+	}
+	nodes, err := normalizeNodes(children)
+	if err != nil {
+		return err
+	}
+	n.Children = nodes
+	return nil
+}
+func spaced(s string) string {
+	return " " + strings.TrimSpace(s) + " "
+}
@@ -0,0 +1,136 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+const (
+	// Similarity is the sort field to order by the similarity to the vector of VectorQuery, such as "-similarity".
+	Similarity = "similarity"
+
+	Cosine     = "cosine"
+	L2Norm     = "l2_norm"
+	DotProduct = "dot_product"
+)
+
+// VectorQuery filters the K nearest neighbors of Vector, whose score is at least MinScore. Metric is cosine, l2_norm or dot_product, and cosine is the default.
+// The score is between 0 and 1, the same as the score of Elasticsearch: (1 + cosine) / 2, 1 / (1 + l2_norm^2) or (1 + dot_product) / 2.
+// The model receives the score by the field with the tag `vector:"score"`.
+type VectorQuery struct {
+	Vector   []float32 `yaml:"vector" mapstructure:"vector" json:"vector,omitempty" gorm:"column:vector" bson:"vector,omitempty" dynamodbav:"vector,omitempty" firestore:"vector,omitempty"`
+	K        int64     `yaml:"k" mapstructure:"k" json:"k,omitempty" gorm:"column:k" bson:"k,omitempty" dynamodbav:"k,omitempty" firestore:"k,omitempty"`
+	Metric   string    `yaml:"metric" mapstructure:"metric" json:"metric,omitempty" gorm:"column:metric" bson:"metric,omitempty" dynamodbav:"metric,omitempty" firestore:"metric,omitempty"`
+	MinScore float64   `yaml:"min_score" mapstructure:"min_score" json:"minScore,omitempty" gorm:"column:minscore" bson:"minScore,omitempty" dynamodbav:"minScore,omitempty" firestore:"minScore,omitempty"`
+}
+
+func (q VectorQuery) IsEmpty() bool {
+	return len(q.Vector) == 0
+}
+
+// GetMetric returns the metric, or cosine if it is empty.
+func (q VectorQuery) GetMetric() string {
+	if len(q.Metric) == 0 {
+		return Cosine
+	}
+	return q.Metric
+}
+
+// VectorScore computes the score of the two vectors by the metric, the greater the more similar. The vectors must have the same dimension.
+func VectorScore(metric string, a []float32, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("the dimension of the vectors must be the same, but it is %d and %d", len(a), len(b))
+	}
+	n := len(a)
+	var dot, normA, normB, l2 float64
+	for i := 0; i < n; i++ {
+		x, y := float64(a[i]), float64(b[i])
+		dot += x * y
+		normA += x * x
+		normB += y * y
+		l2 += (x - y) * (x - y)
+	}
+	switch metric {
+	case L2Norm:
+		return 1 / (1 + l2), nil
+	case DotProduct:
+		return (1 + dot) / 2, nil
+	default:
+		if normA == 0 || normB == 0 {
+			return 0, nil
+		}
+		return (1 + dot/math.Sqrt(normA*normB)) / 2, nil
+	}
+}
+
+// SearchVectors is the brute-force search in memory: it scores the models which pass the filter, such as the regular conditions of the search,
+// and returns the K models with the greatest scores, which are at least MinScore. The score is set to the field with the tag `vector:"score"`.
+// It returns the error if the dimension of a vector is not the dimension of the vector of the query.
+func SearchVectors[T any](models []T, q VectorQuery, getVector func(*T) []float32, filter func(*T) bool) ([]T, error) {
+	type scored struct {
+		index int
+		score float64
+	}
+	metric := q.GetMetric()
+	candidates := make([]scored, 0)
+	for i := range models {
+		if filter != nil && !filter(&models[i]) {
+			continue
+		}
+		v := getVector(&models[i])
+		if len(v) == 0 {
+			continue
+		}
+		score, err := VectorScore(metric, q.Vector, v)
+		if err != nil {
+			return nil, err
+		}
+		if score >= q.MinScore {
+			candidates = append(candidates, scored{index: i, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if q.K > 0 && int64(len(candidates)) > q.K {
+		candidates = candidates[:q.K]
+	}
+	var t T
+	scoreIndex := FindVectorScoreField(reflect.TypeOf(t))
+	results := make([]T, 0, len(candidates))
+	for _, c := range candidates {
+		m := models[c.index]
+		if scoreIndex >= 0 {
+			v := reflect.Indirect(reflect.ValueOf(&m).Elem())
+			if v.Kind() == reflect.Struct {
+				if f := v.Field(scoreIndex); f.CanSet() && (f.Kind() == reflect.Float64 || f.Kind() == reflect.Float32) {
+					f.SetFloat(c.score)
+				}
+			}
+		}
+		results = append(results, m)
+	}
+	return results, nil
+}
+
+// FindVectorScoreField finds the index of the field with the tag `vector:"score"`, or -1 if there is no such field.
+func FindVectorScoreField(modelType reflect.Type) int {
+	if modelType == nil {
+		return -1
+	}
+	if modelType.Kind() == reflect.Ptr {
+		modelType = modelType.Elem()
+	}
+	if modelType.Kind() != reflect.Struct {
+		return -1
+	}
+	numField := modelType.NumField()
+	for i := 0; i < numField; i++ {
+		if modelType.Field(i).Tag.Get("vector") == "score" {
+			return i
+		}
+	}
+	return -1
+}